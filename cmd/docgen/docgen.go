@@ -14,9 +14,12 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 
+	"golang.org/x/tools/go/packages"
 	yaml "gopkg.in/yaml.v2"
 	"mvdan.cc/gofumpt/format"
 )
@@ -157,9 +160,13 @@ type structType struct {
 	text *Text
 	pos  token.Pos
 	node *ast.StructType
+	// file and dir are the file declaring the struct and its directory,
+	// used to resolve the structs it embeds from other packages.
+	file *ast.File
+	dir  string
 }
 
-func collectStructs(node ast.Node) []*structType {
+func collectStructs(file *ast.File, dir string) []*structType {
 	structs := []*structType{}
 
 	collectStructs := func(n ast.Node) bool {
@@ -206,6 +213,8 @@ func collectStructs(node ast.Node) []*structType {
 				text: text,
 				node: x,
 				pos:  x.Pos(),
+				file: file,
+				dir:  dir,
 			}
 
 			structs = append(structs, s)
@@ -214,7 +223,7 @@ func collectStructs(node ast.Node) []*structType {
 		return true
 	}
 
-	ast.Inspect(node, collectStructs)
+	ast.Inspect(file, collectStructs)
 
 	return structs
 }
@@ -302,11 +311,19 @@ func collectFields(s *structType) (fields []*Field) {
 	fields = []*Field{}
 
 	for _, f := range s.node.Fields.List {
-		if f.Tag == nil {
-			if f.Names == nil {
-				// This is an embedded struct.
+		if len(f.Names) == 0 {
+			// This is an embedded struct.
+			if f.Tag == nil || (f.Doc != nil && strings.Contains(f.Doc.Text(), "docgen:nodoc")) {
+				continue
+			}
+
+			embedded, ok := embeddedStruct(s, f.Type)
+			if !ok {
 				continue
 			}
+			embeddedFields := collectFields(embedded)
+			fields = append(fields, embeddedFields...)
+			continue
 		}
 
 		if f.Doc == nil {
@@ -317,25 +334,6 @@ func collectFields(s *structType) (fields []*Field) {
 			continue
 		}
 
-		if len(f.Names) == 0 {
-			gotStruct, ok := f.Type.(*ast.Ident)
-			if !ok {
-				continue
-			}
-			typeSpec, ok := gotStruct.Obj.Decl.(*ast.TypeSpec)
-			if !ok {
-				continue
-			}
-			structData := typeSpec.Type.(*ast.StructType)
-			if !ok {
-				continue
-			}
-			log.Printf("got embedded struct: %+v\n", structData)
-
-			embeddedFields := collectFields(&structType{node: structData})
-			fields = append(fields, embeddedFields...)
-			continue
-		}
 		name := f.Names[0].Name
 
 		fieldType := formatFieldType(f.Type)
@@ -369,6 +367,127 @@ func collectFields(s *structType) (fields []*Field) {
 	return fields
 }
 
+// embeddedStruct returns the struct of the type embedded in s, following
+// the imports of its file for structs declared in other packages.
+func embeddedStruct(s *structType, expr ast.Expr) (*structType, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if t.Obj == nil {
+			return nil, false
+		}
+		typeSpec, ok := t.Obj.Decl.(*ast.TypeSpec)
+		if !ok {
+			return nil, false
+		}
+		structData, ok := typeSpec.Type.(*ast.StructType)
+		if !ok {
+			return nil, false
+		}
+		return &structType{name: t.Name, node: structData, file: s.file, dir: s.dir}, true
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil, false
+		}
+		return importedStruct(s, pkg.Name, t.Sel.Name)
+	default:
+		return nil, false
+	}
+}
+
+// importedStruct returns the struct with the name declared in the package
+// imported as pkgName by the file of s.
+func importedStruct(s *structType, pkgName, name string) (*structType, bool) {
+	for _, spec := range s.file.Imports {
+		if spec.Name != nil && spec.Name.Name != pkgName {
+			continue
+		}
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		pkg, err := loadPackage(s.dir, importPath)
+		if err != nil {
+			log.Printf("could not load package %s: %s", importPath, err)
+			continue
+		}
+		if (spec.Name == nil && pkg.Name != pkgName) || len(pkg.GoFiles) == 0 {
+			continue
+		}
+
+		dir := filepath.Dir(pkg.GoFiles[0])
+		for _, file := range pkg.Syntax {
+			for _, imported := range collectStructs(file, dir) {
+				if imported.name == name {
+					return imported, true
+				}
+			}
+		}
+	}
+
+	log.Printf("could not resolve embedded struct %s.%s", pkgName, name)
+	return nil, false
+}
+
+// loadedPackage is a package loaded by loadPackage.
+type loadedPackage struct {
+	once sync.Once
+	pkg  *packages.Package
+	err  error
+}
+
+var (
+	loadMutex sync.Mutex
+	// loaded caches the packages loaded by loadPackage by import path.
+	loaded = map[string]*loadedPackage{}
+)
+
+// loadPackage parses the package imported from the directory at the import
+// path, along with its comments. Every package is loaded at most once.
+func loadPackage(dir, importPath string) (*packages.Package, error) {
+	loadMutex.Lock()
+	l, ok := loaded[importPath]
+	if !ok {
+		l = &loadedPackage{}
+		loaded[importPath] = l
+	}
+	loadMutex.Unlock()
+
+	l.once.Do(func() {
+		l.pkg, l.err = parsePackage(dir, importPath)
+	})
+	return l.pkg, l.err
+}
+
+// parsePackage parses the package imported from the directory at the
+// import path.
+func parsePackage(dir, importPath string) (*packages.Package, error) {
+	config := &packages.Config{
+		Dir:  dir,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		// keep the object resolution, used to resolve local embedded structs
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			return parser.ParseFile(fset, filename, src, parser.ParseComments)
+		},
+	}
+	pkgs, err := packages.Load(config, importPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package, got %d", len(pkgs))
+	}
+	if len(pkgs[0].Errors) > 0 {
+		return nil, pkgs[0].Errors[0]
+	}
+	return pkgs[0], nil
+}
+
 func render(doc *Doc, dest string) {
 	t := template.Must(template.New("docfile.tpl").Parse(tpl))
 	buf := bytes.Buffer{}
@@ -424,7 +543,7 @@ func main() {
 
 	fmt.Printf("parsing file in package %q: %s\n", packageName, tokenFile.Name())
 
-	structs = append(structs, collectStructs(node)...)
+	structs = append(structs, collectStructs(node, filepath.Dir(abs))...)
 
 	if len(structs) == 0 {
 		log.Fatalf("failed to find types that could be documented in %s", abs)
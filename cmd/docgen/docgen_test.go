@@ -0,0 +1,31 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectEmbeddedExternalFields(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("dstdocgen", "testdata", "embed", "embed.go"))
+	require.NoError(t, err)
+
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+	require.NoError(t, err)
+
+	structs := collectStructs(file, filepath.Dir(path))
+	require.Len(t, structs, 1)
+
+	var tags []string
+	for _, field := range collectFields(structs[0]) {
+		tags = append(tags, field.Tag)
+	}
+	require.Equal(t, []string{"timeout", "retries", "name", "interval"}, tags)
+}
@@ -0,0 +1,106 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// badgeLabel is the label shown on the left side of coverage badges.
+const badgeLabel = "docs"
+
+// shieldsEndpoint is the response format of shields.io endpoint badges.
+//
+// See https://shields.io/badges/endpoint-badge.
+type shieldsEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// badgeColor returns the shields.io color name and hex value of the
+// coverage percentage.
+func badgeColor(percent float64) (name, hex string) {
+	switch {
+	case percent >= 90:
+		return "brightgreen", "#4c1"
+	case percent >= 75:
+		return "green", "#97ca00"
+	case percent >= 60:
+		return "yellow", "#dfb317"
+	case percent >= 40:
+		return "orange", "#fe7d37"
+	default:
+		return "red", "#e05d44"
+	}
+}
+
+func badgeMessage(percent float64) string {
+	return fmt.Sprintf("%.0f%%", percent)
+}
+
+// renderShields renders the description coverage as a shields.io
+// endpoint JSON.
+func renderShields(doc *Doc) ([]byte, error) {
+	percent := computeCoverage(doc).DescriptionPercent()
+	color, _ := badgeColor(percent)
+
+	return json.MarshalIndent(&shieldsEndpoint{
+		SchemaVersion: 1,
+		Label:         badgeLabel,
+		Message:       badgeMessage(percent),
+		Color:         color,
+	}, "", "  ")
+}
+
+var badgeTemplate = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{ .Width }}" height="20" role="img" aria-label="{{ .Label }}: {{ .Message }}">
+  <title>{{ .Label }}: {{ .Message }}</title>
+  <linearGradient id="s" x2="0" y2="100%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r">
+    <rect width="{{ .Width }}" height="20" rx="3" fill="#fff"/>
+  </clipPath>
+  <g clip-path="url(#r)">
+    <rect width="{{ .LabelWidth }}" height="20" fill="#555"/>
+    <rect x="{{ .LabelWidth }}" width="{{ .MessageWidth }}" height="20" fill="{{ .Color }}"/>
+    <rect width="{{ .Width }}" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="{{ .LabelX }}" y="14">{{ .Label }}</text>
+    <text x="{{ .MessageX }}" y="14">{{ .Message }}</text>
+  </g>
+</svg>
+`))
+
+// renderBadge renders the description coverage as a flat SVG badge.
+func renderBadge(doc *Doc) ([]byte, error) {
+	percent := computeCoverage(doc).DescriptionPercent()
+	_, color := badgeColor(percent)
+	message := badgeMessage(percent)
+
+	// approximate the text widths as verdana averages about 7px per
+	// character at 11px, padded by 5px on both sides
+	labelWidth := len(badgeLabel)*7 + 10
+	messageWidth := len(message)*7 + 10
+
+	var buf bytes.Buffer
+	err := badgeTemplate.Execute(&buf, map[string]interface{}{
+		"Label":        badgeLabel,
+		"Message":      message,
+		"Color":        color,
+		"Width":        labelWidth + messageWidth,
+		"LabelWidth":   labelWidth,
+		"MessageWidth": messageWidth,
+		"LabelX":       float64(labelWidth) / 2,
+		"MessageX":     float64(labelWidth) + float64(messageWidth)/2,
+	})
+	return buf.Bytes(), err
+}
@@ -0,0 +1,201 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheVersion is part of every cache key and has to be bumped whenever
+// the cached structures change in an incompatible way.
+const cacheVersion = "31"
+
+// cacheEntry is the serialized form of a collection. Collections failing
+// with an error, such as a root structure marked with docgen:nodoc or
+// unresolved embedded structs, are never cached.
+type cacheEntry struct {
+	Structures []*cachedStruct
+	// Diagnostics are the diagnostics recorded while collecting, replayed
+	// on a cache hit.
+	Diagnostics []diagnostic
+}
+
+// cachedStruct is the serialized form of a collected structure.
+type cachedStruct struct {
+	Name          string
+	PackagePrefix string
+	PackageName   string
+	Text          *Text
+	Fields        []*Field
+	PartValues    []Example
+	Undocumented  []string
+	Source        *encoder.Source
+}
+
+// collectCached returns the structures cached for the current package
+// sources, collecting and caching them when missing.
+//
+// The cache key is computed from the go files of the root package and its
+// dependencies within modules, which only requires listing the packages
+// and skips the expensive parsing and type checking on a cache hit.
+func collectCached(dir string) ([]*structType, error) {
+	key, err := cacheKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute cache key")
+	}
+	path := filepath.Join(dir, key+".gob")
+
+	if structures, err := readCache(path); err == nil {
+		return structures, nil
+	} else if !os.IsNotExist(err) {
+		log.Printf("ignoring invalid cache entry %s: %s", path, err)
+	}
+
+	recorded := len(recordedDiagnostics())
+	structures, err := collectStructures()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeCache(dir, path, structures, recordedDiagnostics()[recorded:]); err != nil {
+		return nil, errors.Wrap(err, "could not write cache")
+	}
+	return structures, nil
+}
+
+// cacheKey hashes the flags affecting collection along with the names and
+// contents of the go files of the root package and its module dependencies.
+// Standard library packages are skipped as they change with the toolchain.
+func cacheKey() (string, error) {
+	abs, err := filepath.Abs(*inputPath)
+	if err != nil {
+		return "", err
+	}
+
+	patterns, err := packagePatterns()
+	if err != nil {
+		return "", err
+	}
+
+	pkgs, err := packages.Load(loadConfig(abs, packages.NeedName|packages.NeedFiles|packages.NeedImports|packages.NeedDeps|packages.NeedModule), patterns...)
+	if err != nil {
+		return "", err
+	}
+
+	roots := make(map[*packages.Package]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		roots[pkg] = true
+	}
+
+	var files []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if roots[pkg] || pkg.Module != nil {
+			files = append(files, pkg.GoFiles...)
+		}
+	})
+	sort.Strings(files)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00%s\x00%t\x00%s\x00%t\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%t\x00%t\x00%t\x00%s\x00%s\x00", cacheVersion, abs, *structure, *caseInsensitive, *packagePath, *qualifyNames, *partStruct, *partVar, *buildTags, *targetOS, *targetArch, friendlyTypeFlags.String(), *loadMissing, *preserveMarkdown, *allStructs, *excludeTypes, *includeTypes)
+	if *typeMapFile != "" {
+		files = append(files, *typeMapFile)
+	}
+	for _, file := range files {
+		if err := hashFile(hash, file); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(w, "%s\x00", path)
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func readCache(path string) ([]*structType, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entry cacheEntry
+	if err := gob.NewDecoder(f).Decode(&entry); err != nil {
+		return nil, err
+	}
+	replayDiagnostics(entry.Diagnostics)
+
+	structures := make([]*structType, len(entry.Structures))
+	for i, s := range entry.Structures {
+		structures[i] = &structType{
+			name:              s.Name,
+			packagePrefix:     s.PackagePrefix,
+			packageName:       s.PackageName,
+			text:              s.Text,
+			fields:            s.Fields,
+			requestPartValues: s.PartValues,
+			undocumented:      s.Undocumented,
+			source:            s.Source,
+		}
+	}
+	return structures, nil
+}
+
+// writeCache stores the structures and the diagnostics recorded while
+// collecting them at the path, writing to a temporary file first so that
+// concurrent runs never observe partial entries.
+func writeCache(dir, path string, structures []*structType, recorded []diagnostic) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	entry := cacheEntry{Structures: make([]*cachedStruct, len(structures)), Diagnostics: recorded}
+	for i, s := range structures {
+		entry.Structures[i] = &cachedStruct{
+			Name:          s.name,
+			PackagePrefix: s.packagePrefix,
+			PackageName:   s.packageName,
+			Text:          s.text,
+			Fields:        s.fields,
+			PartValues:    s.requestPartValues,
+			Undocumented:  s.undocumented,
+			Source:        s.source,
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(entry); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,40 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectCached(t *testing.T) {
+	defer func(dir string) { *cacheDir = dir }(*cacheDir)
+	*cacheDir = t.TempDir()
+	defer resetDiagnostics()
+
+	// the first run collects and caches the structures, the second one
+	// reads them back from the cache
+	for run := 0; run < 2; run++ {
+		doc, err := collectFixture(t, "lint", "Config")
+		require.NoError(t, err, "run %d", run)
+
+		require.Equal(t, []string{"Version"}, doc.Structs[0].undocumented, "run %d", run)
+		examples := doc.Structs[0].Fields[0].Text.Examples
+		require.Len(t, examples, 1, "run %d", run)
+		require.Equal(t, 9, examples[0].Position.Line, "run %d", run)
+
+		var rules []string
+		for _, d := range recordedDiagnostics() {
+			rules = append(rules, d.Rule)
+		}
+		require.Equal(t, []string{"undocumented-field"}, rules, "run %d", run)
+	}
+
+	for run := 0; run < 2; run++ {
+		_, err := collectFixture(t, "nodoc", "Hidden")
+		require.EqualError(t, err, "could not document Hidden as it is marked with docgen:nodoc: structure not found", "run %d", run)
+	}
+}
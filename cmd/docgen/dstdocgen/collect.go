@@ -0,0 +1,251 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dave/dst/decorator"
+	"github.com/pkg/errors"
+)
+
+// collectState is the state shared by a single collection run. It
+// deduplicates the collected structures and limits the number of
+// goroutines used for collecting them.
+type collectState struct {
+	mutex sync.Mutex
+	seen  map[string]struct{}
+	slots chan struct{}
+
+	loadMutex sync.Mutex
+	loaded    map[string]*loadedPackage
+
+	// unresolved lists the embedded structures which could not be
+	// resolved, failing the collection.
+	unresolved []string
+	// nodoc lists the structures skipped as they are marked with
+	// docgen:nodoc.
+	nodoc []string
+}
+
+// newCollectState returns a new collection state using up to the
+// provided number of workers.
+func newCollectState(workers int) *collectState {
+	if workers < 1 {
+		workers = 1
+	}
+	return &collectState{
+		seen:   make(map[string]struct{}),
+		loaded: make(map[string]*loadedPackage),
+		// the calling goroutine acts as a worker as well
+		slots: make(chan struct{}, workers-1),
+	}
+}
+
+// claim marks the structure name as collected, returning false if
+// it was already claimed before.
+func (c *collectState) claim(name string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.seen[name]; ok {
+		return false
+	}
+	c.seen[name] = struct{}{}
+	return true
+}
+
+// unresolvedEmbed records an embedded structure which could not be resolved.
+func (c *collectState) unresolvedEmbed(typeName, structName string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.unresolved = append(c.unresolved, fmt.Sprintf("%s embedded in %s", typeName, structName))
+}
+
+// skipNodoc records a structure skipped as it is marked with docgen:nodoc.
+func (c *collectState) skipNodoc(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.nodoc = append(c.nodoc, name)
+}
+
+// skippedNodoc returns true if a structure matching the name was skipped
+// as it is marked with docgen:nodoc.
+func (c *collectState) skippedNodoc(name string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, skipped := range c.nodoc {
+		if matchStructName(name, skipped) {
+			return true
+		}
+	}
+	return false
+}
+
+// err returns an error listing the unresolved embedded structures, if any.
+func (c *collectState) err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.unresolved) == 0 {
+		return nil
+	}
+	sort.Strings(c.unresolved)
+	return errors.Errorf("could not resolve embedded structs: %s", strings.Join(c.unresolved, ", "))
+}
+
+// importPackage returns the package imported by pkg at the path. With
+// -load-missing, packages missing from the imports, such as packages of
+// modules only required indirectly, are loaded on demand and reused for
+// the rest of the run.
+func (c *collectState) importPackage(pkg *decorator.Package, path string) (*decorator.Package, bool) {
+	if imported, ok := pkg.Imports[path]; ok {
+		return imported, true
+	}
+	if !*loadMissing {
+		return nil, false
+	}
+
+	// only the lookup is locked, so that packages at other paths are
+	// loaded concurrently while workers needing the same package wait
+	// for a single load
+	c.loadMutex.Lock()
+	loaded, ok := c.loaded[path]
+	if !ok {
+		loaded = &loadedPackage{}
+		c.loaded[path] = loaded
+	}
+	c.loadMutex.Unlock()
+
+	// failures are remembered as well to load every package at most once
+	loaded.once.Do(func() {
+		loaded.pkg = loadMissingPackage(path)
+	})
+	return loaded.pkg, loaded.pkg != nil
+}
+
+// loadedPackage is a package loaded on demand by importPackage.
+type loadedPackage struct {
+	once sync.Once
+	pkg  *decorator.Package
+}
+
+// loadMissingPackage loads the package at the path, returning nil if it
+// could not be loaded.
+var loadMissingPackage = func(path string) *decorator.Package {
+	pkgs, err := loadPackages(path)
+	if err == nil && len(pkgs) != 1 {
+		err = errors.Errorf("expected one package, got %d", len(pkgs))
+	} else if err == nil && len(pkgs[0].Errors) > 0 {
+		err = pkgs[0].Errors[0]
+	}
+	if err != nil {
+		log.Printf("could not load missing package %s: %s", path, err)
+		return nil
+	}
+	return pkgs[0]
+}
+
+// run calls fn for every index in [0, n) and waits for all the calls to
+// return. Calls are handed to idle workers when available and run inline
+// otherwise, so that nested calls can never deadlock waiting for a worker.
+func (c *collectState) run(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		select {
+		case c.slots <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-c.slots }()
+
+				fn(i)
+			}(i)
+		default:
+			fn(i)
+		}
+	}
+	wg.Wait()
+}
+
+// sortStructures sorts all but the first, main structure by name so that
+// the output does not depend on the order in which workers collected them.
+// With -all, the structure named by -structure, if any, is moved first to
+// become the main structure. With the positional form, the structures keep
+// the order in which the input file declares them, as with the Talos docgen.
+func sortStructures(structures []*structType) {
+	if len(structures) < 2 {
+		return
+	}
+	if declarationOrder != nil {
+		sort.SliceStable(structures, func(i, j int) bool {
+			return declarationOrder[structures[i].name] < declarationOrder[structures[j].name]
+		})
+		return
+	}
+	rest := structures[1:]
+	if *allStructs {
+		// without a main structure, all the structures are sorted
+		rest = structures
+		for i, s := range structures {
+			if s.name == structureName() {
+				copy(structures[1:i+1], structures[:i])
+				structures[0] = s
+				rest = structures[1:]
+				break
+			}
+		}
+	}
+	sort.SliceStable(rest, func(i, j int) bool {
+		return wrapStructName(rest[i].packagePrefix, rest[i].name) < wrapStructName(rest[j].packagePrefix, rest[j].name)
+	})
+}
+
+// excludeTypesPattern and includeTypesPattern are the compiled patterns of
+// the -exclude-types and -include-types flags, nil if not set.
+var excludeTypesPattern, includeTypesPattern *regexp.Regexp
+
+// compileTypeFilters compiles the patterns of the type filter flags.
+func compileTypeFilters() error {
+	excludeTypesPattern, includeTypesPattern = nil, nil
+	if *excludeTypes != "" {
+		pattern, err := regexp.Compile(*excludeTypes)
+		if err != nil {
+			return errors.Wrap(err, "invalid -exclude-types")
+		}
+		excludeTypesPattern = pattern
+	}
+	if *includeTypes != "" {
+		pattern, err := regexp.Compile(*includeTypes)
+		if err != nil {
+			return errors.Wrap(err, "invalid -include-types")
+		}
+		includeTypesPattern = pattern
+	}
+	return nil
+}
+
+// filteredType returns true if the struct declared in the package at
+// pkgPath is excluded from the documentation by the type filters. Like
+// structs marked with docgen:nodoc, the structures only it references are
+// excluded as well.
+func filteredType(name, pkgPath string) bool {
+	matches := func(pattern *regexp.Regexp) bool {
+		return pattern.MatchString(name) || pattern.MatchString(pkgPath+"."+name)
+	}
+	if excludeTypesPattern != nil && matches(excludeTypesPattern) {
+		return true
+	}
+	return includeTypesPattern != nil && !matches(includeTypesPattern)
+}
@@ -0,0 +1,203 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/stretchr/testify/require"
+)
+
+// collectFixture collects the documentation of the structure declared by
+// the package in the testdata directory.
+func collectFixture(t *testing.T, dir, name string) (*Doc, error) {
+	t.Helper()
+
+	defer func(path, name string, w io.Writer) {
+		*inputPath, *structure, progress = path, name, w
+	}(*inputPath, *structure, progress)
+	*inputPath, *structure, progress = filepath.Join("testdata", dir), name, io.Discard
+
+	return collect()
+}
+
+func TestUndocumentedFields(t *testing.T) {
+	doc, err := collectFixture(t, "lint", "Config")
+	require.NoError(t, err)
+	require.Len(t, doc.Structs, 1)
+	require.Equal(t, []string{"Version"}, doc.Structs[0].undocumented, "unexported fields are not documented")
+}
+
+func TestTaggedStructNames(t *testing.T) {
+	file, err := decorator.Parse(`package config
+
+type Server struct {
+	Port int ` + "`yaml:\"port\"`" + `
+}
+
+type (
+	Client struct {
+		Host string ` + "`yaml:\"host\"`" + `
+	}
+	state struct {
+		Done bool ` + "`yaml:\"done\"`" + `
+	}
+	Untagged struct {
+		Name string
+	}
+)
+
+// Hosts is a host or a list of hosts.
+//
+// docgen:unmarshaler
+type Hosts struct {
+	List []string ` + "`yaml:\"list\"`" + `
+}
+`)
+	require.NoError(t, err)
+
+	pkg := &decorator.Package{Syntax: []*dst.File{file}}
+	require.Equal(t, []string{"Server", "Client"}, taggedStructNames(pkg))
+}
+
+func TestSortStructuresAll(t *testing.T) {
+	*allStructs = true
+	defer func() {
+		*allStructs = false
+		*structure = ""
+	}()
+
+	names := func(structures []*structType) []string {
+		var names []string
+		for _, s := range structures {
+			names = append(names, s.name)
+		}
+		return names
+	}
+
+	structures := []*structType{{name: "Server"}, {name: "Client"}, {name: "Auth"}}
+	sortStructures(structures)
+	require.Equal(t, []string{"Auth", "Client", "Server"}, names(structures))
+
+	*structure = "Server"
+	structures = []*structType{{name: "Client"}, {name: "Server"}, {name: "Auth"}}
+	sortStructures(structures)
+	require.Equal(t, []string{"Server", "Auth", "Client"}, names(structures))
+}
+
+func TestFilteredType(t *testing.T) {
+	defer func() {
+		*excludeTypes, *includeTypes = "", ""
+		require.NoError(t, compileTypeFilters())
+	}()
+
+	*excludeTypes = `(State|Cache)$`
+	require.NoError(t, compileTypeFilters())
+	require.True(t, filteredType("ProtocolState", "github.com/x/y/protocols"))
+	require.False(t, filteredType("Request", "github.com/x/y/protocols"))
+
+	*excludeTypes, *includeTypes = "", `^github\.com/x/y/protocols\.`
+	require.NoError(t, compileTypeFilters())
+	require.False(t, filteredType("Request", "github.com/x/y/protocols"))
+	require.True(t, filteredType("Options", "github.com/x/y/internal"))
+
+	*includeTypes = "("
+	require.EqualError(t, compileTypeFilters(), "invalid -include-types: error parsing regexp: missing closing ): `(`")
+}
+
+func TestSecretDirective(t *testing.T) {
+	documentation, secret := secretDirective("description: |\n  API key of the provider.\ndocgen:secret")
+	require.True(t, secret)
+	require.Equal(t, "description: |\n  API key of the provider.", documentation)
+
+	_, secret = secretDirective("API key of the provider, see docgen:secret.")
+	require.False(t, secret)
+}
+
+func TestNameDirective(t *testing.T) {
+	tests := []struct {
+		documentation string
+		expected      string
+		name          string
+	}{
+		{documentation: "Hosts to connect to.\ndocgen:name=hosts", expected: "Hosts to connect to.", name: "hosts"},
+		{documentation: "  docgen:name= hosts \nHosts to connect to.", expected: "Hosts to connect to.", name: "hosts"},
+		{documentation: "description: |\n  Hosts to connect to.\n  docgen:name=hosts", expected: "description: |\n  Hosts to connect to.", name: "hosts"},
+		{documentation: "Hosts to connect to, see docgen:name=hosts.", expected: "Hosts to connect to, see docgen:name=hosts."},
+		{documentation: "Hosts to connect to.", expected: "Hosts to connect to."},
+	}
+	for _, test := range tests {
+		documentation, name := nameDirective(test.documentation)
+		require.Equal(t, test.expected, documentation, test.documentation)
+		require.Equal(t, test.name, name, test.documentation)
+	}
+}
+
+func TestNamedFields(t *testing.T) {
+	doc, err := collectFixture(t, "names", "Config")
+	require.NoError(t, err)
+
+	var tags []string
+	for _, field := range doc.Structs[0].Fields {
+		tags = append(tags, field.Tag)
+	}
+	require.Equal(t, []string{"hosts", "port", "name"}, tags)
+	require.Equal(t, "Hosts to connect to, a host or a list of hosts.", doc.Structs[0].Fields[0].Text.Description)
+}
+
+func TestEmbeddedExternalStruct(t *testing.T) {
+	doc, err := collectFixture(t, "embed", "Config")
+	require.NoError(t, err)
+	require.Len(t, doc.Structs, 1, "inlined structs are not documented separately")
+
+	var tags []string
+	for _, field := range doc.Structs[0].Fields {
+		tags = append(tags, field.Tag)
+	}
+	require.Equal(t, []string{"timeout", "retries", "name", "interval"}, tags)
+	require.Equal(t, "Timeout of the requests.", doc.Structs[0].Fields[0].Text.Description)
+}
+
+func TestUnresolvedEmbeddedStruct(t *testing.T) {
+	require.NotPanics(t, func() {
+		_, err := collectFixture(t, "unresolved", "Config")
+		require.EqualError(t, err, "could not resolve embedded structs: Runner embedded in Config, io.Reader embedded in Config")
+	})
+}
+
+func TestCollectErrors(t *testing.T) {
+	_, err := collectFixture(t, "lint", "Missing")
+	require.ErrorIs(t, err, ErrStructNotFound)
+
+	_, err = collectFixture(t, "empty", "Config")
+	require.ErrorIs(t, err, ErrNoFields)
+	require.EqualError(t, err, "could not document Config: structure has no fields to document")
+}
+
+func TestInlineEmbed(t *testing.T) {
+	require.True(t, inlineEmbed(`mapstructure:",squash"`))
+	require.True(t, inlineEmbed(`yaml:",inline"`))
+	require.False(t, inlineEmbed(`mapstructure:"base"`))
+}
+
+func TestNodocStructs(t *testing.T) {
+	doc, err := collectFixture(t, "nodoc", "Config")
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range doc.Structs {
+		names = append(names, s.GetName())
+	}
+	require.Equal(t, []string{"Config", "Options"}, names, "State and the Cursor it references are skipped")
+	require.Len(t, doc.Structs[0].Fields, 3, "fields of internal types are still documented")
+
+	_, err = collectFixture(t, "nodoc", "Hidden")
+	require.ErrorIs(t, err, ErrStructNotFound)
+	require.EqualError(t, err, "could not document Hidden as it is marked with docgen:nodoc: structure not found")
+}
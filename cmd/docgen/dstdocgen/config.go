@@ -0,0 +1,121 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// configFile lists the generation targets of a -config file. Every target
+// maps flag names, such as path, structure, output, package and format, to
+// their values. Flags given on the command line apply to all the targets
+// unless overridden.
+type configFile struct {
+	Targets []map[string]interface{} `yaml:"targets"`
+}
+
+// loadConfigFile reads the targets of the -config file.
+func loadConfigFile(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read config file")
+	}
+
+	config := &configFile{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, errors.Wrap(err, "could not parse config file")
+	}
+	if len(config.Targets) == 0 {
+		return nil, fmt.Errorf("no targets in config file %s", path)
+	}
+	return config, nil
+}
+
+// runConfig returns a function calling run once for every target of the
+// config file at path, with the flags of the target set.
+func runConfig(path string, run func() error) func() error {
+	return func() error {
+		config, err := loadConfigFile(path)
+		if err != nil {
+			return err
+		}
+
+		for i, target := range config.Targets {
+			restore, err := setFlags(target)
+			if err == nil {
+				fmt.Fprintf(progress, "generating target %d: %s\n", i+1, targetName())
+				err = run()
+			}
+			restore()
+			if err != nil {
+				return errors.Wrapf(err, "target %d", i+1)
+			}
+		}
+		return nil
+	}
+}
+
+// targetName describes the target configured by the flags.
+func targetName() string {
+	if *output == "" {
+		return fmt.Sprintf("%s %s", *inputPath, *structure)
+	}
+	return fmt.Sprintf("%s %s to %s", *inputPath, *structure, *output)
+}
+
+// setFlags sets the flags of a target and returns a function restoring
+// their previous values. Lists set repeatable flags once per item.
+func setFlags(settings map[string]interface{}) (func(), error) {
+	var restores []func()
+	restore := func() {
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := flag.Lookup(name)
+		if f == nil || name == "config" || name == "watch" {
+			return restore, fmt.Errorf("unknown flag %q", name)
+		}
+		restores = append(restores, saveFlag(f))
+
+		values, ok := settings[name].([]interface{})
+		if !ok {
+			values = []interface{}{settings[name]}
+		}
+		for _, value := range values {
+			if value == nil {
+				value = ""
+			}
+			if err := f.Value.Set(fmt.Sprint(value)); err != nil {
+				return restore, errors.Wrapf(err, "invalid value for flag %q", name)
+			}
+		}
+	}
+	return restore, nil
+}
+
+// saveFlag returns a function restoring the current value of the flag.
+func saveFlag(f *flag.Flag) func() {
+	if friendly, ok := f.Value.(*friendlyTypeFlag); ok {
+		saved := append(friendlyTypeFlag(nil), *friendly...)
+		return func() { *friendly = saved }
+	}
+	saved := f.Value.String()
+	return func() { _ = f.Value.Set(saved) }
+}
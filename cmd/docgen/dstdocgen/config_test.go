@@ -0,0 +1,54 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".docgen.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`targets:
+  - path: ./config
+    structure: Config
+    output: config_doc.go
+    package: config
+  - path: ./server
+    structure: Server
+    format: schema
+    all: true
+    friendly-type: [time.Duration=duration, net.IP=ip]
+`), 0o600))
+
+	type target struct {
+		path, structure, output, packageName, format string
+		all                                          bool
+		friendlyTypes                                []string
+	}
+	var targets []target
+	err := runConfig(path, func() error {
+		targets = append(targets, target{*inputPath, *structure, *output, *packageName, *outputFormat, *allStructs, append([]string(nil), friendlyTypeFlags...)})
+		return nil
+	})()
+	require.NoError(t, err)
+	require.Equal(t, []target{
+		{"./config", "Config", "config_doc.go", "config", "go", false, nil},
+		{"./server", "Server", "", "main", "schema", true, []string{"time.Duration=duration", "net.IP=ip"}},
+	}, targets)
+
+	// the flags are restored after every target
+	require.Equal(t, "", *inputPath)
+	require.Equal(t, "go", *outputFormat)
+	require.False(t, *allStructs)
+	require.Empty(t, friendlyTypeFlags)
+
+	require.NoError(t, os.WriteFile(path, []byte("targets:\n  - path: .\n    watch: true\n"), 0o600))
+	err = runConfig(path, func() error { return nil })()
+	require.EqualError(t, err, `target 1: unknown flag "watch"`)
+}
@@ -0,0 +1,46 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Diagram is a diagram referenced from a struct comment with the
+// `diagram:` key. All the values are escaped for the generated code.
+type Diagram struct {
+	Path    string
+	Mermaid string
+	Data    string
+}
+
+// loadDiagram loads the diagram at the path relative to the input path.
+// The source of Mermaid diagrams is embedded, other files such as images
+// are embedded as well to be copied into documentation sites.
+func loadDiagram(path string) (*Diagram, error) {
+	file := path
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(*inputPath, file)
+	}
+
+	diagram := &Diagram{Path: escape(path)}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".mmd", ".mermaid":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		diagram.Mermaid = escape(string(data))
+	default:
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		diagram.Data = quote(string(data))
+	}
+	return diagram, nil
+}
@@ -0,0 +1,41 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadImageDiagram(t *testing.T) {
+	dir := t.TempDir()
+	image := []byte("\x89PNG\r\n\x1a\n\x00\xff`\"\\")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.png"), image, 0o600))
+
+	defer func(path string) { *inputPath = path }(*inputPath)
+	*inputPath = dir
+
+	diagram, err := loadDiagram("config.png")
+	require.NoError(t, err)
+	require.Empty(t, diagram.Mermaid)
+
+	doc := &Doc{
+		Name:    "Config",
+		Package: "main",
+		Structs: []*Struct{{name: "Config", Text: &Text{}, Diagram: diagram}},
+	}
+	_, err = renderGo(doc)
+	require.NoError(t, err)
+
+	d := doc.toFileDoc().Structs[0].Diagram
+	require.Equal(t, "config.png", d.Path)
+	require.Equal(t, image, d.Data)
+
+	_, err = loadDiagram("missing.png")
+	require.Error(t, err)
+}
@@ -0,0 +1,110 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+)
+
+// diffCommand collects the documentation for the structure at two git refs
+// and prints the changes as a changelog.
+func diffCommand(args []string) error {
+	fs := newFlagSet("diff")
+	oldRef := fs.String("old", "", "Git ref of the old version")
+	newRef := fs.String("new", "", "Git ref of the new version, the working tree by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *oldRef == "" {
+		return fmt.Errorf("missing -old ref")
+	}
+
+	oldDoc, err := collectAtRef(*oldRef)
+	if err != nil {
+		return errors.Wrapf(err, "could not collect documentation at %s", *oldRef)
+	}
+	newDoc, err := collectAtRef(*newRef)
+	if err != nil {
+		return errors.Wrapf(err, "could not collect documentation at %s", *newRef)
+	}
+
+	diff := encoder.Diff(oldDoc, newDoc)
+	if diff.Empty() {
+		fmt.Println("no changes")
+		return nil
+	}
+	fmt.Print(diff.Changelog())
+	return nil
+}
+
+// collectAtRef collects the documentation from a temporary git worktree
+// checked out at the ref, or from the working tree for an empty ref.
+func collectAtRef(ref string) (*encoder.FileDoc, error) {
+	if ref == "" {
+		doc, err := collect()
+		if err != nil {
+			return nil, err
+		}
+		return doc.toFileDoc(), nil
+	}
+
+	abs, err := filepath.Abs(*inputPath)
+	if err != nil {
+		return nil, err
+	}
+	root, err := git(abs, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "docgen-diff-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := git(root, "worktree", "add", "--detach", dir, ref); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = git(root, "worktree", "remove", "--force", dir)
+	}()
+
+	// collect from the worktree, skipping the cache which is keyed by the
+	// temporary path
+	previousPath, previousCache := *inputPath, *cacheDir
+	*inputPath, *cacheDir = filepath.Join(dir, rel), ""
+	defer func() {
+		*inputPath, *cacheDir = previousPath, previousCache
+	}()
+
+	doc, err := collect()
+	if err != nil {
+		return nil, err
+	}
+	return doc.toFileDoc(), nil
+}
+
+// git runs the git command in the directory, returning its trimmed output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -7,31 +7,75 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"go/token"
+	"io"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 	"unicode"
 
 	"github.com/dave/dst"
 	"github.com/dave/dst/decorator"
 	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/encoder"
 	"golang.org/x/tools/go/packages"
 	"gopkg.in/yaml.v2"
 	"mvdan.cc/gofumpt/format"
 )
 
 var (
-	inputPath   = flag.String("path", "", "Root Path to Generate Documentation From")
-	structure   = flag.String("structure", "", "Structure Name to Generate Documentation From")
-	output      = flag.String("output", "", "File to write generated documentation code to")
-	packageName = flag.String("package", "main", "Name of the package for auto-generated code")
+	inputPath        = flag.String("path", "", "Root Path to Generate Documentation From")
+	structure        = flag.String("structure", "", "Structure Name to Generate Documentation From")
+	excludeTypes     = flag.String("exclude-types", "", "Regular expression of the structs which are not documented, matched against their name and their name qualified with the import path")
+	includeTypes     = flag.String("include-types", "", "Regular expression of the only structs which are documented besides the root structure, matched like -exclude-types")
+	allStructs       = flag.Bool("all", false, "Document every exported struct with yaml tagged fields of the package, named by -structure or the package name")
+	output           = flag.String("output", "", "File to write generated documentation code to, - for stdout")
+	packageName      = flag.String("package", "main", "Name of the package for auto-generated code")
+	templateFile     = flag.String("template", "", "Custom text/template file to use instead of the built-in template")
+	dialects         = flag.String("dialects", "", "Comma separated dialects rendered alongside YAML in the documentation (json, toml)")
+	outputFormat     = flag.String("format", "go", "Output format to generate (go, badge, catalog, completion, dictionary, dot, json, md, md-toml, mdx, mermaid, openapi, paths, schema, shields, snippets, tool)")
+	mdxTitle         = flag.String("mdx-title", "", "Title written to the frontmatter of -format mdx pages")
+	mdxSidebar       = flag.Int("mdx-sidebar-position", 0, "Sidebar position written to the frontmatter of -format mdx pages")
+	apiVersion       = flag.String("api-version", "1.0.0", "API version written to the info of -format openapi documents")
+	catalogURL       = flag.String("catalog-url", "", "URL the JSON Schema is published at, written to -format catalog entries")
+	fileMatch        = flag.String("file-match", "", "Comma separated glob patterns of the files validated by the schema, written to -format catalog entries")
+	workers          = flag.Int("workers", runtime.NumCPU(), "Number of workers used to collect structures")
+	watchMode        = flag.Bool("watch", false, "Watch the input path for changes and regenerate automatically")
+	watchInterval    = flag.Duration("watch-interval", time.Second, "Interval between checks for changes in -watch mode")
+	lintMode         = flag.Bool("lint", false, "Report fields missing documentation instead of generating code")
+	lintThreshold    = flag.Float64("lint-threshold", 0, "Minimum documentation coverage percentage required by -lint")
+	docsURLs         = flag.String("docs-urls", "", "YAML file mapping type names and type.field paths to documentation URLs")
+	glossaryFile     = flag.String("glossary", "", "YAML file mapping glossary terms to their definitions")
+	strict           = flag.Bool("strict", false, "Fail generation when inline examples do not validate against their types")
+	caseInsensitive  = flag.Bool("case-insensitive", false, "Match the structure name ignoring case")
+	cacheDir         = flag.String("cache-dir", "", "Directory caching collected structures keyed by a hash of the package sources")
+	packagePath      = flag.String("package-path", "", "Import path or pattern (e.g. ./...) of the packages searched for the structure")
+	qualifyNames     = flag.Bool("qualify", false, "Document the structure of every matching package, qualifying names with the package name")
+	partStruct       = flag.String("part-struct", "Request", "Name of the structs documenting the part definitions of the -part-var variable, empty to disable")
+	partVar          = flag.String("part-var", "RequestPartDefinitions", "Name of the variable mapping part names to descriptions for -part-struct structs")
+	rendererCmd      = flag.String("renderer-cmd", "", "External renderer command receiving the documentation as JSON and returning the files written to the -output directory")
+	loadMissing      = flag.Bool("load-missing", false, "Load the packages of referenced types missing from the imports of the loaded packages on demand")
+	typeMapFile      = flag.String("type-map", "", "YAML file mapping types, by import path and name, to the documented type and schema type")
+	buildTags        = flag.String("tags", "", "Comma separated build tags applied when loading packages")
+	targetOS         = flag.String("goos", "", "GOOS applied when loading packages, defaults to the host")
+	targetArch       = flag.String("goarch", "", "GOARCH applied when loading packages, defaults to the host")
+	headerFile       = flag.String("header-file", "", "File containing the license heading the generated code instead of the built-in header")
+	reportFile       = flag.String("report", "", "File to write the generation diagnostics to with their positions, as SARIF if it ends with .sarif and as JSON otherwise")
+	configPath       = flag.String("config", "", "YAML file listing generation targets, each setting flags such as path, structure, output, package and format")
+	preserveMarkdown = flag.Bool("preserve-markdown", false, "Preserve the line structure and indentation of descriptions written as markdown")
 )
 
 type Doc struct {
@@ -40,17 +84,43 @@ type Doc struct {
 	Title   string
 	Header  string
 	File    string
-	Structs []*Struct
+	// FileHeader is the comment heading the generated code, such as the
+	// license.
+	FileHeader string
+	// Command is the docgen invocation generating the code.
+	Command string
+	// SourceHash is the hex encoded sha256 hash of the documented structs,
+	// which changes whenever the generated code is stale.
+	SourceHash string
+	Structs    []*Struct
+	Dialects   []string
+	Glossary   []encoder.GlossaryTerm
+	// DocMethods is set to generate a Doc method on every registered
+	// struct, as the Talos docgen does.
+	DocMethods bool
+}
+
+// EncoderVersion returns the encoder version required by the generated
+// code, which is the version docgen is built with.
+func (d *Doc) EncoderVersion() string {
+	return encoder.Version
 }
 
 type Struct struct {
 	name          string
 	packagePrefix string
+	undocumented  []string
+
+	// Registered is set for structs declared in the package of the
+	// generated code, whose documentation is registered for their type.
+	Registered bool
 
 	Text       *Text
 	Fields     []*Field
 	AppearsIn  []Appearance
 	PartValues []Example
+	Diagram    *Diagram
+	Source     *encoder.Source
 }
 
 // GetName returns the name of the struct. If a package name is provided, it
@@ -59,6 +129,11 @@ func (s *Struct) GetName() string {
 	return wrapStructName(s.packagePrefix, s.name)
 }
 
+// GetRawName returns the name of the struct type without the package name.
+func (s *Struct) GetRawName() string {
+	return s.name
+}
+
 // GetEscapedName returns the GetName result in escaped form for templating
 func (s *Struct) GetEscapedName() string {
 	if s.packagePrefix == "" {
@@ -70,11 +145,35 @@ func (s *Struct) GetEscapedName() string {
 type Appearance struct {
 	Struct    *Struct
 	FieldName string
+	Kind      string
+	Path      string
+}
+
+// BadExample is an invalid value of a field documenting a common mistake.
+type BadExample struct {
+	Name   string `json:"name,omitempty" yaml:"name"`
+	Value  string `json:"value" yaml:"value"`
+	Reason string `json:"reason,omitempty" yaml:"reason"`
 }
 
+// Example is an example of a field or struct, either a go expression or
+// a yaml snippet captured verbatim.
 type Example struct {
 	Name  string `yaml:"name"`
 	Value string `yaml:"value"`
+	YAML  string `yaml:"yaml"`
+
+	// Position is the source position of the documented declaration,
+	// reported when the value does not compile. It is only exported to be
+	// cached along with the example.
+	Position token.Position `json:"-" yaml:"-"`
+}
+
+// EnumValue is a value of an enum type, with the description escaped like
+// the Text fields.
+type EnumValue struct {
+	Value       string
+	Description string
 }
 
 type Field struct {
@@ -84,61 +183,257 @@ type Field struct {
 	Text       *Text
 	Tag        string
 	Note       string
-	EnumFields []string
+	EnumFields []EnumValue
+	Tags       map[string]string
+	Source     *encoder.Source
+	// Pointer is set for fields of pointer types, referencing an optional
+	// value.
+	Pointer bool
+	// Secret is set for fields marked with docgen:secret, which hold
+	// credentials.
+	Secret bool
 }
 
 type Text struct {
-	Comment     string     `json:"-"`
+	Comment string `json:"-"`
+	// Description is stored unescaped, unlike the other keys, and quoted
+	// by the template.
 	Description string     `json:"description"`
 	Examples    []*Example `json:"examples"`
+	Example     string     `json:"-"`
 	Values      []string   `json:"values"`
+	DocsURL     string     `json:"docs-url,omitempty" yaml:"docs-url"`
+	Diagram     string     `json:"diagram,omitempty"`
+	Required    bool       `json:"required,omitempty"`
+	Stability   string     `json:"stability,omitempty"`
+	Default     string     `json:"default,omitempty"`
+	MinItems    int        `json:"min-items,omitempty" yaml:"min-items"`
+	MaxItems    int        `json:"max-items,omitempty" yaml:"max-items"`
+	Unique      bool       `json:"unique,omitempty"`
+	Accepts     []string   `json:"accepts,omitempty"`
+	KeyDoc      string     `json:"keydoc,omitempty" yaml:"keydoc"`
+	ReplacedBy  string     `json:"replaced-by,omitempty" yaml:"replaced-by"`
+
+	PartDefinitions string `json:"part-definitions,omitempty" yaml:"part-definitions"`
+
+	BadExamples []*BadExample `json:"bad-examples,omitempty" yaml:"bad-examples"`
+
+	Discriminator *Discriminator `json:"discriminator,omitempty"`
+}
+
+// Discriminator maps the values of a field to the structs documenting
+// the shape of the value.
+type Discriminator struct {
+	Field   string            `json:"field" yaml:"field"`
+	Mapping map[string]string `json:"mapping" yaml:"mapping"`
+}
+
+var (
+	// ErrStructNotFound is returned when the structure to document is not
+	// declared by the loaded packages.
+	ErrStructNotFound = errors.New("structure not found")
+	// ErrNoFields is returned when the structure to document has no fields
+	// with a yaml key.
+	ErrNoFields = errors.New("structure has no fields to document")
+)
+
+// progress receives the progress messages of the generation.
+var progress io.Writer = os.Stdout
+
+// commands contains the subcommands supported in addition to
+// the default code generation.
+var commands = map[string]func(args []string) error{
+	"diff":         diffCommand,
+	"explain":      explainCommand,
+	"schemaserver": schemaServerCommand,
+	"serve":        serveCommand,
+	"server":       serverCommand,
+	"site":         siteCommand,
+	"validate":     validateCommand,
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				log.Fatalf("FAIL: %s\n", err.Error())
+			}
+			return
+		}
+	}
+
 	flag.Parse()
+	defaults := goGenerateDefaults
+	if flag.NArg() > 0 {
+		defaults = func() error { return positionalArgs(flag.Args()) }
+	}
+	if err := defaults(); err != nil {
+		log.Fatalf("FAIL: %s\n", err.Error())
+	}
+	if *output == stdoutPath {
+		// keep stdout for the generated documentation
+		progress = os.Stderr
+	}
 
-	if err := process(); err != nil {
+	run := process
+	if *lintMode {
+		run = lintCommand
+	}
+	if *configPath != "" {
+		if *watchMode {
+			log.Fatalf("FAIL: -watch is not supported with -config\n")
+		}
+		run = runConfig(*configPath, run)
+	}
+	if *watchMode {
+		run = watch(run)
+	}
+	if err := run(); err != nil {
 		log.Fatalf("FAIL: %s\n", err.Error())
 	}
 }
 
+// newFlagSet returns a flag set for a subcommand sharing all the
+// generation flags.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	flag.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	return fs
+}
+
 // process performs the documentation generation process on the loaded code
+// reporting generation statistics to the encoder metrics.
 func process() error {
-	pkgs, err := loadRootPackage()
+	start := time.Now()
+
+	doc, err := generate()
+	if *reportFile != "" {
+		if reportErr := writeReport(*reportFile); err == nil {
+			err = reportErr
+		}
+	}
+
+	event := &encoder.Event{
+		Kind:     encoder.EventGenerate,
+		Name:     structureName(),
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if doc != nil {
+		event.Structs = len(doc.Structs)
+		for _, s := range doc.Structs {
+			event.Fields += len(s.Fields)
+		}
+	}
+	encoder.Observe(event)
+
+	return err
+}
+
+// generate collects the documentation for the structure and renders it
+func generate() (*Doc, error) {
+	doc, err := collect()
 	if err != nil {
-		return errors.Wrap(err, "could not load packages")
+		return nil, err
+	}
+	if issues := checkExamples(doc); len(issues) > 0 {
+		for _, issue := range issues {
+			log.Printf("stale example: %s", issue)
+		}
+		if *strict {
+			return doc, fmt.Errorf("%d examples do not validate against their types", len(issues))
+		}
+	}
+	if *output != "" || len(extraOutputs()) == 0 {
+		if err := render(doc, *output); err != nil {
+			return doc, errors.Wrap(err, "could not render")
+		}
+	}
+	for _, out := range extraOutputs() {
+		if err := renderFormat(doc, out.format, out.path); err != nil {
+			return doc, errors.Wrapf(err, "could not render %s", out.format)
+		}
 	}
+	return doc, nil
+}
 
-	var structures []*structType
-	// Iterate through all the packages and files loaded for the root structure,
-	// trying to find the main structure for which documentation is to be
-	// created.
-	for _, pkg := range pkgs {
-		main, extra := collectStructsWithOpts(&collectStructOptions{
-			pkg:        pkg,
-			structName: *structure,
-		})
-		if main != nil {
-			structures = append(structures, main)
+// collect loads the packages and collects the documentation for the structure
+func collect() (*Doc, error) {
+	typeMap = nil
+	if *typeMapFile != "" {
+		mapping, err := loadTypeMap(*typeMapFile)
+		if err != nil {
+			return nil, err
 		}
-		structures = append(structures, extra...)
+		typeMap = mapping
+	}
+	if err := compileTypeFilters(); err != nil {
+		return nil, err
 	}
+	resetDiagnostics()
+
+	var structures []*structType
+	var err error
+	// diagnostics are recorded while collecting, so the cache is bypassed
+	// when they are reported
+	if *cacheDir != "" && *reportFile == "" {
+		structures, err = collectCached(*cacheDir)
+	} else {
+		structures, err = collectStructures()
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	if len(structures) == 0 {
-		log.Fatalf("failed to find types that could be documented in %s", *inputPath)
+		if *allStructs {
+			return nil, errors.Wrapf(ErrStructNotFound, "could not document any struct in %s", *inputPath)
+		}
+		return nil, errors.Wrapf(ErrStructNotFound, "could not document %s in %s", *structure, *inputPath)
+	}
+	if main := structures[0]; !*allStructs && len(main.fields) == 0 && len(main.undocumented) == 0 {
+		return nil, errors.Wrapf(ErrNoFields, "could not document %s", wrapStructName(main.packagePrefix, main.name))
 	}
 
+	name := structureName()
+	if name == "" && *allStructs {
+		// the documentation of all the structs is named after the package
+		name = strings.ToUpper(structures[0].packageName[:1]) + structures[0].packageName[1:]
+	}
 	doc := &Doc{
 		Package: *packageName,
-		Name:    *structure,
+		Name:    name,
 		Structs: []*Struct{},
-		File:    *output,
+		File:    goOutputPath(),
+		// the positional form stays compatible with the Talos docgen
+		DocMethods: declarationOrder != nil,
+	}
+	if doc.File == stdoutPath {
+		doc.File = ""
+	}
+	if *dialects != "" {
+		doc.Dialects = strings.Split(*dialects, ",")
+	}
+	doc.Command = invocation(os.Args)
+	if doc.SourceHash, err = sourceHash(structures); err != nil {
+		return nil, errors.Wrap(err, "could not hash structures")
+	}
+	doc.FileHeader = defaultFileHeader
+	if *headerFile != "" {
+		header, err := loadFileHeader(*headerFile)
+		if err != nil {
+			return nil, err
+		}
+		doc.FileHeader = header
 	}
 
 	extraExamples := map[string][]*Example{}
 	backReferences := map[string][]Appearance{}
 
 	for _, s := range structures {
-		fmt.Printf("generating docs for type: %q\n", s.name)
+		fmt.Fprintf(progress, "generating docs for type: %q\n", s.name)
 
 		newStruct := &Struct{
 			name:          s.name,
@@ -146,6 +441,21 @@ func process() error {
 			Text:          s.text,
 			Fields:        s.fields,
 			PartValues:    s.requestPartValues,
+			undocumented:  s.undocumented,
+			Source:        s.source,
+			Registered:    s.packageName == *packageName && (s.packagePrefix == "" || s.packagePrefix == s.packageName),
+		}
+		switch s.text.Stability {
+		case "", encoder.StabilityExperimental, encoder.StabilityBeta, encoder.StabilityStable:
+		default:
+			return nil, fmt.Errorf("invalid stability %q of %s, expected experimental, beta or stable", s.text.Stability, s.name)
+		}
+		if s.text.Diagram != "" {
+			diagram, err := loadDiagram(s.text.Diagram)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not load diagram of %s", s.name)
+			}
+			newStruct.Diagram = diagram
 		}
 
 		for _, field := range s.fields {
@@ -160,6 +470,7 @@ func process() error {
 			backReferences[field.TypeRef] = append(backReferences[field.TypeRef], Appearance{
 				Struct:    newStruct,
 				FieldName: field.Tag,
+				Kind:      encoder.ReferenceKind(field.Type, field.Pointer),
 			})
 		}
 		doc.Structs = append(doc.Structs, newStruct)
@@ -174,14 +485,125 @@ func process() error {
 			s.AppearsIn = append(s.AppearsIn, ref...)
 		}
 	}
-	if err := render(doc, *output); err != nil {
-		return errors.Wrap(err, "could not render")
+	setAppearancePaths(doc)
+
+	if *docsURLs != "" {
+		urls, err := loadDocsURLs(*docsURLs)
+		if err != nil {
+			return nil, err
+		}
+		applyDocsURLs(doc, urls)
+	}
+
+	if *glossaryFile != "" {
+		glossary, err := loadGlossary(*glossaryFile)
+		if err != nil {
+			return nil, err
+		}
+		doc.Glossary = glossary
+	}
+	return doc, nil
+}
+
+// collectStructures loads the packages and collects the structures
+// reachable from the root structure.
+func collectStructures() ([]*structType, error) {
+	pkgs, err := loadRootPackage()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load packages")
+	}
+
+	state := newCollectState(*workers)
+
+	// Iterate through all the packages and files loaded for the root structure,
+	// trying to find the main structure for which documentation is to be
+	// created.
+	mains := make([]*structType, len(pkgs))
+	results := make([][]*structType, len(pkgs))
+	state.run(len(pkgs), func(i int) {
+		opts := &collectStructOptions{
+			state:      state,
+			pkg:        pkgs[i],
+			structName: structureName(),
+			unfiltered: !*allStructs,
+		}
+		if *qualifyNames {
+			opts.packagePrefix = pkgs[i].Name
+		}
+		if *allStructs {
+			results[i] = collectAllStructs(opts)
+			return
+		}
+		mains[i], results[i] = collectStructsWithOpts(opts)
+	})
+	if err := state.err(); err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	var structures []*structType
+	for i, main := range mains {
+		if main != nil {
+			matched = append(matched, pkgs[i].PkgPath+"."+main.name)
+			structures = append(structures, main)
+		}
+	}
+	if len(matched) == 0 && !*allStructs && state.skippedNodoc(structureName()) {
+		return nil, errors.Wrapf(ErrStructNotFound, "could not document %s as it is marked with docgen:nodoc", *structure)
+	}
+	if len(matched) > 1 && !*qualifyNames {
+		return nil, fmt.Errorf("structure %q found in multiple packages: %s; select one with -package-path or a qualified -structure, or pass -qualify to document all of them", structureName(), strings.Join(matched, ", "))
+	}
+	for _, result := range results {
+		structures = append(structures, result...)
+	}
+	if err := checkExampleExpressions(pkgs, structures); err != nil {
+		return nil, err
 	}
-	return nil
+	sortStructures(structures)
+	return structures, nil
+}
+
+// structureName returns the requested structure name without the package
+// path qualifier of -structure.
+func structureName() string {
+	return (*structure)[strings.LastIndex(*structure, ".")+1:]
+}
+
+// packagePatterns returns the package patterns searched for the structure,
+// taken from the qualifier of -structure or -package-path and defaulting
+// to the package of the root path.
+func packagePatterns() ([]string, error) {
+	var patterns []string
+	if i := strings.LastIndex(*structure, "."); i > 0 {
+		patterns = append(patterns, (*structure)[:i])
+	}
+	if *packagePath != "" {
+		if len(patterns) > 0 && patterns[0] != *packagePath {
+			return nil, fmt.Errorf("-package-path %q conflicts with the package of -structure %q", *packagePath, *structure)
+		}
+		patterns = []string{*packagePath}
+	}
+	return patterns, nil
 }
 
 // loadRootPackage loads the package from the disk
 func loadRootPackage() ([]*decorator.Package, error) {
+	patterns, err := packagePatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs, err := loadPackages(patterns...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load package")
+	}
+	return pkgs, nil
+}
+
+// loadPackages loads the packages matching the patterns, resolved from the
+// module of the input path, with their syntax and dependencies.
+func loadPackages(patterns ...string) ([]*decorator.Package, error) {
 	abs, err := filepath.Abs(*inputPath)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get absolute path")
@@ -191,22 +613,41 @@ func loadRootPackage() ([]*decorator.Package, error) {
 	// which in turn corresponds to below expression
 	loadAllSyntax := packages.NeedDeps | packages.NeedSyntax | packages.NeedTypesInfo |
 		packages.NeedTypesSizes | packages.NeedTypes | packages.NeedImports | packages.NeedName |
-		packages.NeedFiles | packages.NeedCompiledGoFiles
+		packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedModule
 
-	pkgs, err := decorator.Load(&packages.Config{
-		Dir:  abs,
-		Mode: loadAllSyntax,
-	})
+	pkgs, err := packages.Load(loadConfig(abs, loadAllSyntax), patterns...)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not load package")
+		return nil, err
 	}
-	return pkgs, nil
+	return decoratePackages(pkgs, *workers)
+}
+
+// loadConfig returns the configuration loading packages from the directory
+// with the build tags and target platform of the flags, so that files
+// guarded by build constraints are selected deliberately.
+func loadConfig(dir string, mode packages.LoadMode) *packages.Config {
+	config := &packages.Config{Dir: dir, Mode: mode}
+	if *buildTags != "" {
+		config.BuildFlags = []string{"-tags=" + *buildTags}
+	}
+	if *targetOS != "" || *targetArch != "" {
+		config.Env = os.Environ()
+		if *targetOS != "" {
+			config.Env = append(config.Env, "GOOS="+*targetOS)
+		}
+		if *targetArch != "" {
+			config.Env = append(config.Env, "GOARCH="+*targetArch)
+		}
+	}
+	return config
 }
 
 type collectStructOptions struct {
+	state         *collectState
 	pkg           *decorator.Package
 	structName    string
 	packagePrefix string // prefix of the package if not root (blank if root package)
+	unfiltered    bool   // the type filters do not apply, as for the root and embedded structures
 }
 
 type structType struct {
@@ -217,7 +658,10 @@ type structType struct {
 	text              *Text
 	fields            []*Field
 	packagePrefix     string
+	packageName       string
 	requestPartValues []Example
+	undocumented      []string
+	source            *encoder.Source
 }
 
 func wrapStructName(prefix, suffix string) string {
@@ -238,28 +682,115 @@ func collectStructsWithOpts(collectOpts *collectStructOptions) (*structType, []*
 	var mainStruct *structType
 	var extras []*structType
 
-	for _, spec := range collectOpts.pkg.Syntax {
-		parsed, extra := collectStructsFromDSTNode(spec, collectOpts)
-		if parsed != nil {
+	files := collectOpts.pkg.Syntax
+
+	parsed := make([]*structType, len(files))
+	extra := make([][]*structType, len(files))
+	collectOpts.state.run(len(files), func(i int) {
+		parsed[i], extra[i] = collectStructsFromDSTNode(files[i], collectOpts)
+	})
+
+	var matched []string
+	for i := range files {
+		if parsed[i] != nil {
+			matched = append(matched, parsed[i].name)
 			if mainStruct == nil {
-				mainStruct = parsed
+				mainStruct = parsed[i]
 			} else {
-				extras = append(extras, parsed)
+				extras = append(extras, parsed[i])
 			}
 		}
-		extras = append(extras, extra...)
+		extras = append(extras, extra[i]...)
+	}
+	if len(matched) > 1 {
+		warn(nodePosition(collectOpts.pkg, mainStruct.node), "ambiguous-structure", "multiple types matched %q in %s: %s, using %s as the main structure", collectOpts.structName, collectOpts.pkg.PkgPath, strings.Join(matched, ", "), mainStruct.name)
 	}
 	return mainStruct, extras
 }
 
+// collectAllStructs collects every exported struct of the package with
+// yaml tagged fields, along with the structures they reference.
+func collectAllStructs(collectOpts *collectStructOptions) []*structType {
+	var structures []*structType
+	for _, name := range taggedStructNames(collectOpts.pkg) {
+		// structs referenced by the ones collected before are skipped
+		if !collectOpts.state.claim(wrapStructName(collectOpts.packagePrefix, name)) {
+			continue
+		}
+		main, extra := collectStructsWithOpts(&collectStructOptions{
+			state:         collectOpts.state,
+			pkg:           collectOpts.pkg,
+			structName:    name,
+			packagePrefix: collectOpts.packagePrefix,
+		})
+		if main != nil {
+			structures = append(structures, main)
+		}
+		structures = append(structures, extra...)
+	}
+	return structures
+}
+
+// taggedStructNames returns the names of the exported structs of the
+// package having fields with a yaml tag, in declaration order, except for
+// types marked with the unmarshaler directive.
+func taggedStructNames(pkg *decorator.Package) []string {
+	var names []string
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			g, ok := decl.(*dst.GenDecl)
+			if !ok || g.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range g.Specs {
+				t, ok := spec.(*dst.TypeSpec)
+				if !ok || !unicode.IsUpper(rune(t.Name.Name[0])) {
+					continue
+				}
+				s, ok := t.Type.(*dst.StructType)
+				if !ok || !hasYAMLTags(s) {
+					continue
+				}
+				// the fields of types decoding other input are not documented
+				if _, custom := parseShape(uncommentDecorationNode(declarationNode(t, t, pkg)), false); !custom {
+					names = append(names, t.Name.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// hasYAMLTags returns true if any field of the struct has a key tag.
+func hasYAMLTags(s *dst.StructType) bool {
+	for _, f := range s.Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		if _, ok := encoder.KeyTag(reflect.StructTag(strings.Trim(f.Tag.Value, "`"))); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchStructName returns true if the declared type name matches the
+// requested structure name, ignoring case with -case-insensitive.
+func matchStructName(requested, name string) bool {
+	if *caseInsensitive {
+		return strings.EqualFold(requested, name)
+	}
+	return requested == name
+}
+
 // collectPartEnumInformation collects enum information for a type from node
-func collectPartEnumInformation(node dst.Node, typeName string) []string {
+func collectPartEnumInformation(node dst.Node, typeName string) []EnumValue {
 	if index := strings.LastIndex(typeName, "."); index != -1 {
 		typeName = typeName[index+1:]
 	}
 	fieldName := strings.Join([]string{"name", typeName}, ":")
 
-	var values []string
+	var values []EnumValue
 	dst.Inspect(node, func(n dst.Node) bool {
 		g, ok := n.(*dst.GenDecl)
 		if !ok {
@@ -287,7 +818,7 @@ func collectPartEnumInformation(node dst.Node, typeName string) []string {
 				continue
 			}
 			valueName := strings.TrimPrefix(value.Decs.Start.All()[len(value.Decs.Start.All())-1], "// name:")
-			values = append(values, valueName)
+			values = append(values, EnumValue{Value: valueName, Description: enumDescription(value.Decs.Start.All(), value.Decs.End.All())})
 		}
 		return true
 	})
@@ -322,8 +853,9 @@ func collectStructsFromDSTNode(node dst.Node, collectOpts *collectStructOptions)
 	return mainStruct, extras
 }
 
-// collectRequestPartDefinitions collects part definitions for a
-func collectRequestPartDefinitions(node dst.Node) []Example {
+// collectPartDefinitions collects the part definitions of a struct from
+// the composite literal assigned to the variable in the node.
+func collectPartDefinitions(node dst.Node, variable string) []Example {
 	values := []Example{}
 
 	dst.Inspect(node, func(n dst.Node) bool {
@@ -347,7 +879,7 @@ func collectRequestPartDefinitions(node dst.Node) []Example {
 			if len(value.Names) == 0 {
 				continue
 			}
-			if value.Names[0].Name != "RequestPartDefinitions" {
+			if value.Names[0].Name != variable {
 				return true
 			}
 			lit, ok := value.Values[0].(*dst.CompositeLit)
@@ -357,8 +889,8 @@ func collectRequestPartDefinitions(node dst.Node) []Example {
 			for _, elt := range lit.Elts {
 				expr := elt.(*dst.KeyValueExpr)
 				values = append(values, Example{
-					Name:  strings.Trim(expr.Key.(*dst.BasicLit).Value, "\""),
-					Value: strings.Trim(expr.Value.(*dst.BasicLit).Value, "\""),
+					Name:  stringValue(expr.Key.(*dst.BasicLit)),
+					Value: stringValue(expr.Value.(*dst.BasicLit)),
 				})
 			}
 		}
@@ -387,7 +919,7 @@ func parseStructuresFromDSTSpec(node, original dst.Node, spec dst.Spec, collectO
 
 	gotStructName := t.Name.Name
 	// We only want structures with name as described
-	if !strings.EqualFold(collectOpts.structName, gotStructName) {
+	if !matchStructName(collectOpts.structName, gotStructName) {
 		return nil, nil
 	}
 	// We only want publicly declrated types
@@ -395,26 +927,109 @@ func parseStructuresFromDSTSpec(node, original dst.Node, spec dst.Spec, collectO
 		return nil, nil
 	}
 
+	comment := uncommentDecorationNode(declarationNode(node, t, collectOpts.pkg))
+	// internal types are excluded along with the structures only they
+	// reference, so they do not appear in the back references either
+	if strings.Contains(comment, "docgen:nodoc") {
+		addDiagnostic(levelNote, nodePosition(collectOpts.pkg, t), "skipped-type", fmt.Sprintf("%s is skipped as it is marked with docgen:nodoc", gotStructName))
+		collectOpts.state.skipNodoc(gotStructName)
+		return nil, nil
+	}
+	if !collectOpts.unfiltered && filteredType(gotStructName, collectOpts.pkg.PkgPath) {
+		addDiagnostic(levelNote, nodePosition(collectOpts.pkg, t), "skipped-type", fmt.Sprintf("%s is skipped by the type filters", gotStructName))
+		return nil, nil
+	}
+	text := parseComment([]byte(comment))
+	setExamplesPosition(text, declarationNode(node, t, collectOpts.pkg), collectOpts.pkg)
+
+	// the part definitions variable is set by the comment, or by the flags
+	// for structs with the configured name
+	variable := text.PartDefinitions
+	if variable == "" && *partStruct != "" && (gotStructName == *partStruct || strings.HasSuffix(gotStructName, "."+*partStruct)) {
+		variable = *partVar
+	}
 	var partDefs []Example
-	if gotStructName == "Request" || strings.HasSuffix(gotStructName, ".Request") {
-		partDefs = collectRequestPartDefinitions(original)
+	if variable != "" {
+		partDefs = collectPartDefinitions(original, variable)
 	}
 
 	s := &structType{
 		name:              gotStructName,
 		node:              x,
 		original:          original,
-		text:              parseComment([]byte(uncommentDecorationNode(node))),
+		text:              text,
 		pkg:               collectOpts.pkg,
 		packagePrefix:     collectOpts.packagePrefix,
+		packageName:       collectOpts.pkg.Name,
 		requestPartValues: partDefs,
+		source:            sourcePosition(collectOpts.pkg, t),
 	}
 	// Collect all the fields of the structure. The
 	fields, structures := collectFields(s, collectOpts)
 	s.fields = fields
+	structures = append(structures, collectDiscriminated(s, collectOpts)...)
 	return s, structures
 }
 
+// declarationNode returns the node holding the comment of the type spec.
+// Comments of single type declarations are attached to the declaration
+// rather than the spec, which is all that is known for types found through
+// references, so the declaration is looked up in the package files.
+func declarationNode(node dst.Node, spec *dst.TypeSpec, pkg *decorator.Package) dst.Node {
+	if node != dst.Node(spec) || len(spec.Decs.Start) > 0 {
+		return node
+	}
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			if g, ok := decl.(*dst.GenDecl); ok && !g.Lparen {
+				for _, s := range g.Specs {
+					if s == dst.Spec(spec) {
+						return g
+					}
+				}
+			}
+		}
+	}
+	return node
+}
+
+// collectDiscriminated collects the structs mapped by the discriminator of
+// the structure from its package, as they are usually not referenced by
+// any field.
+func collectDiscriminated(s *structType, collectOpts *collectStructOptions) []*structType {
+	d := s.text.Discriminator
+	if d == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(d.Mapping))
+	for value, name := range d.Mapping {
+		names = append(names, name)
+		d.Mapping[value] = wrapStructName(collectOpts.packagePrefix, name)
+	}
+	sort.Strings(names)
+
+	var structures []*structType
+	for _, name := range names {
+		if !collectOpts.state.claim(wrapStructName(collectOpts.packagePrefix, name)) {
+			continue
+		}
+		main, extra := collectStructsWithOpts(&collectStructOptions{
+			state:         collectOpts.state,
+			pkg:           collectOpts.pkg,
+			structName:    name,
+			packagePrefix: collectOpts.packagePrefix,
+		})
+		if main == nil {
+			warn(nodePosition(collectOpts.pkg, s.node), "unknown-struct", "discriminator of %s maps to unknown struct %s", s.name, name)
+			continue
+		}
+		structures = append(structures, main)
+		structures = append(structures, extra...)
+	}
+	return structures
+}
+
 // collectFields collects all the fields from a structure, as well
 // as collecting any nested structures based on their types.
 //
@@ -426,27 +1041,55 @@ func collectFields(s *structType, collectOpts *collectStructOptions) (fields []*
 	var foundStructures []*structType
 
 	for _, f := range s.node.Fields.List {
-		if f.Tag == nil || len(f.Names) < 1 {
+		if f.Tag == nil {
 			continue
 		}
 		tag := reflect.StructTag(strings.Trim(f.Tag.Value, "`"))
 
-		var enumFields []string
+		if len(f.Names) == 0 && inlineEmbed(tag) {
+			if strings.Contains(fieldDocumentation(f), "docgen:nodoc") {
+				continue
+			}
+			// Append all the fields of embedded structure to the
+			// parent structure and add any additional found structures
+			// to the finalStructures array.
+			structure, extra := collectEmbedded(f, collectOpts)
+			foundStructures = append(foundStructures, extra...)
+			if structure == nil {
+				collectOpts.state.unresolvedEmbed(formatFieldType(f.Type, s.packagePrefix, collectOpts.pkg.PkgPath, false), wrapStructName(s.packagePrefix, s.name))
+				continue
+			}
+			fields = append(fields, structure.fields...)
+			s.undocumented = append(s.undocumented, structure.undocumented...)
+			continue
+		}
+		name := fieldName(f)
+		// Public fields only
+		if name != "" && !unicode.IsUpper(rune(name[0])) {
+			continue
+		}
 
-		documentation := uncommentDecorationNode(f)
+		var enumFields []EnumValue
+
+		documentation, displayName := nameDirective(fieldDocumentation(f))
+		documentation, secret := secretDirective(documentation)
 		mapping := tag.Get("mapping")
 
-		yamlTags := tag.Get("yaml")
+		yamlTags, _ := encoder.KeyTag(tag)
 		yamlTag := strings.Split(yamlTags, ",")[0]
 		if mapping == "" {
-			if (yamlTag == "" || yamlTag == "-") && strings.Count(yamlTags, ",") < 1 {
+			if (yamlTag == "" || yamlTag == "-") && strings.Count(yamlTags, ",") < 1 && displayName == "" {
 				continue
 			}
 
 			yamlTag = strings.ToLower(yamlTag)
+			if displayName != "" {
+				yamlTag = displayName
+			}
 
 			if documentation == "" {
-				log.Printf("field %q is missing a documentation", f.Type)
+				warn(nodePosition(collectOpts.pkg, f), "undocumented-field", "field %s.%s is missing a documentation", s.name, name)
+				s.undocumented = append(s.undocumented, name)
 				continue
 			}
 		} else {
@@ -461,71 +1104,158 @@ func collectFields(s *structType, collectOpts *collectStructOptions) (fields []*
 			continue
 		}
 
-		if len(f.Names) == 0 {
-			ident, ok := f.Type.(*dst.Ident)
-			if !ok {
-				continue
-			}
-
-			var structure *structType
-			var extra []*structType
-			if ident.Path != "" {
-				structPackage, ok := collectOpts.pkg.Imports[ident.Path]
-				if !ok {
-					log.Printf("[debug] [ref] no package found for struct %s: %s\n", collectOpts.structName, ident.Path)
-					return
-				}
-
-				structure, extra = collectStructsWithOpts(&collectStructOptions{
-					pkg:           structPackage,
-					structName:    ident.Name,
-					packagePrefix: path.Base(ident.Path),
-				})
-			} else if ident.Obj != nil {
-				spec := ident.Obj.Decl.(*dst.TypeSpec)
-				structure, extra = parseStructuresFromDSTSpec(spec, spec, spec, &collectStructOptions{
-					pkg:           collectOpts.pkg,
-					structName:    ident.Name,
-					packagePrefix: collectOpts.packagePrefix,
-				})
-			}
-			// Append all the fields of embedded structure to the
-			// parent structure and add any additional found structures
-			// to the finalStructures array.
-
-			fields = append(fields, structure.fields...)
-			foundStructures = append(foundStructures, extra...)
-			continue
-		}
-		name := f.Names[0].Name
-
-		// Public fields only
-		if !unicode.IsUpper(rune(name[0])) {
-			continue
-		}
-		fieldType := formatFieldType(f.Type, s.packagePrefix, false)
+		fieldType := formatFieldType(f.Type, s.packagePrefix, collectOpts.pkg.PkgPath, false)
 		if name == "" {
 			name = fieldType
 		}
-		fieldTypeRef := getFieldType(f.Type, s.packagePrefix, false)
+		fieldTypeRef := getFieldType(f.Type, s.packagePrefix, collectOpts.pkg.PkgPath, false)
+		if enumFields == nil {
+			enumFields = collectEnumValues(f.Type, collectOpts.pkg)
+		}
 
-		// Collect any unresolved reference to a remote object.
-		collectUnresolvedExternalStructs(f.Type, &foundStructures, collectOpts)
+		text := parseComment([]byte(documentation))
+		shape, custom := unmarshalerShape(f.Type, collectOpts)
+		if custom {
+			addShape(text, shape)
+		}
+		setExamplesPosition(text, f, collectOpts.pkg)
+		if len(text.Accepts) > 0 {
+			// the wrapper type decoding the accepted types is not documented
+			for i, accepted := range text.Accepts {
+				text.Accepts[i] = acceptedType(accepted)
+			}
+			fieldType = strings.Join(text.Accepts, " or ")
+			fieldTypeRef = ""
+		} else if custom {
+			// neither are the fields of types decoding other input
+			fieldTypeRef = ""
+		} else {
+			if schemaType, ok := mappedSchemaType(f.Type, collectOpts.pkg.PkgPath); ok {
+				text.Accepts = []string{schemaType}
+			}
+			// Collect any unresolved reference to a remote object.
+			collectUnresolvedExternalStructs(f.Type, &foundStructures, collectOpts)
+		}
 
 		field := &Field{
 			Name:       name,
 			Tag:        yamlTag,
 			Type:       fieldType,
 			TypeRef:    fieldTypeRef,
-			Text:       parseComment([]byte(documentation)),
+			Text:       text,
 			EnumFields: enumFields,
+			Tags:       dialectTags(tag),
+			Source:     sourcePosition(collectOpts.pkg, f),
+			Secret:     secret,
+		}
+		_, field.Pointer = f.Type.(*dst.StarExpr)
+		if strings.HasPrefix(fieldType, "[]") {
+			itemConstraints(field.Text, tag.Get("validate"))
+		} else if field.Text.MinItems != 0 || field.Text.MaxItems != 0 || field.Text.Unique {
+			warn(nodePosition(collectOpts.pkg, f), "ignored-annotation", "item constraints of non-slice field %s.%s are ignored", s.name, name)
+		}
+		if field.Text.KeyDoc != "" && !strings.HasPrefix(fieldType, "map[") {
+			warn(nodePosition(collectOpts.pkg, f), "ignored-annotation", "keydoc of non-map field %s.%s is ignored", s.name, name)
+			field.Text.KeyDoc = ""
 		}
 		fields = append(fields, field)
 	}
 	return fields, foundStructures
 }
 
-var uniqueStructures = make(map[string]struct{})
+// acceptedType returns the documented type of a type listed in the accepts
+// key, where `<type>-slice` is a shorthand for `[]<type>` as brackets have
+// to be quoted in yaml.
+func acceptedType(name string) string {
+	name = strings.TrimSpace(name)
+	if elem := strings.TrimSuffix(name, "-slice"); elem != name {
+		return "[]" + acceptedType(elem)
+	}
+	return name
+}
+
+// inlineEmbed returns true if the fields of an embedded struct with the tag
+// are inlined into the embedding struct, as with the yaml inline and the
+// mapstructure squash options.
+// Other embedded structs are documented like fields named after the type.
+func inlineEmbed(tag reflect.StructTag) bool {
+	value, _ := encoder.KeyTag(tag)
+	options := strings.Split(value, ",")[1:]
+	for _, option := range options {
+		// mapstructure squashes embedded structs into their parent
+		if option == "inline" || option == "squash" {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldName returns the name of a field, which is the name of the type for
+// embedded fields.
+func fieldName(f *dst.Field) string {
+	if len(f.Names) > 0 {
+		return f.Names[0].Name
+	}
+	expr := f.Type
+	if star, ok := expr.(*dst.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*dst.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// collectEmbedded collects the structure embedded by the field, along with
+// the structures referenced by its fields. Embedded structures of other
+// packages are collected from the imports of the package, and nil is
+// returned if the structure cannot be resolved.
+func collectEmbedded(f *dst.Field, collectOpts *collectStructOptions) (*structType, []*structType) {
+	expr := f.Type
+	if star, ok := expr.(*dst.StarExpr); ok {
+		expr = star.X
+	}
+	ident, ok := expr.(*dst.Ident)
+	if !ok {
+		return nil, nil
+	}
+
+	if ident.Path != "" {
+		structPackage, ok := collectOpts.state.importPackage(collectOpts.pkg, ident.Path)
+		if !ok {
+			warn(nodePosition(collectOpts.pkg, ident), "unresolved-import", "no package found for struct %s: %s", collectOpts.structName, ident.Path)
+			return nil, nil
+		}
+		return collectStructsWithOpts(&collectStructOptions{
+			state:         collectOpts.state,
+			pkg:           structPackage,
+			structName:    ident.Name,
+			packagePrefix: path.Base(ident.Path),
+			unfiltered:    true,
+		})
+	}
+	if ident.Obj != nil {
+		spec, ok := ident.Obj.Decl.(*dst.TypeSpec)
+		if !ok {
+			return nil, nil
+		}
+		return parseStructuresFromDSTSpec(spec, spec, spec, &collectStructOptions{
+			state:         collectOpts.state,
+			pkg:           collectOpts.pkg,
+			structName:    ident.Name,
+			packagePrefix: collectOpts.packagePrefix,
+			unfiltered:    true,
+		})
+	}
+	// declared in another file of the package
+	return collectStructsWithOpts(&collectStructOptions{
+		state:         collectOpts.state,
+		pkg:           collectOpts.pkg,
+		structName:    ident.Name,
+		packagePrefix: collectOpts.packagePrefix,
+		unfiltered:    true,
+	})
+}
 
 // collectUnresolvedExternalStructs collects unresolved external structures
 // for a package into the list.
@@ -534,7 +1264,7 @@ var uniqueStructures = make(map[string]struct{})
 // on the parent data structure, it is collected from a remote
 // package.
 //
-// It also handles deduplication by having a uniqueStructures map.
+// It also handles deduplication using the collection state.
 func collectUnresolvedExternalStructs(p interface{}, results *[]*structType, collectOpts *collectStructOptions) {
 	if m, ok := p.(*dst.MapType); ok {
 		collectUnresolvedExternalStructs(m.Key, results, collectOpts)
@@ -544,6 +1274,9 @@ func collectUnresolvedExternalStructs(p interface{}, results *[]*structType, col
 
 	switch t := p.(type) {
 	case *dst.Ident:
+		if _, ok := friendlyType(t, collectOpts.pkg.PkgPath); ok {
+			return
+		}
 		if t.Obj != nil { // in case of arrays of objects
 			spec := t.Obj.Decl.(*dst.TypeSpec)
 
@@ -551,12 +1284,12 @@ func collectUnresolvedExternalStructs(p interface{}, results *[]*structType, col
 			if collectOpts.packagePrefix != "" {
 				structName = wrapStructName(collectOpts.packagePrefix, t.Obj.Name)
 			}
-			if _, ok := uniqueStructures[structName]; ok {
+			if !collectOpts.state.claim(structName) {
 				return
 			}
-			uniqueStructures[structName] = struct{}{}
 
 			main, extra := parseStructuresFromDSTSpec(spec, spec, spec, &collectStructOptions{
+				state:         collectOpts.state,
 				pkg:           collectOpts.pkg,
 				structName:    t.Name,
 				packagePrefix: collectOpts.packagePrefix,
@@ -567,23 +1300,21 @@ func collectUnresolvedExternalStructs(p interface{}, results *[]*structType, col
 			*results = append(*results, extra...)
 		} else if t.Path != "" {
 			prefixSmallName := wrapStructName(path.Base(t.Path), t.Name)
-			if _, ok := uniqueStructures[prefixSmallName]; ok {
+			if !collectOpts.state.claim(prefixSmallName) {
 				return
 			}
-			uniqueStructures[prefixSmallName] = struct{}{}
-
-			if _, ok := uniqueStructures[t.String()]; ok {
+			if !collectOpts.state.claim(t.String()) {
 				return
 			}
-			uniqueStructures[t.String()] = struct{}{}
 
-			structPackage, ok := collectOpts.pkg.Imports[t.Path]
+			structPackage, ok := collectOpts.state.importPackage(collectOpts.pkg, t.Path)
 			if !ok {
-				log.Printf("[debug] [ref] no package found for struct %s: %s\n", collectOpts.structName, t.Path)
+				warn(nodePosition(collectOpts.pkg, t), "unresolved-import", "no package found for struct %s: %s", collectOpts.structName, t.Path)
 				return
 			}
 
 			main, extra := collectStructsWithOpts(&collectStructOptions{
+				state:         collectOpts.state,
 				pkg:           structPackage,
 				structName:    t.Name,
 				packagePrefix: path.Base(t.Path),
@@ -593,12 +1324,12 @@ func collectUnresolvedExternalStructs(p interface{}, results *[]*structType, col
 			}
 			*results = append(*results, extra...)
 		} else {
-			if _, ok := uniqueStructures[t.Name]; ok {
+			if !collectOpts.state.claim(t.Name) {
 				return
 			}
-			uniqueStructures[t.Name] = struct{}{}
 
 			main, extra := collectStructsWithOpts(&collectStructOptions{
+				state:      collectOpts.state,
 				pkg:        collectOpts.pkg,
 				structName: t.Name,
 			})
@@ -620,13 +1351,16 @@ func collectUnresolvedExternalStructs(p interface{}, results *[]*structType, col
 
 // getFieldType returns the full name of a field, with the prefix
 // applied if the field is from a remote package.
-func getFieldType(p interface{}, prefix string, apply bool) string {
+func getFieldType(p interface{}, prefix, pkgPath string, apply bool) string {
 	if m, ok := p.(*dst.MapType); ok {
-		return getFieldType(m.Value, prefix, false)
+		return getFieldType(m.Value, prefix, pkgPath, false)
 	}
 
 	switch t := p.(type) {
 	case *dst.Ident:
+		if _, ok := friendlyType(t, pkgPath); ok {
+			return ""
+		}
 		if t.Path != "" {
 			return wrapStructName(path.Base(t.Path), t.Name) // If we have a path
 		}
@@ -635,29 +1369,147 @@ func getFieldType(p interface{}, prefix string, apply bool) string {
 		}
 		return t.Name
 	case *dst.ArrayType:
-		return getFieldType(p.(*dst.ArrayType).Elt, prefix, false)
+		return getFieldType(p.(*dst.ArrayType).Elt, prefix, pkgPath, false)
 	case *dst.StarExpr:
-		return getFieldType(t.X, prefix, true)
+		return getFieldType(t.X, prefix, pkgPath, true)
 	case *dst.SelectorExpr:
-		return getFieldType(t.Sel, prefix, false)
+		return getFieldType(t.Sel, prefix, pkgPath, false)
 	default:
 		return ""
 	}
 }
 
-// uncommentDecorationNode uncomments comments for a dst node.
+// dialectTags returns the keys of a field in the other supported dialects
+// taken from the struct tags.
+// itemConstraints fills the item constraints of a slice field missing from
+// its comment from the min, max and unique rules of its validate tag.
+// Rules following dive apply to the items and are ignored.
+func itemConstraints(text *Text, validate string) {
+	for _, rule := range strings.Split(validate, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			return
+		case "min":
+			if n, err := strconv.Atoi(value); err == nil && text.MinItems == 0 {
+				text.MinItems = n
+			}
+		case "max":
+			if n, err := strconv.Atoi(value); err == nil && text.MaxItems == 0 {
+				text.MaxItems = n
+			}
+		case "unique":
+			text.Unique = true
+		}
+	}
+}
+
+func dialectTags(tag reflect.StructTag) map[string]string {
+	var tags map[string]string
+	for _, dialect := range []string{"json", "toml"} {
+		name := strings.Split(tag.Get(dialect), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[dialect] = name
+	}
+	return tags
+}
+
+// uncommentDecorationNode uncomments the comments placed above a dst node.
 func uncommentDecorationNode(node dst.Node) string {
-	decorations := node.Decorations()
-	parts := decorations.Start.All()
+	return uncommentDecorations(node.Decorations().Start.All())
+}
+
+// fieldDocumentation returns the documentation of a struct field.
+//
+// Comments placed above the field take precedence. Comments placed after
+// the field type, either before the tag or at the end of the line, are
+// only used when there are none above, with the exception of docgen
+// directives which are honoured wherever they are placed.
+func fieldDocumentation(f *dst.Field) string {
+	leading := uncommentDecorations(f.Decs.Start.All())
+	trailing := uncommentDecorations(append(f.Decs.Type.All(), f.Decs.End.All()...))
+	if strings.TrimSpace(leading) == "" {
+		return strings.TrimSpace(trailing)
+	}
+
+	for _, line := range strings.Split(trailing, "\n") {
+		if strings.Contains(line, "docgen:") {
+			leading += "\n" + line
+		}
+	}
+	return leading
+}
+
+// nameDirective returns the documentation without the `docgen:name=`
+// directive and the key name it sets, which overrides the key of the yaml
+// tag for fields decoded from other keys by custom unmarshalers.
+func nameDirective(documentation string) (string, string) {
+	var name string
+	var lines []string
+	for _, line := range strings.Split(documentation, "\n") {
+		if value := strings.TrimPrefix(strings.TrimSpace(line), "docgen:name="); value != strings.TrimSpace(line) {
+			name = strings.TrimSpace(value)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), name
+}
+
+// secretDirective returns the documentation without the `docgen:secret`
+// directive and whether it is present. It marks fields holding credentials,
+// such as API keys, whose values are redacted by the encoder on request.
+func secretDirective(documentation string) (string, bool) {
+	var secret bool
+	var lines []string
+	for _, line := range strings.Split(documentation, "\n") {
+		if strings.TrimSpace(line) == "docgen:secret" {
+			secret = true
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), secret
+}
+
+// uncommentDecorations removes the comment delimiters of line as well as
+// block comments, ignoring nolint directives.
+func uncommentDecorations(parts []string) string {
+	var lines []string
+	for _, part := range parts {
+		if !strings.HasPrefix(part, "/*") {
+			lines = append(lines, strings.TrimPrefix(part, "//"))
+			continue
+		}
+
+		// block comments may span multiple lines, optionally starting with
+		// an asterisk aligned with the opening delimiter
+		block := strings.TrimSuffix(strings.TrimPrefix(part, "/*"), "*/")
+		blockLines := strings.Split(block, "\n")
+		for i, line := range blockLines {
+			if i > 0 {
+				line = strings.TrimLeft(line, " \t")
+				line = strings.TrimPrefix(line, "*")
+			}
+			if (i == 0 || i == len(blockLines)-1) && strings.TrimSpace(line) == "" {
+				continue
+			}
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
 
 	commentBuilder := &strings.Builder{}
-	for i, part := range parts {
-		trimmedLine := strings.TrimPrefix(part, "//")
-		if strings.Contains(trimmedLine, "nolint:") {
+	for i, line := range lines {
+		if strings.Contains(line, "nolint:") {
 			continue
 		}
-		commentBuilder.WriteString(trimmedLine)
-		if i != len(parts)-1 {
+		commentBuilder.WriteString(line)
+		if i != len(lines)-1 {
 			commentBuilder.WriteString("\n")
 		}
 	}
@@ -666,13 +1518,16 @@ func uncommentDecorationNode(node dst.Node) string {
 
 // formatFieldType returns the type of field for a structure with the prefix
 // applied if the field is from a remote package.
-func formatFieldType(p interface{}, prefix string, apply bool) string {
+func formatFieldType(p interface{}, prefix, pkgPath string, apply bool) string {
 	if m, ok := p.(*dst.MapType); ok {
-		return fmt.Sprintf("map[%s]%s", formatFieldType(m.Key, prefix, false), formatFieldType(m.Value, prefix, false))
+		return fmt.Sprintf("map[%s]%s", formatFieldType(m.Key, prefix, pkgPath, false), formatFieldType(m.Value, prefix, pkgPath, false))
 	}
 
 	switch t := p.(type) {
 	case *dst.Ident:
+		if friendly, ok := friendlyType(t, pkgPath); ok {
+			return friendly
+		}
 		if t.Path != "" {
 			return wrapStructName(path.Base(t.Path), t.Name) // If we have a path
 		}
@@ -681,13 +1536,13 @@ func formatFieldType(p interface{}, prefix string, apply bool) string {
 		}
 		return t.Name
 	case *dst.ArrayType:
-		return "[]" + formatFieldType(p.(*dst.ArrayType).Elt, prefix, false)
+		return "[]" + formatFieldType(p.(*dst.ArrayType).Elt, prefix, pkgPath, false)
 	case *dst.StructType:
 		return "struct"
 	case *dst.StarExpr:
-		return formatFieldType(t.X, prefix, true)
+		return formatFieldType(t.X, prefix, pkgPath, true)
 	case *dst.SelectorExpr:
-		return formatFieldType(t.Sel, prefix, false)
+		return formatFieldType(t.Sel, prefix, pkgPath, false)
 	case *dst.InterfaceType:
 		return "interface{}"
 	default:
@@ -696,20 +1551,48 @@ func formatFieldType(p interface{}, prefix string, apply bool) string {
 	}
 }
 
+// escape trims the value and escapes it for a Go interpreted string
+// literal of the template.
 func escape(value string) string {
-	return strings.TrimSpace(strings.ReplaceAll(
-		strings.ReplaceAll(value, "\"", "\\\""),
-		"\n",
-		"\\n",
-	))
+	return quote(strings.TrimSpace(value))
+}
+
+// quote escapes the value for a Go interpreted string literal, as
+// strconv.Quote without the surrounding quotes, so that backslashes,
+// backticks and control characters of comments generate valid code.
+func quote(value string) string {
+	quoted := strconv.Quote(value)
+	return quoted[1 : len(quoted)-1]
+}
+
+// stringValue returns the value of a string literal, interpreted or raw,
+// or the literal itself for other kinds of literals.
+func stringValue(lit *dst.BasicLit) string {
+	if value, err := strconv.Unquote(lit.Value); err == nil {
+		return value
+	}
+	return lit.Value
+}
+
+// rawString returns the value as a raw string literal, so that snippets
+// are readable in the generated code, or as an interpreted string literal
+// if it contains characters raw strings can not hold.
+func rawString(value string) string {
+	if !strconv.CanBackquote(strings.ReplaceAll(value, "\n", "")) {
+		return strconv.Quote(value)
+	}
+	return "`" + value + "`"
 }
 
 // parseComment parses a comment into a Text object
 func parseComment(comment []byte) *Text {
 	text := &Text{}
+	if *preserveMarkdown {
+		comment = uncommentSpace(comment)
+	}
 	if err := yaml.Unmarshal(comment, text); err != nil {
 		// not yaml, fallback
-		text.Description = string(comment)
+		text.Description = strings.TrimSpace(string(comment))
 		// take only the first line from the Description for the comment
 		text.Comment = strings.Split(text.Description, "\n")[0]
 
@@ -717,6 +1600,9 @@ func parseComment(comment []byte) *Text {
 		if err = yaml.Unmarshal([]byte(strings.Join(strings.Split(text.Description, "\n")[1:], "\n")), text); err == nil {
 			// if parsed, remove it from the description
 			text.Description = text.Comment
+		} else {
+			// otherwise only the last paragraph may contain yaml keys
+			text.Description = parseTrailingKeys(text.Description, text)
 		}
 	} else {
 		text.Description = strings.TrimSpace(text.Description)
@@ -724,45 +1610,175 @@ func parseComment(comment []byte) *Text {
 		text.Comment = strings.Split(text.Description, "\n")[0]
 	}
 
-	text.Description = escape(text.Description)
+	text.Comment = quote(text.Comment)
+	text.Description = strings.Trim(text.Description, "\n")
+	if !*preserveMarkdown {
+		text.Description = strings.TrimSpace(text.Description)
+	}
+	text.DocsURL = escape(text.DocsURL)
+	for i, value := range text.Values {
+		text.Values[i] = escape(value)
+	}
+	text.Default = escape(text.Default)
+	text.KeyDoc = escape(text.KeyDoc)
+	text.ReplacedBy = escape(text.ReplacedBy)
+	if text.Example != "" {
+		text.Examples = append(text.Examples, &Example{YAML: text.Example})
+		text.Example = ""
+	}
 	for _, example := range text.Examples {
 		example.Name = escape(example.Name)
 		example.Value = strings.TrimSpace(example.Value)
+		example.YAML = strings.Trim(example.YAML, "\n")
+	}
+	for _, bad := range text.BadExamples {
+		bad.Name = escape(bad.Name)
+		bad.Value = escape(bad.Value)
+		bad.Reason = escape(bad.Reason)
 	}
 	return text
 }
 
-var tpl = `// This Source Code Form is subject to the terms of the Mozilla Public
+// uncommentSpace removes the space separating the comment delimiter from
+// the text of every line, as go/ast does, so that the indentation of lists
+// and code blocks is relative to the delimiter.
+func uncommentSpace(comment []byte) []byte {
+	lines := strings.Split(string(comment), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// parseTrailingKeys parses the last paragraph of a description as yaml
+// keys into text, returning the description without it. The description
+// is returned unchanged unless the paragraph sets any of the known keys,
+// so that trailing prose is never mistaken for yaml.
+func parseTrailingKeys(description string, text *Text) string {
+	description = strings.TrimRight(description, "\n ")
+	index := strings.LastIndex(description, "\n\n")
+	if index == -1 {
+		return description
+	}
+
+	trailing := &Text{}
+	if err := yaml.Unmarshal([]byte(description[index+2:]), trailing); err != nil {
+		return description
+	}
+	if len(trailing.Examples) == 0 && trailing.Example == "" && len(trailing.Values) == 0 && trailing.DocsURL == "" && trailing.Diagram == "" && !trailing.Required && trailing.Stability == "" && trailing.Default == "" && trailing.Discriminator == nil && len(trailing.BadExamples) == 0 &&
+		trailing.MinItems == 0 && trailing.MaxItems == 0 && !trailing.Unique && trailing.PartDefinitions == "" && len(trailing.Accepts) == 0 && trailing.KeyDoc == "" && trailing.ReplacedBy == "" {
+		return description
+	}
+
+	text.Examples = append(text.Examples, trailing.Examples...)
+	text.Example = trailing.Example
+	text.Values = append(text.Values, trailing.Values...)
+	text.DocsURL = trailing.DocsURL
+	text.Diagram = trailing.Diagram
+	text.Required = trailing.Required
+	text.Stability = trailing.Stability
+	text.Default = trailing.Default
+	text.Discriminator = trailing.Discriminator
+	text.MinItems = trailing.MinItems
+	text.MaxItems = trailing.MaxItems
+	text.Unique = trailing.Unique
+	text.BadExamples = append(text.BadExamples, trailing.BadExamples...)
+	text.PartDefinitions = trailing.PartDefinitions
+	text.Accepts = append(text.Accepts, trailing.Accepts...)
+	text.KeyDoc = trailing.KeyDoc
+	text.ReplacedBy = trailing.ReplacedBy
+	return description[:index]
+}
+
+// defaultFileHeader is the comment heading the generated code unless
+// replaced with -header-file.
+const defaultFileHeader = `// This Source Code Form is subject to the terms of the Mozilla Public
 // License, v. 2.0. If a copy of the MPL was not distributed with this
-// file, You can obtain one at http://mozilla.org/MPL/2.0/.
-// DO NOT EDIT: this file is automatically generated by docgen
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.`
+
+var tpl = `{{ .FileHeader }}
+
+// Code generated by docgen. DO NOT EDIT.
+{{- with .Command }}
+//
+// Command: {{ . }}
+{{- end }}
+{{- with .SourceHash }}
+// Source hash: sha256:{{ . }}
+{{- end }}
+
 package {{ .Package }}
 import (
 	"github.com/projectdiscovery/yamldoc-go/encoder"
 )
 {{ $tick := "` + "`" + `" -}}
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the encoder package it is being compiled against.
+const _ = encoder.SupportPackageIsVersion2
 var (
 	{{ range $struct := .Structs -}}
 	{{ $struct.GetEscapedName }}Doc encoder.Doc
 	{{ end -}}
 )
 func init() {
+	encoder.RequireVersion("{{ .EncoderVersion }}")
 	{{ range $struct := .Structs -}}
 	{{ $docVar := printf "%v%v" $struct.GetEscapedName "Doc" }}
 	{{ $docVar }}.Type = "{{ $struct.GetName }}"
 	{{ $docVar }}.Comments[encoder.LineComment] = "{{ $struct.Text.Comment }}"
-	{{ $docVar }}.Description = "{{ $struct.Text.Description }}"
+	{{ $docVar }}.Description = "{{ quote $struct.Text.Description }}"
+	{{ if $struct.Text.DocsURL -}}
+	{{ $docVar }}.DocsURL = "{{ $struct.Text.DocsURL }}"
+	{{ end -}}
+	{{ with $struct.Text.Discriminator -}}
+	{{ $docVar }}.Discriminator = &encoder.Discriminator{
+		Field: "{{ quote .Field }}",
+		Mapping: map[string]string{
+		{{ range $value, $type := .Mapping -}}
+			"{{ quote $value }}": "{{ quote $type }}",
+		{{ end -}}
+		},
+	}
+	{{ end -}}
+	{{ if $struct.Text.Stability -}}
+	{{ $docVar }}.Stability = "{{ $struct.Text.Stability }}"
+	{{ end -}}
+	{{ with $struct.Source -}}
+	{{ $docVar }}.Source = &encoder.Source{File: "{{ quote .File }}", Line: {{ .Line }}}
+	{{ end -}}
+	{{ if $struct.Diagram -}}
+	{{ $docVar }}.Diagram = &encoder.Diagram{
+		Path: "{{ $struct.Diagram.Path }}",
+		{{ if $struct.Diagram.Mermaid -}}
+		Mermaid: "{{ $struct.Diagram.Mermaid }}",
+		{{ end -}}
+		{{ if $struct.Diagram.Data -}}
+		Data: []byte("{{ $struct.Diagram.Data }}"),
+		{{ end -}}
+	}
+	{{ end -}}
 	{{ range $example := $struct.Text.Examples }}
-	{{ if $example.Value }}
+	{{ if $example.YAML }}
+	{{ $docVar }}.AddYAMLExample("{{ $example.Name }}", {{ rawString $example.YAML }})
+	{{ else if $example.Value }}
 	{{ $docVar }}.AddExample("{{ $example.Name }}", {{ $example.Value }})
 	{{ end -}}
 	{{ end -}}
+	{{ if $struct.Registered -}}
+	encoder.Register((*{{ $struct.GetRawName }})(nil), &{{ $docVar }})
+	{{ end -}}
 	{{ if $struct.AppearsIn -}}
 	{{ $docVar }}.AppearsIn = []encoder.Appearance{
 	{{ range $value := $struct.AppearsIn -}}
 		{
 			TypeName: "{{ $value.Struct.GetName }}",
 			FieldName: "{{ $value.FieldName }}",
+			{{ if $value.Kind -}}
+			Kind: "{{ $value.Kind }}",
+			{{ end -}}
+			{{ if $value.Path -}}
+			Path: "{{ $value.Path }}",
+			{{ end -}}
 		},
 	{{ end -}}
 	}
@@ -771,8 +1787,8 @@ func init() {
 	{{ $docVar }}.PartDefinitions = []encoder.KeyValue{
 	{{ range $value := $struct.PartValues -}}
 		{
-			Key: "{{ $value.Name }}",
-			Value: "{{ $value.Value }}",
+			Key: "{{ quote $value.Name }}",
+			Value: "{{ quote $value.Value }}",
 		},
 	{{ end -}}
 	}
@@ -782,20 +1798,71 @@ func init() {
 	{{ $docVar }}.Fields[{{ $index }}].Name = "{{ $field.Tag }}"
 	{{ $docVar }}.Fields[{{ $index }}].Type = "{{ $field.Type }}"
 	{{ $docVar }}.Fields[{{ $index }}].Note = "{{ $field.Note }}"
-	{{ $docVar }}.Fields[{{ $index }}].Description = "{{ $field.Text.Description }}"
+	{{ $docVar }}.Fields[{{ $index }}].Description = "{{ quote $field.Text.Description }}"
 	{{ $docVar }}.Fields[{{ $index }}].Comments[encoder.LineComment] = "{{ $field.Text.Comment }}"
+	{{ if $field.Text.DocsURL -}}
+	{{ $docVar }}.Fields[{{ $index }}].DocsURL = "{{ $field.Text.DocsURL }}"
+	{{ end -}}
+	{{ with $field.Source -}}
+	{{ $docVar }}.Fields[{{ $index }}].Source = &encoder.Source{File: "{{ quote .File }}", Line: {{ .Line }}}
+	{{ end -}}
+	{{ if $field.Text.Default -}}
+	{{ $docVar }}.Fields[{{ $index }}].Default = "{{ $field.Text.Default }}"
+	{{ end -}}
+	{{ if $field.Text.MinItems -}}
+	{{ $docVar }}.Fields[{{ $index }}].MinItems = {{ $field.Text.MinItems }}
+	{{ end -}}
+	{{ if $field.Text.MaxItems -}}
+	{{ $docVar }}.Fields[{{ $index }}].MaxItems = {{ $field.Text.MaxItems }}
+	{{ end -}}
+	{{ if $field.Text.Unique -}}
+	{{ $docVar }}.Fields[{{ $index }}].UniqueItems = true
+	{{ end -}}
+	{{ if $field.Text.Required -}}
+	{{ $docVar }}.Fields[{{ $index }}].Required = true
+	{{ end -}}
+	{{ if $field.Secret -}}
+	{{ $docVar }}.Fields[{{ $index }}].Secret = true
+	{{ end -}}
+	{{ if $field.Text.KeyDoc -}}
+	{{ $docVar }}.Fields[{{ $index }}].KeyDoc = "{{ $field.Text.KeyDoc }}"
+	{{ end -}}
+	{{ if $field.Text.ReplacedBy -}}
+	{{ $docVar }}.Fields[{{ $index }}].ReplacedBy = "{{ $field.Text.ReplacedBy }}"
+	{{ end -}}
+	{{ if $field.Text.Accepts -}}
+	{{ $docVar }}.Fields[{{ $index }}].Accepts = []string{
+	{{ range $type := $field.Text.Accepts -}}
+		"{{ quote $type }}",
+	{{ end -}}
+	}
+	{{ end -}}
 	{{ if $field.EnumFields -}}
 	{{ $docVar }}.Fields[{{ $index }}].EnumFields = []string{
 	{{ range $value := $field.EnumFields -}}
-		"{{ $value }}",
+		"{{ $value.Value }}",
+	{{ end -}}
+	}
+	{{ $docVar }}.Fields[{{ $index }}].EnumValues = []encoder.EnumValue{
+	{{ range $value := $field.EnumFields -}}
+		{Value: "{{ $value.Value }}"{{ with $value.Description }}, Description: "{{ . }}"{{ end }}},
 	{{ end -}}
 	}
 	{{ end -}}
 	{{ range $example := $field.Text.Examples }}
-	{{ if $example.Value }}
+	{{ if $example.YAML }}
+	{{ $docVar }}.Fields[{{ $index }}].AddYAMLExample("{{ $example.Name }}", {{ rawString $example.YAML }})
+	{{ else if $example.Value }}
 	{{ $docVar }}.Fields[{{ $index }}].AddExample("{{ $example.Name }}", {{ $example.Value }})
 	{{ end -}}
 	{{ end -}}
+	{{ range $bad := $field.Text.BadExamples -}}
+	{{ $docVar }}.Fields[{{ $index }}].BadExamples = append({{ $docVar }}.Fields[{{ $index }}].BadExamples, encoder.BadExample{
+		Name:   "{{ $bad.Name }}",
+		Value:  "{{ $bad.Value }}",
+		Reason: "{{ $bad.Reason }}",
+	})
+	{{ end -}}
 	{{ if $field.Text.Values -}}
 	{{ $docVar }}.Fields[{{ $index }}].Values = []string{
 	{{ range $value := $field.Text.Values -}}
@@ -803,10 +1870,26 @@ func init() {
 	{{ end -}}
 	}
 	{{ end -}}
+	{{ if and $.Dialects $field.Tags -}}
+	{{ $docVar }}.Fields[{{ $index }}].Tags = map[string]string{
+	{{ range $key, $value := $field.Tags -}}
+		"{{ quote $key }}": "{{ quote $value }}",
+	{{ end -}}
+	}
+	{{ end -}}
 	{{ end -}}
 	{{ end }}
 }
-// Get{{ .Name }}Doc returns documentation for the file {{ .File }}.
+{{ if .DocMethods -}}
+{{ range $struct := .Structs -}}
+{{ if $struct.Registered }}
+func ({{ $struct.GetRawName }}) Doc() *encoder.Doc {
+	return &{{ $struct.GetEscapedName }}Doc
+}
+{{ end -}}
+{{ end }}
+{{ end -}}
+// Get{{ .Name }}Doc returns documentation {{ with .File }}for the file {{ . }}{{ else }}of {{ $.Name }}{{ end }}.
 func Get{{ .Name }}Doc() *encoder.FileDoc {
 	return &encoder.FileDoc{
 		Name: "{{ .Name }}",
@@ -816,23 +1899,177 @@ func Get{{ .Name }}Doc() *encoder.FileDoc {
 			&{{ $struct.GetEscapedName }}Doc,
 			{{ end -}}
 		},
+		{{ if .Glossary -}}
+		Glossary: []encoder.GlossaryTerm{
+			{{ range $entry := .Glossary -}}
+			{
+				Term: "{{ $entry.Term }}",
+				Definition: "{{ $entry.Definition }}",
+			},
+			{{ end -}}
+		},
+		{{ end -}}
+		{{ if .Dialects -}}
+		Dialects: []encoder.Dialect{
+			{{ range $dialect := .Dialects -}}
+			"{{ $dialect }}",
+			{{ end -}}
+		},
+		{{ end -}}
 	}
 }
 `
 
-func render(doc *Doc, dest string) error {
-	t := template.Must(template.New("docfile.tpl").Parse(tpl))
-	buf := bytes.Buffer{}
+// loadFileHeader reads the header of the generated code from the file.
+// Lines which are not comments already are commented out, so that plain
+// license texts can be used as is.
+func loadFileHeader(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "could not read header file")
+	}
 
-	err := t.Execute(&buf, doc)
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	var block bool
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case block:
+			block = !strings.Contains(trimmed, "*/")
+		case strings.HasPrefix(trimmed, "/*"):
+			block = !strings.Contains(trimmed, "*/")
+		case strings.HasPrefix(trimmed, "//"):
+		case trimmed == "":
+			lines[i] = "//"
+		default:
+			lines[i] = "// " + line
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// invocation returns the command line of docgen, quoting the arguments
+// which are not safe to paste into a shell as is.
+func invocation(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	command := []string{filepath.Base(args[0])}
+	for _, arg := range args[1:] {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'`$\\") {
+			arg = strconv.Quote(arg)
+		}
+		command = append(command, arg)
+	}
+	return strings.Join(command, " ")
+}
+
+// sourceHash hashes the documentation collected from the structs, so that
+// the generated code can be checked against the sources without parsing
+// it. Structs are hashed in their serialized form, with map keys sorted.
+func sourceHash(structures []*structType) (string, error) {
+	hash := sha256.New()
+	enc := json.NewEncoder(hash)
+	for _, s := range structures {
+		if err := enc.Encode(&cachedStruct{
+			Name:          s.name,
+			PackagePrefix: s.packagePrefix,
+			PackageName:   s.packageName,
+			Text:          s.text,
+			Fields:        s.fields,
+			PartValues:    s.requestPartValues,
+			Undocumented:  s.undocumented,
+			Source:        s.source,
+		}); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// templateFuncs are the helper functions available to the built-in
+// as well as any user supplied template.
+var templateFuncs = template.FuncMap{
+	"toLower":   strings.ToLower,
+	"anchor":    anchor,
+	"join":      strings.Join,
+	"quote":     quote,
+	"rawString": rawString,
+}
+
+// anchor returns the markdown anchor for a type name.
+func anchor(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), ".", "")
+}
+
+// loadTemplate returns the template used for rendering the documentation
+// code. If a custom template file is provided, it is used instead of the
+// built-in one.
+func loadTemplate() (*template.Template, error) {
+	contents := tpl
+	if *templateFile != "" {
+		data, err := os.ReadFile(*templateFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read template file")
+		}
+		contents = string(data)
+	}
+	t, err := template.New("docfile.tpl").Funcs(templateFuncs).Parse(contents)
 	if err != nil {
-		return errors.Wrap(err, "could not execute template")
+		return nil, errors.Wrap(err, "could not parse template")
 	}
+	return t, nil
+}
 
-	formatted, err := format.Source(buf.Bytes(), format.Options{})
+// renderers contains the output formats supported by the -format flag.
+var renderers = map[string]func(doc *Doc) ([]byte, error){
+	"go":         renderGo,
+	"badge":      renderBadge,
+	"catalog":    renderCatalog,
+	"completion": renderCompletion,
+	"dictionary": renderDictionary,
+	"dot":        renderDOT,
+	"json":       renderJSON,
+	"md":         renderMarkdown,
+	"md-toml":    renderTOMLMarkdown,
+	"mdx":        renderMDX,
+	"mermaid":    renderMermaid,
+	"openapi":    renderOpenAPI,
+	"paths":      renderPaths,
+	"schema":     renderSchema,
+	"shields":    renderShields,
+	"snippets":   renderSnippets,
+	"tool":       renderTool,
+}
+
+// render renders the documentation to dest in the -format output format
+// or with the -renderer-cmd command.
+func render(doc *Doc, dest string) error {
+	if *rendererCmd != "" {
+		if dest == stdoutPath {
+			return fmt.Errorf("-renderer-cmd writes files and requires an -output directory")
+		}
+		return renderExternal(doc, dest)
+	}
+
+	return renderFormat(doc, *outputFormat, dest)
+}
+
+// renderFormat renders the documentation in the output format to dest.
+func renderFormat(doc *Doc, outputFormat, dest string) error {
+	renderer, ok := renderers[outputFormat]
+	if !ok {
+		return fmt.Errorf("unknown output format %q", outputFormat)
+	}
+
+	data, err := renderer(doc)
 	if err != nil {
-		log.Printf("data: %s", buf.Bytes())
-		return errors.Wrap(err, "could not format generate code")
+		return err
+	}
+
+	if dest == stdoutPath {
+		_, err = os.Stdout.Write(data)
+		return err
 	}
 
 	abs, err := filepath.Abs(dest)
@@ -845,6 +2082,27 @@ func render(doc *Doc, dest string) error {
 		return errors.Wrap(err, "could not create output file")
 	}
 	defer out.Close()
-	_, err = out.Write(formatted)
+	_, err = out.Write(data)
 	return err
 }
+
+// renderGo renders the documentation as go code using the template.
+func renderGo(doc *Doc) ([]byte, error) {
+	t, err := loadTemplate()
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.Buffer{}
+
+	err = t.Execute(&buf, doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not execute template")
+	}
+
+	formatted, err := format.Source(buf.Bytes(), format.Options{})
+	if err != nil {
+		log.Printf("data: %s", buf.Bytes())
+		return nil, errors.Wrap(err, "could not format generate code")
+	}
+	return formatted, nil
+}
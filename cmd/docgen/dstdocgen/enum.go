@@ -0,0 +1,156 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+)
+
+// enumDirective marks a const block as the values of a type, for constants
+// which are untyped or not declared with the type.
+const enumDirective = "docgen:enum"
+
+// collectEnumValues returns the values and descriptions of the constants declared with the
+// named type of the field, looking through pointers and slices, or in const
+// blocks marked with the enum directive for the type. Constants declared
+// after a typed constant of a block, as with iota, share its type.
+func collectEnumValues(expr dst.Expr, pkg *decorator.Package) []EnumValue {
+	for {
+		switch t := expr.(type) {
+		case *dst.StarExpr:
+			expr = t.X
+			continue
+		case *dst.ArrayType:
+			expr = t.Elt
+			continue
+		}
+		break
+	}
+
+	ident, ok := expr.(*dst.Ident)
+	if !ok {
+		return nil
+	}
+	if _, ok := friendlyType(ident, pkg.PkgPath); ok {
+		return nil
+	}
+	if ident.Path != "" {
+		if pkg, ok = pkg.Imports[ident.Path]; !ok {
+			return nil
+		}
+	} else if ident.Obj == nil || ident.Obj.Kind != dst.Typ {
+		// predeclared types
+		return nil
+	}
+
+	var values []EnumValue
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			g, ok := decl.(*dst.GenDecl)
+			if !ok || g.Tok != token.CONST {
+				continue
+			}
+			values = append(values, enumBlockValues(g, ident.Name)...)
+		}
+	}
+	return values
+}
+
+// enumBlockValues returns the values of the constants of the block which
+// have the type.
+func enumBlockValues(g *dst.GenDecl, typeName string) []EnumValue {
+	marked := hasEnumDirective(g.Decs.Start.All(), typeName)
+
+	var values []EnumValue
+	var current dst.Expr
+	for _, spec := range g.Specs {
+		value, ok := spec.(*dst.ValueSpec)
+		if !ok {
+			continue
+		}
+		if value.Type != nil || len(value.Values) > 0 {
+			current = value.Type
+		}
+		if !marked && !isTypeIdent(current, typeName) {
+			continue
+		}
+
+		for i, name := range value.Names {
+			if name.Name == "_" {
+				continue
+			}
+			comments := value.Decs.Start.All()
+			if !g.Lparen {
+				// the comment of single constant declarations is attached
+				// to the declaration
+				comments = g.Decs.Start.All()
+			}
+			values = append(values, EnumValue{
+				Value:       escape(enumValue(value, i)),
+				Description: escape(enumDescription(comments, value.Decs.End.All())),
+			})
+		}
+	}
+	return values
+}
+
+// enumValue returns the documented value of the i-th constant of the spec:
+// the value of a `name:` comment, the string literal, or the constant name.
+func enumValue(value *dst.ValueSpec, i int) string {
+	comments := value.Decs.Start.All()
+	if len(comments) > 0 {
+		if name := strings.TrimPrefix(comments[len(comments)-1], "// name:"); name != comments[len(comments)-1] {
+			return name
+		}
+	}
+	if i < len(value.Values) {
+		if lit, ok := value.Values[i].(*dst.BasicLit); ok && lit.Kind == token.STRING {
+			if unquoted, err := strconv.Unquote(lit.Value); err == nil {
+				return unquoted
+			}
+		}
+	}
+	return value.Names[i].Name
+}
+
+// enumDescription returns the description of a constant from its doc
+// comment, or from its line comment, skipping `name:` comments and
+// directives. Lines are joined with spaces to keep lists of values compact.
+func enumDescription(doc, line []string) string {
+	var words []string
+	for _, comments := range [][]string{doc, line} {
+		for _, comment := range comments {
+			text := strings.TrimSpace(strings.TrimPrefix(comment, "//"))
+			if strings.HasPrefix(text, "name:") || strings.HasPrefix(text, "docgen:") || strings.HasPrefix(text, "nolint") {
+				continue
+			}
+			words = append(words, strings.Fields(text)...)
+		}
+		if len(words) > 0 {
+			break
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func hasEnumDirective(comments []string, typeName string) bool {
+	for _, comment := range comments {
+		fields := strings.Fields(strings.TrimPrefix(comment, "//"))
+		if len(fields) == 2 && fields[0] == enumDirective && fields[1] == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+func isTypeIdent(expr dst.Expr, typeName string) bool {
+	ident, ok := expr.(*dst.Ident)
+	return ok && ident.Path == "" && ident.Name == typeName
+}
@@ -0,0 +1,157 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/token"
+	"testing"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+)
+
+const enumSource = `package config
+
+type Level int
+
+type Mode string
+
+type Config struct {
+	Level  Level   ` + "`yaml:\"level\"`" + `
+	Levels []Level ` + "`yaml:\"levels\"`" + `
+	Mode   *Mode   ` + "`yaml:\"mode\"`" + `
+	Name   string  ` + "`yaml:\"name\"`" + `
+}
+
+const (
+	// Debug logs everything.
+	Debug Level = iota
+	Info // Info logs informational messages.
+	_
+	// name:warning
+	Warn
+	Count = 4
+)
+
+// docgen:enum Mode
+const (
+	// Fast skips the checks.
+	Fast = "fast"
+	// Safe runs
+	// all the checks.
+	Safe = "safe" // ignored line comment
+)
+
+// Strict fails on the first error.
+const Strict Mode = "strict"
+
+const Other = "other"
+`
+
+// enumFixture parses the enum source as a package.
+func enumFixture(t *testing.T) (*decorator.Package, *dst.StructType, []*dst.GenDecl) {
+	t.Helper()
+
+	file, err := decorator.Parse(enumSource)
+	require.NoError(t, err)
+
+	var config *dst.StructType
+	var consts []*dst.GenDecl
+	for _, decl := range file.Decls {
+		g, ok := decl.(*dst.GenDecl)
+		if !ok {
+			continue
+		}
+		if g.Tok == token.CONST {
+			consts = append(consts, g)
+			continue
+		}
+		if spec, ok := g.Specs[0].(*dst.TypeSpec); ok && spec.Name.Name == "Config" {
+			config = spec.Type.(*dst.StructType)
+		}
+	}
+	pkg := &decorator.Package{Package: &packages.Package{PkgPath: "example.com/config"}, Syntax: []*dst.File{file}}
+	return pkg, config, consts
+}
+
+func TestEnumBlockValues(t *testing.T) {
+	_, _, consts := enumFixture(t)
+	require.Len(t, consts, 4)
+
+	levels := []EnumValue{
+		{Value: "Debug", Description: "Debug logs everything."},
+		{Value: "Info", Description: "Info logs informational messages."},
+		{Value: "warning"},
+	}
+	modes := []EnumValue{
+		{Value: "fast", Description: "Fast skips the checks."},
+		{Value: "safe", Description: "Safe runs all the checks."},
+	}
+
+	tests := []struct {
+		name     string
+		block    int
+		typeName string
+		expected []EnumValue
+	}{
+		{name: "iota inherits the type", block: 0, typeName: "Level", expected: levels},
+		{name: "other type", block: 0, typeName: "Mode"},
+		{name: "enum directive", block: 1, typeName: "Mode", expected: modes},
+		{name: "enum directive of other type", block: 1, typeName: "Level"},
+		{name: "single typed constant", block: 2, typeName: "Mode", expected: []EnumValue{{Value: "strict", Description: "Strict fails on the first error."}}},
+		{name: "untyped constant", block: 3, typeName: "Mode"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, enumBlockValues(consts[test.block], test.typeName), test.name)
+	}
+}
+
+func TestCollectEnumValues(t *testing.T) {
+	pkg, config, _ := enumFixture(t)
+
+	fields := map[string]dst.Expr{}
+	for _, f := range config.Fields.List {
+		fields[f.Names[0].Name] = f.Type
+	}
+
+	levels := collectEnumValues(fields["Level"], pkg)
+	require.Equal(t, []string{"Debug", "Info", "warning"}, enumValueNames(levels))
+	require.Equal(t, levels, collectEnumValues(fields["Levels"], pkg), "slices use the values of their items")
+
+	modes := collectEnumValues(fields["Mode"], pkg)
+	require.Equal(t, []string{"fast", "safe", "strict"}, enumValueNames(modes), "pointers use the values of their type")
+
+	require.Nil(t, collectEnumValues(fields["Name"], pkg), "predeclared types have no values")
+}
+
+func TestEnumDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      []string
+		line     []string
+		expected string
+	}{
+		{name: "doc comment", doc: []string{"// Fast skips", "// the checks."}, expected: "Fast skips the checks."},
+		{name: "line comment", line: []string{"// Fast skips the checks."}, expected: "Fast skips the checks."},
+		{name: "doc comment first", doc: []string{"// From the doc."}, line: []string{"// From the line."}, expected: "From the doc."},
+		{name: "name comment", doc: []string{"// name:fast"}, line: []string{"// Skips the checks."}, expected: "Skips the checks."},
+		{name: "directives", doc: []string{"// docgen:enum Mode", "// Skips the checks."}, line: []string{"//nolint:gosec"}, expected: "Skips the checks."},
+		{name: "extra white space", doc: []string{"//   Skips    the", "//\tchecks.  "}, expected: "Skips the checks."},
+		{name: "no comments"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, enumDescription(test.doc, test.line), test.name)
+	}
+}
+
+func enumValueNames(values []EnumValue) []string {
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		names = append(names, value.Value)
+	}
+	return names
+}
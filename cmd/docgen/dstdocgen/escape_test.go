@@ -0,0 +1,203 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dave/dst/decorator"
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var escapeCases = []string{
+	`plain text`,
+	`a "quoted" word`,
+	"a `backtick` word",
+	`a 100% match with %s and %d`,
+	`a \d+ regex with C:\path\to\file`,
+	`template {{ .Name }} braces {{- end }}`,
+	"first line\nsecond line",
+	"tab\tseparated",
+	"carriage\r\nreturn",
+	"unicode ✓ and emoji 🚀",
+	"bell \a character",
+	`trailing backslash \`,
+}
+
+func TestEscape(t *testing.T) {
+	for _, value := range escapeCases {
+		unquoted, err := strconv.Unquote(`"` + escape(value) + `"`)
+		require.NoError(t, err, value)
+		require.Equal(t, value, unquoted)
+		require.Equal(t, value, unescape(escape(value)))
+	}
+
+	require.Equal(t, "padded", escape("  padded\n"))
+	require.Equal(t, " leading space", quote(" leading space"))
+}
+
+func TestRenderGoEscaping(t *testing.T) {
+	for _, value := range escapeCases {
+		text := parseComment([]byte("description: " + strconv.Quote(value) + "\nvalues:\n  - " + strconv.Quote(value) + "\ndefault: " + strconv.Quote(value)))
+		require.Equal(t, value, text.Description, value)
+
+		structText := parseComment([]byte(value))
+		structText.Discriminator = &Discriminator{Field: value, Mapping: map[string]string{value: value}}
+		text.Accepts = []string{value}
+
+		doc := &Doc{
+			Name:     "Config",
+			Package:  "main",
+			Header:   escape(value),
+			Dialects: []string{"json"},
+			Structs: []*Struct{{
+				name:       "Config",
+				Text:       structText,
+				PartValues: []Example{{Name: value, Value: value}},
+				Fields: []*Field{{
+					Name: "Host",
+					Tag:  "host",
+					Type: "string",
+					Text: text,
+					Note: escape(value),
+					Tags: map[string]string{value: value},
+				}},
+			}},
+		}
+
+		_, err := renderGo(doc)
+		require.NoError(t, err, value)
+
+		fd := doc.toFileDoc()
+		field := fd.Structs[0].Fields[0]
+		require.Equal(t, value, field.Description)
+		require.Equal(t, []string{value}, field.Values)
+		require.Equal(t, value, field.Default)
+		require.Equal(t, value, fd.Description)
+		require.Equal(t, &encoder.Discriminator{Field: value, Mapping: map[string]string{value: value}}, fd.Structs[0].Discriminator)
+		require.Equal(t, []encoder.KeyValue{{Key: value, Value: value}}, fd.Structs[0].PartDefinitions)
+		require.Equal(t, []string{value}, field.Accepts)
+		require.Equal(t, map[string]string{value: value}, field.Tags)
+	}
+}
+
+func TestPartDefinitionLiterals(t *testing.T) {
+	file, err := decorator.Parse("package config\n\n// Parts of the request.\nvar parts = map[string]string{\n\t\"raw\": `a \"quoted\" \\d+ value`,\n\t\"interpreted\": \"a \\\"quoted\\\" \\\\d+ value\",\n}\n")
+	require.NoError(t, err)
+
+	require.Equal(t, []Example{
+		{Name: "raw", Value: `a "quoted" \d+ value`},
+		{Name: "interpreted", Value: `a "quoted" \d+ value`},
+	}, collectPartDefinitions(file, "parts"))
+}
+
+func TestPreserveMarkdown(t *testing.T) {
+	comment := []byte(" Steps lists the steps:\n\n   - first\n     continued\n   - second\n\n\tsteps:\n\t  - type: dns")
+
+	text := parseComment(comment)
+	require.Equal(t, "Steps lists the steps:\n\n   - first\n     continued\n   - second\n\n\tsteps:\n\t  - type: dns", text.Description)
+	require.Equal(t, "Steps lists the steps:", text.Comment)
+
+	*preserveMarkdown = true
+	defer func() { *preserveMarkdown = false }()
+
+	text = parseComment(comment)
+	require.Equal(t, "Steps lists the steps:\n\n  - first\n    continued\n  - second\n\n\tsteps:\n\t  - type: dns", text.Description)
+	require.Equal(t, "Steps lists the steps:", text.Comment)
+}
+
+func TestYAMLExample(t *testing.T) {
+	for _, snippet := range []string{"host: localhost\nport: 8080", "query: '`id`'\nregex: '\\d+'"} {
+		text := parseComment([]byte("Endpoint to connect to.\n\nexample: |\n  " + strings.ReplaceAll(snippet, "\n", "\n  ")))
+		require.Equal(t, "Endpoint to connect to.", text.Description)
+		require.Len(t, text.Examples, 1)
+		require.Equal(t, snippet, text.Examples[0].YAML)
+
+		doc := &Doc{
+			Name:    "Config",
+			Package: "main",
+			Structs: []*Struct{{
+				name: "Config",
+				Text: &Text{},
+				Fields: []*Field{{
+					Name: "Endpoint",
+					Tag:  "endpoint",
+					Type: "map[string]string",
+					Text: text,
+				}},
+			}},
+		}
+
+		data, err := renderGo(doc)
+		require.NoError(t, err, snippet)
+		require.Contains(t, string(data), "AddYAMLExample(\"\", "+rawString(snippet)+")")
+		require.Empty(t, checkExamples(doc))
+
+		var expected interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(snippet), &expected))
+		data, err = json.Marshal(doc.toFileDoc().Structs[0].Fields[0].Examples[0])
+		require.NoError(t, err)
+		expectedData, err := json.Marshal(map[string]interface{}{"Name": "", "Value": expected})
+		require.NoError(t, err)
+		require.JSONEq(t, string(expectedData), string(data))
+	}
+}
+
+func TestLoadFileHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "header.txt")
+	require.NoError(t, os.WriteFile(path, []byte("Copyright 2026 Example Corp.\n\n/*\n SPDX-License-Identifier: Apache-2.0\n*/\n// All rights reserved.\n\n"), 0o600))
+
+	header, err := loadFileHeader(path)
+	require.NoError(t, err)
+	require.Equal(t, "// Copyright 2026 Example Corp.\n//\n/*\n SPDX-License-Identifier: Apache-2.0\n*/\n// All rights reserved.", header)
+
+	data, err := renderGo(&Doc{Package: "config", FileHeader: header})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), header+"\n\n// Code generated by docgen. DO NOT EDIT.\n\npackage config\n"))
+}
+
+func TestProvenance(t *testing.T) {
+	require.Equal(t, `dstdocgen -path . -structure Job -header-file "license header.txt"`, invocation([]string{"/go/bin/dstdocgen", "-path", ".", "-structure", "Job", "-header-file", "license header.txt"}))
+
+	data, err := renderGo(&Doc{Package: "config", FileHeader: defaultFileHeader, Command: "dstdocgen -path .", SourceHash: "abc"})
+	require.NoError(t, err)
+	require.Regexp(t, `(?m)^// Code generated .* DO NOT EDIT\.$`, string(data))
+	require.Contains(t, string(data), "// Command: dstdocgen -path .\n// Source hash: sha256:abc\n\npackage config\n")
+}
+
+func TestSourcePosition(t *testing.T) {
+	doc := &Doc{
+		Name:    "Config",
+		Package: "main",
+		Structs: []*Struct{{
+			name:   "Config",
+			Text:   &Text{},
+			Source: &encoder.Source{File: "pkg/config/config.go", Line: 12},
+			Fields: []*Field{{
+				Name:   "Name",
+				Tag:    "name",
+				Type:   "string",
+				Text:   &Text{},
+				Source: &encoder.Source{File: "pkg/config/config.go", Line: 14},
+			}},
+		}},
+	}
+
+	data, err := renderGo(doc)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `ConfigDoc.Source = &encoder.Source{File: "pkg/config/config.go", Line: 12}`)
+	require.Contains(t, string(data), `ConfigDoc.Fields[0].Source = &encoder.Source{File: "pkg/config/config.go", Line: 14}`)
+
+	fd := doc.toFileDoc()
+	require.Equal(t, "pkg/config/config.go:12", fd.Structs[0].Source.String())
+	require.Equal(t, "pkg/config/config.go:14", fd.Structs[0].Fields[0].Source.String())
+}
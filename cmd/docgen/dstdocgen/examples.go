@@ -0,0 +1,176 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	"gopkg.in/yaml.v2"
+)
+
+// checkExamples validates the inline examples against the documented type
+// they belong to, returning a description of every example which would
+// not load.
+//
+// Only yaml snippets and examples with a literal value are checked, as go
+// identifiers and expressions are compiled instead. String literals of fields which are
+// not strings themselves, such as structs and lists, are parsed as yaml.
+// Bad examples are reported when they are accepted instead.
+func checkExamples(doc *Doc) []string {
+	fd := doc.toFileDoc()
+
+	var issues []string
+	// field examples are also added to the examples of the struct type
+	// of the field, so every example is only checked once
+	checked := map[*Example]bool{}
+	check := func(owner, typ string, example *Example) {
+		if checked[example] {
+			return
+		}
+		checked[example] = true
+
+		data, ok := []byte(example.YAML), true
+		if example.YAML == "" {
+			data, ok = exampleYAML(fd, typ, example.Value)
+		}
+		if !ok {
+			return
+		}
+		for _, err := range encoder.ValidateType(data, fd, typ) {
+			issue := fmt.Sprintf("%s: example %q: %s", owner, unescape(example.Name), err.Error())
+			addDiagnostic(levelWarning, example.Position, "stale-example", issue)
+			issues = append(issues, issue)
+		}
+	}
+
+	for _, s := range doc.Structs {
+		for _, field := range s.Fields {
+			for _, example := range field.Text.Examples {
+				check(s.GetName()+"."+field.Tag, field.Type, example)
+			}
+		}
+	}
+	for _, s := range doc.Structs {
+		for _, example := range s.Text.Examples {
+			check(s.GetName(), s.GetName(), example)
+		}
+	}
+	for _, err := range fd.CheckBadExamples() {
+		addDiagnostic(levelWarning, token.Position{}, "accepted-bad-example", err.Error())
+		issues = append(issues, err.Error())
+	}
+	return issues
+}
+
+// exampleYAML returns the yaml document for a literal example value of
+// the type, returning false for values which can not be checked.
+func exampleYAML(fd *encoder.FileDoc, typ, value string) ([]byte, bool) {
+	literal, ok := literalValue(value)
+	if !ok {
+		return nil, false
+	}
+
+	if text, isString := literal.(string); isString && isCompositeType(fd, typ) {
+		return []byte(text), true
+	}
+	data, err := yaml.Marshal(literal)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// isCompositeType returns true for documented struct, slice and map types.
+func isCompositeType(fd *encoder.FileDoc, typ string) bool {
+	return strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") || fd.Struct(typ) != nil
+}
+
+// setExamplesPosition records the source position of the declaration
+// documented by the text on its examples.
+func setExamplesPosition(text *Text, node dst.Node, pkg *decorator.Package) {
+	if len(text.Examples) == 0 {
+		return
+	}
+	position := nodePosition(pkg, node)
+	for _, example := range text.Examples {
+		example.Position = position
+	}
+}
+
+// checkExampleExpressions type checks the go expressions of the examples
+// in the scope of the package the documentation code is generated in,
+// returning an error listing the position of every example which would
+// not compile. Nothing is checked if the code is generated in another
+// package, whose identifiers are unknown.
+func checkExampleExpressions(pkgs []*decorator.Package, structures []*structType) error {
+	var scope *types.Package
+	var fset *token.FileSet
+	for _, pkg := range pkgs {
+		if pkg.Name == *packageName && pkg.Types != nil {
+			scope, fset = pkg.Types, pkg.Fset
+			break
+		}
+	}
+	if scope == nil {
+		return nil
+	}
+
+	var issues []string
+	check := func(owner string, examples []*Example) {
+		for _, example := range examples {
+			if example.Value == "" || example.YAML != "" {
+				continue
+			}
+			if _, ok := literalValue(example.Value); ok {
+				continue
+			}
+			if err := checkExpression(fset, scope, example.Value); err != nil {
+				issues = append(issues, fmt.Sprintf("%s: %s: example %q: %s", example.Position, owner, unescape(example.Name), err))
+			}
+		}
+	}
+	for _, s := range structures {
+		name := wrapStructName(s.packagePrefix, s.name)
+		check(name, s.text.Examples)
+		for _, field := range s.fields {
+			check(name+"."+field.Tag, field.Text.Examples)
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+	return errors.Errorf("invalid examples:\n%s", strings.Join(issues, "\n"))
+}
+
+// checkExpression type checks a go expression in the package scope,
+// which has to evaluate to a value.
+func checkExpression(fset *token.FileSet, pkg *types.Package, value string) error {
+	expr, err := parser.ParseExpr(value)
+	if err != nil {
+		return errors.Wrap(err, "could not parse value")
+	}
+	info := &types.Info{Types: map[ast.Expr]types.TypeAndValue{}}
+	if err := types.CheckExpr(fset, pkg, token.NoPos, expr, info); err != nil {
+		// the position of the error is within the value, not the package
+		if typeErr, ok := err.(types.Error); ok {
+			return errors.New(typeErr.Msg)
+		}
+		return err
+	}
+	if tv := info.Types[expr]; !tv.IsValue() {
+		return errors.Errorf("%s is not a value", value)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckExpression(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "config.go", `package config
+
+type Endpoint struct{ Host string }
+
+var exampleEndpoint = &Endpoint{Host: "localhost"}
+`, 0)
+	require.NoError(t, err)
+	pkg, err := (&types.Config{}).Check("config", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+
+	for _, value := range []string{"exampleEndpoint", `&Endpoint{Host: "example.com"}`, `[]*Endpoint{exampleEndpoint}`} {
+		require.NoError(t, checkExpression(fset, pkg, value), value)
+	}
+
+	require.EqualError(t, checkExpression(fset, pkg, "exampleEndpont"), "undefined: exampleEndpont")
+	require.EqualError(t, checkExpression(fset, pkg, "Endpoint"), "Endpoint is not a value")
+	err = checkExpression(fset, pkg, `&Endpoint{Port: 80}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown field Port")
+	require.Error(t, checkExpression(fset, pkg, "exampleEndpoint["))
+}
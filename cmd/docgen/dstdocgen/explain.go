@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// explainCommand collects the documentation for the structure and prints
+// the documentation of the field at the dotted path given as argument.
+func explainCommand(args []string) error {
+	fs := newFlagSet("explain")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("expected a single field path, got %d arguments", fs.NArg())
+	}
+
+	// only print the explanation to stdout
+	progress = io.Discard
+
+	doc, err := collect()
+	if err != nil {
+		return errors.Wrap(err, "could not collect documentation")
+	}
+
+	text, err := doc.toFileDoc().Explain(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Print(text)
+	return nil
+}
@@ -0,0 +1,101 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+)
+
+// externalProtocolVersion is the version of the external renderer protocol,
+// bumped on incompatible changes of the request or the response.
+const externalProtocolVersion = 1
+
+// externalRequest is written as JSON to the standard input of external
+// renderers.
+type externalRequest struct {
+	Version int              `json:"version"`
+	Package string           `json:"package"`
+	Doc     *encoder.FileDoc `json:"doc"`
+}
+
+// externalResponse is read as JSON from the standard output of external
+// renderers.
+type externalResponse struct {
+	Files []externalFile `json:"files"`
+}
+
+// externalFile is a file rendered by an external renderer, with a path
+// relative to the output directory.
+type externalFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// renderExternal pipes the documentation model to the -renderer-cmd
+// command and writes the files it returns to the dest directory. The
+// command is split on white space and run without a shell, its standard
+// error is passed through.
+func renderExternal(doc *Doc, dest string) error {
+	args := strings.Fields(*rendererCmd)
+	if len(args) == 0 {
+		return fmt.Errorf("empty renderer command")
+	}
+
+	request, err := json.Marshal(&externalRequest{
+		Version: externalProtocolVersion,
+		Package: doc.Package,
+		Doc:     doc.toFileDoc(),
+	})
+	if err != nil {
+		return err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "could not run renderer %s", args[0])
+	}
+
+	var response externalResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return errors.Wrap(err, "could not decode renderer response")
+	}
+
+	for _, file := range response.Files {
+		path, err := externalPath(dest, file.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(file.Content), 0o644); err != nil {
+			return errors.Wrap(err, "could not write rendered file")
+		}
+	}
+	return nil
+}
+
+// externalPath returns the path of a rendered file within the dest
+// directory, rejecting paths escaping it or naming the directory itself.
+func externalPath(dest, path string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(path))
+	if path == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("renderer returned invalid path %q", path)
+	}
+	return filepath.Join(dest, clean), nil
+}
@@ -0,0 +1,44 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalPath(t *testing.T) {
+	dest := filepath.Join("docs", "out")
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "index.md", expected: filepath.Join(dest, "index.md")},
+		{path: "types/job.md", expected: filepath.Join(dest, "types", "job.md")},
+		{path: "types/../index.md", expected: filepath.Join(dest, "index.md")},
+		{path: "./..x/job.md", expected: filepath.Join(dest, "..x", "job.md")},
+		{path: ""},
+		{path: "."},
+		{path: "a/.."},
+		{path: ".."},
+		{path: "../x"},
+		{path: "a/../../x"},
+		{path: "a/b/../../../x"},
+		{path: "/etc/passwd"},
+		{path: "/x/../y"},
+	}
+	for _, test := range tests {
+		path, err := externalPath(dest, test.path)
+		if test.expected == "" {
+			require.EqualError(t, err, "renderer returned invalid path \""+test.path+"\"", test.path)
+			continue
+		}
+		require.NoError(t, err, test.path)
+		require.Equal(t, test.expected, path, test.path)
+	}
+}
@@ -0,0 +1,182 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+)
+
+// toFileDoc converts the collected documentation into the runtime
+// encoder representation, so that it can be used without generating
+// and compiling the documentation code first.
+//
+// Examples are only carried over when they are yaml snippets or their
+// value is a basic literal, as arbitrary go expressions can not be
+// evaluated at runtime.
+func (d *Doc) toFileDoc() *encoder.FileDoc {
+	fd := &encoder.FileDoc{
+		Name:        d.Name,
+		Description: unescape(d.Header),
+	}
+	for _, entry := range d.Glossary {
+		fd.Glossary = append(fd.Glossary, encoder.GlossaryTerm{
+			Term:       unescape(entry.Term),
+			Definition: unescape(entry.Definition),
+		})
+	}
+	for _, dialect := range d.Dialects {
+		fd.Dialects = append(fd.Dialects, encoder.Dialect(dialect))
+	}
+
+	for _, s := range d.Structs {
+		doc := &encoder.Doc{
+			Type:        s.GetName(),
+			Description: s.Text.Description,
+			DocsURL:     unescape(s.Text.DocsURL),
+			Stability:   s.Text.Stability,
+			Source:      s.Source,
+		}
+		doc.Comments[encoder.LineComment] = unescape(s.Text.Comment)
+		if s.Diagram != nil {
+			doc.Diagram = &encoder.Diagram{
+				Path:    unescape(s.Diagram.Path),
+				Mermaid: unescape(s.Diagram.Mermaid),
+				Data:    []byte(unescape(s.Diagram.Data)),
+			}
+		}
+		if d := s.Text.Discriminator; d != nil {
+			doc.Discriminator = &encoder.Discriminator{Field: d.Field, Mapping: d.Mapping}
+		}
+		addExamples(doc, s.Text.Examples)
+
+		for _, appearance := range s.AppearsIn {
+			doc.AppearsIn = append(doc.AppearsIn, encoder.Appearance{
+				TypeName:  appearance.Struct.GetName(),
+				FieldName: appearance.FieldName,
+				Kind:      appearance.Kind,
+				Path:      appearance.Path,
+			})
+		}
+		for _, value := range s.PartValues {
+			doc.PartDefinitions = append(doc.PartDefinitions, encoder.KeyValue{
+				Key:   value.Name,
+				Value: value.Value,
+			})
+		}
+
+		doc.Fields = make([]encoder.Doc, len(s.Fields))
+		for i, f := range s.Fields {
+			field := &doc.Fields[i]
+			field.Name = f.Tag
+			field.Type = f.Type
+			field.Note = unescape(f.Note)
+			field.Description = f.Text.Description
+			field.DocsURL = unescape(f.Text.DocsURL)
+			field.Comments[encoder.LineComment] = unescape(f.Text.Comment)
+			for _, value := range f.Text.Values {
+				field.Values = append(field.Values, unescape(value))
+			}
+			field.Required = f.Text.Required
+			field.Secret = f.Secret
+			field.Accepts = f.Text.Accepts
+			field.KeyDoc = unescape(f.Text.KeyDoc)
+			field.ReplacedBy = unescape(f.Text.ReplacedBy)
+			field.Default = unescape(f.Text.Default)
+			for _, bad := range f.Text.BadExamples {
+				field.BadExamples = append(field.BadExamples, encoder.BadExample{
+					Name:   unescape(bad.Name),
+					Value:  unescape(bad.Value),
+					Reason: unescape(bad.Reason),
+				})
+			}
+			field.MinItems = f.Text.MinItems
+			field.MaxItems = f.Text.MaxItems
+			field.UniqueItems = f.Text.Unique
+			for _, value := range f.EnumFields {
+				field.EnumFields = append(field.EnumFields, unescape(value.Value))
+				field.EnumValues = append(field.EnumValues, encoder.EnumValue{
+					Value:       unescape(value.Value),
+					Description: unescape(value.Description),
+				})
+			}
+			field.Tags = f.Tags
+			field.Source = f.Source
+			addExamples(field, f.Text.Examples)
+		}
+
+		fd.Structs = append(fd.Structs, doc)
+	}
+	return fd
+}
+
+// setAppearancePaths sets the paths of the appearances of the structs from
+// the root struct, computed on the encoder representation.
+func setAppearancePaths(d *Doc) {
+	fd := d.toFileDoc()
+	fd.SetAppearancePaths()
+	for i, s := range d.Structs {
+		for j := range s.AppearsIn {
+			s.AppearsIn[j].Path = fd.Structs[i].AppearsIn[j].Path
+		}
+	}
+}
+
+// addExamples adds the yaml examples and the examples with a basic literal
+// value to the doc.
+func addExamples(doc *encoder.Doc, examples []*Example) {
+	for _, example := range examples {
+		if example.YAML != "" {
+			doc.AddYAMLExample(unescape(example.Name), example.YAML)
+		} else if value, ok := literalValue(example.Value); ok {
+			doc.AddExample(unescape(example.Name), value)
+		}
+	}
+}
+
+// literalValue evaluates a go expression consisting of a single basic
+// literal or boolean constant.
+func literalValue(expr string) (interface{}, bool) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, false
+	}
+
+	switch t := parsed.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	case *ast.BasicLit:
+		switch t.Kind {
+		case token.STRING:
+			value, err := strconv.Unquote(t.Value)
+			return value, err == nil
+		case token.INT:
+			value, err := strconv.ParseInt(t.Value, 0, 64)
+			return int(value), err == nil
+		case token.FLOAT:
+			value, err := strconv.ParseFloat(t.Value, 64)
+			return value, err == nil
+		}
+	}
+	return nil, false
+}
+
+// unescape reverts the escaping applied to strings for the generated code.
+func unescape(value string) string {
+	unquoted, err := strconv.Unquote(`"` + value + `"`)
+	if err != nil {
+		return value
+	}
+	return unquoted
+}
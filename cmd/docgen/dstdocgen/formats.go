@@ -0,0 +1,147 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+)
+
+// formatOutputs are the files set with the -out-<format> flags, which are
+// rendered in addition to -output from a single load of the packages.
+var formatOutputs = map[string]*string{}
+
+func init() {
+	for name := range renderers {
+		formatOutputs[name] = flag.String("out-"+name, "", fmt.Sprintf("File to write the %s output format to, in addition to -output", name))
+	}
+}
+
+// formatOutput is an output format rendered to a file.
+type formatOutput struct {
+	format string
+	path   string
+}
+
+// extraOutputs returns the outputs set with the -out-<format> flags,
+// sorted by format.
+func extraOutputs() []formatOutput {
+	var outputs []formatOutput
+	for name, path := range formatOutputs {
+		if *path != "" {
+			outputs = append(outputs, formatOutput{format: name, path: *path})
+		}
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].format < outputs[j].format
+	})
+	return outputs
+}
+
+// goOutputPath returns the file the go code is generated to, if any.
+func goOutputPath() string {
+	if *outputFormat == "go" && *rendererCmd == "" && *output != "" {
+		return *output
+	}
+	return *formatOutputs["go"]
+}
+
+// renderMarkdown renders the documentation as a markdown page.
+func renderMarkdown(doc *Doc) ([]byte, error) {
+	return doc.toFileDoc().Encode()
+}
+
+// renderTOMLMarkdown renders the documentation as a markdown page with the
+// field keys and commented examples written in TOML.
+func renderTOMLMarkdown(doc *Doc) ([]byte, error) {
+	return doc.toFileDoc().ToMarkdown(&encoder.MarkdownOptions{Dialect: encoder.DialectTOML})
+}
+
+// renderTool renders the documentation as an LLM function calling
+// tool definition.
+func renderTool(doc *Doc) ([]byte, error) {
+	return json.MarshalIndent(doc.toFileDoc().ToolDefinition(), "", "  ")
+}
+
+// renderJSON renders the documentation model as JSON.
+func renderJSON(doc *Doc) ([]byte, error) {
+	return json.MarshalIndent(doc.toFileDoc(), "", "  ")
+}
+
+// renderOpenAPI renders the documented structs as OpenAPI components.
+func renderOpenAPI(doc *Doc) ([]byte, error) {
+	return json.MarshalIndent(doc.toFileDoc().OpenAPI(*apiVersion), "", "  ")
+}
+
+// renderCatalog renders the schemastore.org catalog entry of the JSON Schema
+// published at -catalog-url.
+func renderCatalog(doc *Doc) ([]byte, error) {
+	if *catalogURL == "" {
+		return nil, fmt.Errorf("the catalog format requires -catalog-url")
+	}
+
+	var patterns []string
+	for _, pattern := range strings.Split(*fileMatch, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return json.MarshalIndent(doc.toFileDoc().CatalogEntry(*catalogURL, patterns...), "", "  ")
+}
+
+// renderSchema renders the documentation as a JSON Schema annotated for
+// the yaml language server and JetBrains IDEs.
+func renderSchema(doc *Doc) ([]byte, error) {
+	return json.MarshalIndent(doc.toFileDoc().LanguageServerSchema(), "", "  ")
+}
+
+// renderCompletion renders the keys and values of the documentation as
+// complete-items for vim and neovim omnifuncs.
+func renderCompletion(doc *Doc) ([]byte, error) {
+	return json.MarshalIndent(doc.toFileDoc().Completions(), "", "  ")
+}
+
+// renderDictionary renders the keys and values of the documentation as a
+// vim dictionary file.
+func renderDictionary(doc *Doc) ([]byte, error) {
+	return doc.toFileDoc().Dictionary(), nil
+}
+
+// renderPaths renders the dotted paths of the keys of the documentation
+// with their short descriptions, for shell completion scripts.
+func renderPaths(doc *Doc) ([]byte, error) {
+	return doc.toFileDoc().KeyPaths(), nil
+}
+
+// renderSnippets renders a snippet inserting every key of the
+// documentation as a VS Code snippet file.
+func renderSnippets(doc *Doc) ([]byte, error) {
+	return json.MarshalIndent(doc.toFileDoc().Snippets(), "", "  ")
+}
+
+// renderMDX renders the documentation as a Docusaurus MDX page.
+func renderMDX(doc *Doc) ([]byte, error) {
+	return doc.toFileDoc().EncodeMDX(&encoder.MDXOptions{
+		Title:           *mdxTitle,
+		SidebarPosition: *mdxSidebar,
+	})
+}
+
+// renderDOT renders the references between the structs as a Graphviz
+// DOT graph.
+func renderDOT(doc *Doc) ([]byte, error) {
+	return []byte(doc.toFileDoc().DOT()), nil
+}
+
+// renderMermaid renders the references between the structs as a Mermaid
+// flowchart.
+func renderMermaid(doc *Doc) ([]byte, error) {
+	return []byte(doc.toFileDoc().Mermaid()), nil
+}
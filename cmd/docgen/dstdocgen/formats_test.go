@@ -0,0 +1,75 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtraOutputs(t *testing.T) {
+	defer func() {
+		*formatOutputs["json"], *formatOutputs["md"], *formatOutputs["go"] = "", "", ""
+		*output, *outputFormat = "", "go"
+	}()
+	require.Empty(t, extraOutputs())
+
+	*formatOutputs["md"], *formatOutputs["json"] = "config.md", "config.json"
+	require.Equal(t, []formatOutput{{format: "json", path: "config.json"}, {format: "md", path: "config.md"}}, extraOutputs())
+
+	*output = "config_doc.go"
+	require.Equal(t, "config_doc.go", goOutputPath())
+
+	// the go code is only generated to -output in the go format
+	*outputFormat = "json"
+	require.Equal(t, "", goOutputPath())
+	*formatOutputs["go"] = "docs_doc.go"
+	require.Equal(t, "docs_doc.go", goOutputPath())
+}
+
+func TestGenerateExtraOutputs(t *testing.T) {
+	dir := t.TempDir()
+	defer func(path, name, out string, w io.Writer) {
+		*inputPath, *structure, *output, progress = path, name, out, w
+		*formatOutputs["json"], *formatOutputs["md"] = "", ""
+	}(*inputPath, *structure, *output, progress)
+	*inputPath, *structure, progress = filepath.Join("testdata", "names"), "Config", io.Discard
+
+	*output = filepath.Join(dir, "config_doc.go")
+	*formatOutputs["json"] = filepath.Join(dir, "config.json")
+	*formatOutputs["md"] = filepath.Join(dir, "config.md")
+
+	doc, err := generate()
+	require.NoError(t, err)
+	require.Equal(t, *output, doc.File)
+
+	code, err := os.ReadFile(*output)
+	require.NoError(t, err)
+	require.Contains(t, string(code), "func GetConfigDoc() *encoder.FileDoc {")
+
+	data, err := os.ReadFile(*formatOutputs["json"])
+	require.NoError(t, err)
+	var fd encoder.FileDoc
+	require.NoError(t, json.Unmarshal(data, &fd))
+	require.Equal(t, "Config", fd.Name)
+
+	markdown, err := os.ReadFile(*formatOutputs["md"])
+	require.NoError(t, err)
+	require.Contains(t, string(markdown), "## Config")
+
+	// without -output, only the -out-<format> files are written
+	require.NoError(t, os.Remove(*output))
+	*output = ""
+	_, err = generate()
+	require.NoError(t, err)
+	require.NoFileExists(t, filepath.Join(dir, "config_doc.go"))
+	require.FileExists(t, *formatOutputs["json"])
+}
@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// stdoutPath is the -output value writing the generated code to stdout.
+const stdoutPath = "-"
+
+// goGenerateDefaults defaults the flags which are not set from the
+// environment of go:generate. The package is documented at the current
+// directory, the generated code belongs to $GOPACKAGE and the structure is
+// the first struct declared after the go:generate directive in $GOFILE.
+func goGenerateDefaults() error {
+	file := os.Getenv("GOFILE")
+	if file == "" || *configPath != "" {
+		return nil
+	}
+
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	if goPackage := os.Getenv("GOPACKAGE"); goPackage != "" && !set["package"] {
+		*packageName = goPackage
+	}
+	if set["path"] {
+		return nil
+	}
+	*inputPath = "."
+
+	if *structure != "" || *allStructs {
+		return nil
+	}
+	line, _ := strconv.Atoi(os.Getenv("GOLINE"))
+	name, err := structAfterLine(file, line)
+	if err != nil {
+		return err
+	}
+	*structure = name
+	return nil
+}
+
+// structAfterLine returns the name of the first struct type declared after
+// the line of the file.
+func structAfterLine(path string, line int) (string, error) {
+	fset := token.NewFileSet()
+	_, specs, err := declaredStructs(fset, path)
+	if err != nil {
+		return "", errors.Wrap(err, "could not parse $GOFILE")
+	}
+
+	for _, spec := range specs {
+		if fset.Position(spec.Pos()).Line > line {
+			return spec.Name.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no struct declared after line %d of %s, set -structure", line, path)
+}
+
+// declarationOrder maps the structs declared in the input file of the
+// positional form to their position in the file, nil otherwise.
+var declarationOrder map[string]int
+
+// positionalArgs applies the positional arguments of the Talos docgen, the
+// input file, the output file and the name of the documentation. Like the
+// Talos docgen, every struct declared in the input file is documented,
+// in the package of the input file unless -package is set.
+func positionalArgs(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("expected <input file> <output file> <name> arguments, got %d arguments", len(args))
+	}
+
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for _, name := range []string{"path", "output", "structure", "all"} {
+		if set[name] {
+			return fmt.Errorf("-%s can not be combined with positional arguments", name)
+		}
+	}
+
+	filePackage, specs, err := declaredStructs(token.NewFileSet(), args[0])
+	if err != nil {
+		return errors.Wrap(err, "could not parse input file")
+	}
+	if len(specs) == 0 {
+		return fmt.Errorf("no struct declared in %s", args[0])
+	}
+
+	declarationOrder = make(map[string]int, len(specs))
+	for i, spec := range specs {
+		declarationOrder[spec.Name.Name] = i
+	}
+	*inputPath = filepath.Dir(args[0])
+	*output = args[1]
+	*structure = args[2]
+	*allStructs = true
+	if !set["package"] {
+		*packageName = filePackage
+	}
+	if !set["include-types"] {
+		names := make([]string, len(specs))
+		for i, spec := range specs {
+			names[i] = spec.Name.Name
+		}
+		*includeTypes = "^(" + strings.Join(names, "|") + ")$"
+	}
+	return nil
+}
+
+// declaredStructs parses the go file and returns its package name along
+// with the struct types it declares.
+func declaredStructs(fset *token.FileSet, path string) (string, []*ast.TypeSpec, error) {
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var specs []*ast.TypeSpec
+	for _, decl := range file.Decls {
+		g, ok := decl.(*ast.GenDecl)
+		if !ok || g.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range g.Specs {
+			t := spec.(*ast.TypeSpec)
+			if _, ok := t.Type.(*ast.StructType); ok {
+				specs = append(specs, t)
+			}
+		}
+	}
+	return file.Name.Name, specs, nil
+}
@@ -0,0 +1,72 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoGenerateDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.go")
+	require.NoError(t, os.WriteFile(path, []byte(`package config
+
+type Options struct{}
+
+//go:generate dstdocgen -output -
+type Config struct {
+	Name string `+"`yaml:\"name\"`"+`
+}
+`), 0o600))
+
+	t.Setenv("GOFILE", path)
+	t.Setenv("GOPACKAGE", "config")
+	t.Setenv("GOLINE", "5")
+	defer func(path, name, pkg string) {
+		*inputPath, *structure, *packageName = path, name, pkg
+	}(*inputPath, *structure, *packageName)
+
+	require.NoError(t, goGenerateDefaults())
+	require.Equal(t, ".", *inputPath)
+	require.Equal(t, "Config", *structure)
+	require.Equal(t, "config", *packageName)
+
+	_, err := structAfterLine(path, 6)
+	require.EqualError(t, err, "no struct declared after line 6 of "+path+", set -structure")
+}
+
+func TestPositionalArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "types.go")
+	require.NoError(t, os.WriteFile(path, []byte(`package types
+
+type Step struct{}
+
+type Job struct{}
+`), 0o600))
+
+	defer func(path, out, name, pkg, include string, all bool) {
+		*inputPath, *output, *structure, *packageName, *includeTypes, *allStructs = path, out, name, pkg, include, all
+	}(*inputPath, *output, *structure, *packageName, *includeTypes, *allStructs)
+	defer func() { declarationOrder = nil }()
+
+	require.NoError(t, positionalArgs([]string{path, "types_doc.go", "Configuration"}))
+	require.Equal(t, filepath.Dir(path), *inputPath)
+	require.Equal(t, "types_doc.go", *output)
+	require.Equal(t, "Configuration", *structure)
+	require.Equal(t, "types", *packageName)
+	require.Equal(t, "^(Step|Job)$", *includeTypes)
+	require.True(t, *allStructs)
+
+	// the structures keep their declaration order
+	structures := []*structType{{name: "Job"}, {name: "Step"}}
+	sortStructures(structures)
+	require.Equal(t, "Step", structures[0].name)
+	require.Equal(t, "Job", structures[1].name)
+
+	require.EqualError(t, positionalArgs([]string{path, "types_doc.go"}), "expected <input file> <output file> <name> arguments, got 2 arguments")
+}
@@ -0,0 +1,71 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	"gopkg.in/yaml.v2"
+)
+
+// loadDocsURLs reads the central mapping of documentation URLs. Keys are
+// either type names or type.field paths using the yaml field name:
+//
+//	Config: https://example.com/docs/config
+//	Config.timeout: https://example.com/docs/timeouts
+func loadDocsURLs(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read docs urls file")
+	}
+
+	urls := map[string]string{}
+	if err := yaml.Unmarshal(data, &urls); err != nil {
+		return nil, errors.Wrap(err, "could not parse docs urls file")
+	}
+	return urls, nil
+}
+
+// applyDocsURLs sets the documentation URLs from the mapping for the
+// structs and fields not declaring a docs-url in their comments.
+func applyDocsURLs(doc *Doc, urls map[string]string) {
+	for _, s := range doc.Structs {
+		if url, ok := urls[s.GetName()]; ok && s.Text.DocsURL == "" {
+			s.Text.DocsURL = escape(url)
+		}
+		for _, field := range s.Fields {
+			if url, ok := urls[s.GetName()+"."+field.Tag]; ok && field.Text.DocsURL == "" {
+				field.Text.DocsURL = escape(url)
+			}
+		}
+	}
+}
+
+// loadGlossary reads the glossary file mapping terms to their definitions.
+// The terms are sorted alphabetically and escaped for the generated code.
+func loadGlossary(path string) ([]encoder.GlossaryTerm, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read glossary file")
+	}
+
+	definitions := map[string]string{}
+	if err := yaml.Unmarshal(data, &definitions); err != nil {
+		return nil, errors.Wrap(err, "could not parse glossary file")
+	}
+
+	glossary := make([]encoder.GlossaryTerm, 0, len(definitions))
+	for term, definition := range definitions {
+		glossary = append(glossary, encoder.GlossaryTerm{Term: escape(term), Definition: escape(definition)})
+	}
+	sort.Slice(glossary, func(i, j int) bool {
+		return strings.ToLower(glossary[i].Term) < strings.ToLower(glossary[j].Term)
+	})
+	return glossary, nil
+}
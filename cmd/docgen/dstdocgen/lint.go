@@ -0,0 +1,88 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// coverage contains documentation coverage statistics for a Doc.
+type coverage struct {
+	Fields      int
+	Described   int
+	Exemplified int
+	Issues      []string
+}
+
+// DescriptionPercent returns the percentage of fields with a description.
+func (c *coverage) DescriptionPercent() float64 {
+	return percent(c.Described, c.Fields)
+}
+
+// ExamplePercent returns the percentage of fields with an example.
+func (c *coverage) ExamplePercent() float64 {
+	return percent(c.Exemplified, c.Fields)
+}
+
+func percent(count, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(count) * 100 / float64(total)
+}
+
+// computeCoverage returns the documentation coverage of all the exported
+// yaml-tagged fields of the collected structures.
+func computeCoverage(doc *Doc) *coverage {
+	c := &coverage{}
+
+	for _, s := range doc.Structs {
+		for _, name := range s.undocumented {
+			c.Fields++
+			c.Issues = append(c.Issues, fmt.Sprintf("%s.%s: missing description", s.GetName(), name))
+		}
+
+		for _, field := range s.Fields {
+			c.Fields++
+
+			if field.Text.Description != "" {
+				c.Described++
+			} else {
+				c.Issues = append(c.Issues, fmt.Sprintf("%s.%s: missing description", s.GetName(), field.Name))
+			}
+
+			if len(field.Text.Examples) > 0 {
+				c.Exemplified++
+			} else {
+				c.Issues = append(c.Issues, fmt.Sprintf("%s.%s: missing example", s.GetName(), field.Name))
+			}
+		}
+	}
+	c.Issues = append(c.Issues, checkExamples(doc)...)
+	return c
+}
+
+// lintCommand reports the fields of the structure tree missing documentation
+// and fails if the description coverage is below the configured threshold.
+func lintCommand() error {
+	doc, err := collect()
+	if err != nil {
+		return errors.Wrap(err, "could not collect documentation")
+	}
+
+	c := computeCoverage(doc)
+	for _, issue := range c.Issues {
+		fmt.Println(issue)
+	}
+	fmt.Printf("description coverage: %d/%d fields (%.1f%%)\n", c.Described, c.Fields, c.DescriptionPercent())
+	fmt.Printf("example coverage: %d/%d fields (%.1f%%)\n", c.Exemplified, c.Fields, c.ExamplePercent())
+
+	if c.DescriptionPercent() < *lintThreshold {
+		return fmt.Errorf("description coverage %.1f%% is below the threshold of %.1f%%", c.DescriptionPercent(), *lintThreshold)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/dave/dst/decorator"
+	"golang.org/x/tools/go/packages"
+)
+
+// decoratePackages decorates the loaded packages along with all their
+// dependencies like decorator.Load, which dominates the loading time of
+// packages importing many others. Packages are decorated concurrently
+// using up to the provided number of workers.
+func decoratePackages(pkgs []*packages.Package, workers int) ([]*decorator.Package, error) {
+	var all []*packages.Package
+	decorated := make(map[*packages.Package]*decorator.Package)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		all = append(all, pkg)
+		decorated[pkg] = &decorator.Package{Package: pkg, Imports: make(map[string]*decorator.Package)}
+	})
+
+	errs := make([]error, len(all))
+	newCollectState(workers).run(len(all), func(i int) {
+		errs[i] = decorateFiles(decorated[all[i]])
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for pkg, p := range decorated {
+		for path, imported := range pkg.Imports {
+			p.Imports[path] = decorated[imported]
+		}
+	}
+
+	result := make([]*decorator.Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		result = append(result, decorated[pkg])
+	}
+	return result, nil
+}
+
+// decorateFiles decorates the go files of the package, skipping the
+// preprocessed cgo files also found in its syntax.
+func decorateFiles(p *decorator.Package) error {
+	if len(p.Package.Syntax) == 0 {
+		return nil
+	}
+
+	goFiles := make(map[string]bool, len(p.GoFiles))
+	for _, path := range p.GoFiles {
+		goFiles[path] = true
+	}
+
+	p.Decorator = decorator.NewDecoratorFromPackage(p.Package)
+	for _, f := range p.Package.Syntax {
+		if !goFiles[p.Fset.File(f.Pos()).Name()] {
+			continue
+		}
+		file, err := p.Decorator.DecorateFile(f)
+		if err != nil {
+			return err
+		}
+		p.Syntax = append(p.Syntax, file)
+	}
+	p.Dir, _ = filepath.Split(p.Fset.File(p.Package.Syntax[0].Pos()).Name())
+	return nil
+}
@@ -0,0 +1,100 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dave/dst/decorator"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestLoadPackages(t *testing.T) {
+	defer func(path string, n int) {
+		*inputPath, *workers = path, n
+	}(*inputPath, *workers)
+	*inputPath, *workers = filepath.Join("testdata", "embed"), 4
+
+	pkgs, err := loadPackages()
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	require.Len(t, pkgs[0].Syntax, 1)
+
+	base := pkgs[0].Imports["github.com/projectdiscovery/yamldoc-go/cmd/docgen/dstdocgen/testdata/base"]
+	require.NotNil(t, base)
+	require.NotNil(t, base.Decorator)
+	require.Len(t, base.Syntax, 1)
+	require.Equal(t, "base", base.Syntax[0].Name.Name)
+
+	// dependencies shared by several packages are decorated once
+	require.NotNil(t, base.Imports["time"])
+	require.NotEmpty(t, base.Imports["time"].Syntax)
+	require.Same(t, pkgs[0].Imports["time"], base.Imports["time"])
+}
+
+func TestImportPackage(t *testing.T) {
+	defer func(load func(string) *decorator.Package) {
+		*loadMissing, loadMissingPackage = false, load
+	}(loadMissingPackage)
+	*loadMissing = true
+
+	var mutex sync.Mutex
+	loads := map[string]int{}
+	started := make(chan string, 4)
+	release := make(chan struct{})
+	loadMissingPackage = func(path string) *decorator.Package {
+		mutex.Lock()
+		loads[path]++
+		mutex.Unlock()
+
+		started <- path
+		<-release
+		if path == "example.com/missing" {
+			return nil
+		}
+		return &decorator.Package{Package: &packages.Package{PkgPath: path}}
+	}
+
+	state := newCollectState(1)
+	root := &decorator.Package{Imports: map[string]*decorator.Package{}}
+
+	paths := []string{"example.com/a", "example.com/a", "example.com/b", "example.com/missing", "example.com/b"}
+	imported := make([]*decorator.Package, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			imported[i], _ = state.importPackage(root, path)
+		}(i, path)
+	}
+
+	// packages at different paths are loaded at the same time
+	var loading []string
+	for len(loading) < 3 {
+		select {
+		case path := <-started:
+			loading = append(loading, path)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("packages are not loaded concurrently, loading %v", loading)
+		}
+	}
+	require.ElementsMatch(t, []string{"example.com/a", "example.com/b", "example.com/missing"}, loading)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, map[string]int{"example.com/a": 1, "example.com/b": 1, "example.com/missing": 1}, loads)
+	require.Same(t, imported[0], imported[1])
+	require.Equal(t, "example.com/b", imported[2].PkgPath)
+	require.Nil(t, imported[3])
+
+	_, ok := state.importPackage(root, "example.com/missing")
+	require.False(t, ok, "failures are not loaded again")
+	require.Equal(t, 1, loads["example.com/missing"])
+}
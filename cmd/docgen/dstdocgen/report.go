@@ -0,0 +1,262 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+)
+
+const (
+	// levelError is the level of the problems found in validated files.
+	levelError = "error"
+	// levelWarning is the level of diagnostics about missing or ignored
+	// documentation.
+	levelWarning = "warning"
+	// levelNote is the level of informational diagnostics, such as
+	// types skipped on purpose.
+	levelNote = "note"
+)
+
+// diagnostic is a problem found while generating the documentation, written
+// to the -report file.
+type diagnostic struct {
+	Rule    string `json:"rule"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// diagnostics collects the diagnostics of the current generation from the
+// collection workers.
+var diagnostics struct {
+	sync.Mutex
+	list []diagnostic
+}
+
+// resetDiagnostics discards the diagnostics of a previous generation.
+func resetDiagnostics() {
+	diagnostics.Lock()
+	defer diagnostics.Unlock()
+
+	diagnostics.list = nil
+}
+
+// addDiagnostic records a diagnostic at the position, which may be unknown.
+func addDiagnostic(level string, position token.Position, rule, message string) {
+	d := diagnostic{Rule: rule, Level: level, Message: message}
+	if position.IsValid() {
+		d.File = reportPath(position.Filename)
+		d.Line, d.Column = position.Line, position.Column
+	}
+
+	diagnostics.Lock()
+	defer diagnostics.Unlock()
+
+	diagnostics.list = append(diagnostics.list, d)
+}
+
+// warn logs the message and records it as a warning of the rule.
+func warn(position token.Position, rule, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Print(message)
+	addDiagnostic(levelWarning, position, rule, message)
+}
+
+// nodePosition returns the source position of a node of the package, or
+// an invalid position if it is unknown.
+func nodePosition(pkg *decorator.Package, node dst.Node) token.Position {
+	if pkg == nil || pkg.Decorator == nil || pkg.Fset == nil {
+		return token.Position{}
+	}
+	original, ok := pkg.Decorator.Map.Ast.Nodes[node]
+	if !ok {
+		return token.Position{}
+	}
+	return pkg.Fset.Position(original.Pos())
+}
+
+// sourcePosition returns the position of a node of the package with the
+// file relative to the root of its module, or nil if it is unknown.
+func sourcePosition(pkg *decorator.Package, node dst.Node) *encoder.Source {
+	position := nodePosition(pkg, node)
+	if !position.IsValid() {
+		return nil
+	}
+
+	file := position.Filename
+	if pkg.Module != nil && pkg.Module.Dir != "" {
+		if rel, err := filepath.Rel(pkg.Module.Dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return &encoder.Source{File: filepath.ToSlash(file), Line: position.Line}
+}
+
+// reportPath returns the path relative to the working directory when it
+// is below it, with forward slashes.
+func reportPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// recordedDiagnostics returns the diagnostics recorded so far, in the
+// order they were recorded.
+func recordedDiagnostics() []diagnostic {
+	diagnostics.Lock()
+	defer diagnostics.Unlock()
+
+	return append([]diagnostic{}, diagnostics.list...)
+}
+
+// replayDiagnostics records the diagnostics of a previous collection again,
+// logging the warnings as warn does.
+func replayDiagnostics(list []diagnostic) {
+	for _, d := range list {
+		if d.Level == levelWarning {
+			log.Print(d.Message)
+		}
+	}
+
+	diagnostics.Lock()
+	defer diagnostics.Unlock()
+
+	diagnostics.list = append(diagnostics.list, list...)
+}
+
+// sortedDiagnostics returns the recorded diagnostics sorted by position.
+func sortedDiagnostics() []diagnostic {
+	diagnostics.Lock()
+	defer diagnostics.Unlock()
+
+	list := append([]diagnostic{}, diagnostics.list...)
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return list
+}
+
+// writeReport writes the recorded diagnostics to the -report file, as a
+// SARIF log if its extension is .sarif and as a JSON list otherwise.
+func writeReport(path string) error {
+	list := sortedDiagnostics()
+
+	var report interface{} = list
+	if filepath.Ext(path) == ".sarif" {
+		report = sarifReport(list)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return errors.Wrap(err, "could not write report")
+	}
+	return nil
+}
+
+// sarifLog is the subset of a SARIF 2.1.0 log written for diagnostics.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifReport converts the diagnostics to a SARIF log.
+func sarifReport(list []diagnostic) *sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "docgen",
+			InformationURI: "https://github.com/projectdiscovery/yamldoc-go",
+		}},
+		Results: []sarifResult{},
+	}
+	for _, d := range list {
+		result := sarifResult{RuleID: d.Rule, Level: d.Level, Message: sarifMessage{Text: d.Message}}
+		if d.File != "" {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: d.File},
+			}}
+			if d.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+			}
+			result.Locations = append(result.Locations, location)
+		}
+		run.Results = append(run.Results, result)
+	}
+	return &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
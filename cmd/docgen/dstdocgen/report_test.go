@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteReport(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	resetDiagnostics()
+	defer resetDiagnostics()
+	addDiagnostic(levelWarning, token.Position{Filename: filepath.Join(wd, "types.go"), Line: 12, Column: 2}, "undocumented-field", "field Config.Name is missing a documentation")
+	addDiagnostic(levelNote, token.Position{Filename: filepath.Join(wd, "types.go"), Line: 3, Column: 6}, "skipped-type", "Internal is skipped as it is marked with docgen:nodoc")
+	addDiagnostic(levelWarning, token.Position{}, "accepted-bad-example", "bad example is accepted")
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, writeReport(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var list []diagnostic
+	require.NoError(t, json.Unmarshal(data, &list))
+	require.Equal(t, []diagnostic{
+		{Rule: "accepted-bad-example", Level: levelWarning, Message: "bad example is accepted"},
+		{Rule: "skipped-type", Level: levelNote, Message: "Internal is skipped as it is marked with docgen:nodoc", File: "types.go", Line: 3, Column: 6},
+		{Rule: "undocumented-field", Level: levelWarning, Message: "field Config.Name is missing a documentation", File: "types.go", Line: 12, Column: 2},
+	}, list)
+
+	path = filepath.Join(t.TempDir(), "report.sarif")
+	require.NoError(t, writeReport(path))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+
+	var sarif sarifLog
+	require.NoError(t, json.Unmarshal(data, &sarif))
+	require.Equal(t, "2.1.0", sarif.Version)
+	require.Len(t, sarif.Runs[0].Results, 3)
+	require.Empty(t, sarif.Runs[0].Results[0].Locations)
+	require.Equal(t, &sarifRegion{StartLine: 12, StartColumn: 2}, sarif.Runs[0].Results[2].Locations[0].PhysicalLocation.Region)
+}
@@ -0,0 +1,46 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/schemaserver"
+)
+
+// schemaServerCommand collects the documentation for the structure and
+// serves its JSON Schema as the -version of the schema, along with the
+// versions published for previous releases in the -dir directory.
+func schemaServerCommand(args []string) error {
+	fs := newFlagSet("schemaserver")
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on")
+	version := fs.String("version", "0.0.0", "Version of the schema of the structure")
+	dir := fs.String("dir", "", "Directory of previously published schemas, laid out as <name>/<version>.json")
+	options := &schemaserver.Options{}
+	fs.StringVar(&options.BaseURL, "base-url", "", "URL the schemas are published at, used for their $id")
+	fs.DurationVar(&options.MaxAge, "max-age", 0, "Duration for which clients may cache the latest schemas")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	doc, err := collect()
+	if err != nil {
+		return errors.Wrap(err, "could not collect documentation")
+	}
+
+	s := schemaserver.New(options)
+	if *dir != "" {
+		if err := s.LoadDir(*dir); err != nil {
+			return errors.Wrap(err, "could not load schemas")
+		}
+	}
+	if err := s.Register(*version, doc.toFileDoc()); err != nil {
+		return err
+	}
+
+	fmt.Printf("serving schemas for %q on %s\n", doc.Name, *addr)
+	return s.ListenAndServe(*addr)
+}
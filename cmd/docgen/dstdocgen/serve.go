@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// reloadTimeout is the maximum duration of a live reload long poll.
+const reloadTimeout = 30 * time.Second
+
+// reloadScript polls the server for a new version of the site and reloads
+// the page once it is available.
+const reloadScript = `<script>
+(function poll(version) {
+  fetch("_reload?version=" + version)
+    .then(function (response) { return response.text(); })
+    .then(function (current) {
+      if (current !== version) { location.reload(); } else { poll(version); }
+    })
+    .catch(function () { setTimeout(function () { poll(version); }, 1000); });
+})("%d");
+</script>
+</head>`
+
+// preview is the in-memory documentation site served by the serve command.
+type preview struct {
+	mutex   sync.Mutex
+	files   map[string][]byte
+	version int
+	// changed is closed and replaced whenever the version changes.
+	changed chan struct{}
+}
+
+// serveCommand collects the documentation for the structure and serves it
+// as an HTML site along with the JSON model, regenerating it and reloading
+// the open pages whenever the sources change.
+func serveCommand(args []string) error {
+	fs := newFlagSet("serve")
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	p := &preview{changed: make(chan struct{})}
+	if err := p.build(); err != nil {
+		return err
+	}
+	go func() {
+		if err := watchChanges(p.build); err != nil {
+			log.Printf("[serve] could not watch for changes: %s\n", err)
+		}
+	}()
+
+	fmt.Printf("serving documentation on http://%s\n", *addr)
+	return http.ListenAndServe(*addr, p)
+}
+
+// build collects the documentation and replaces the served site.
+func (p *preview) build() error {
+	doc, err := collect()
+	if err != nil {
+		return errors.Wrap(err, "could not collect documentation")
+	}
+	fd := doc.toFileDoc()
+
+	files, err := fd.Site()
+	if err != nil {
+		return errors.Wrap(err, "could not render site")
+	}
+	if files["doc.json"], err = json.MarshalIndent(fd, "", "  "); err != nil {
+		return errors.Wrap(err, "could not render model")
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.files = files
+	p.version++
+	close(p.changed)
+	p.changed = make(chan struct{})
+	return nil
+}
+
+func (p *preview) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(path.Clean("/" + r.URL.Path))
+	switch name {
+	case "/":
+		name = "index.html"
+	case "_reload":
+		p.serveReload(w, r)
+		return
+	}
+
+	p.mutex.Lock()
+	contents, ok := p.files[name]
+	version := p.version
+	p.mutex.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if path.Ext(name) == ".html" {
+		contents = bytes.Replace(contents, []byte("</head>"), []byte(fmt.Sprintf(reloadScript, version)), 1)
+	}
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(name)))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(contents); err != nil {
+		log.Printf("[serve] could not write %s: %s\n", name, err)
+	}
+}
+
+// serveReload waits until the site version differs from the version query
+// parameter, or the reload timeout, and writes the current version.
+func (p *preview) serveReload(w http.ResponseWriter, r *http.Request) {
+	p.mutex.Lock()
+	version, changed := p.version, p.changed
+	p.mutex.Unlock()
+
+	if r.URL.Query().Get("version") == strconv.Itoa(version) {
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-time.After(reloadTimeout):
+		}
+
+		p.mutex.Lock()
+		version = p.version
+		p.mutex.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, version)
+}
@@ -0,0 +1,34 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/server"
+)
+
+// serverCommand collects the documentation for the structure and serves
+// the validation, schema and explanation endpoints for it.
+func serverCommand(args []string) error {
+	fs := newFlagSet("server")
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on")
+	options := server.DefaultOptions()
+	fs.Int64Var(&options.MaxBodySize, "max-body-size", options.MaxBodySize, "Maximum request body size in bytes")
+	fs.DurationVar(&options.Timeout, "timeout", options.Timeout, "Maximum duration for reading and handling a request")
+	fs.IntVar(&options.MaxConcurrent, "max-concurrent", options.MaxConcurrent, "Maximum number of requests handled at once")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	doc, err := collect()
+	if err != nil {
+		return errors.Wrap(err, "could not collect documentation")
+	}
+
+	fmt.Printf("serving documentation for %q on %s\n", doc.Name, *addr)
+	return server.New(options, doc.toFileDoc()).ListenAndServe(*addr)
+}
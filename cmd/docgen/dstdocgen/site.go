@@ -0,0 +1,32 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// siteCommand collects the documentation for the structure and writes
+// it as a static HTML site.
+func siteCommand(args []string) error {
+	fs := newFlagSet("site")
+	dir := fs.String("dir", "site", "Directory to write the site to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	doc, err := collect()
+	if err != nil {
+		return errors.Wrap(err, "could not collect documentation")
+	}
+
+	if err := doc.toFileDoc().WriteSite(*dir); err != nil {
+		return errors.Wrap(err, "could not write site")
+	}
+	fmt.Printf("wrote site for %q to %s\n", doc.Name, *dir)
+	return nil
+}
@@ -0,0 +1,13 @@
+package base
+
+import "time"
+
+// Base contains the options shared by configurations.
+type Base struct {
+	// description: |
+	//   Timeout of the requests.
+	Timeout time.Duration `yaml:"timeout"`
+	// description: |
+	//   Retries of failed requests.
+	Retries int `yaml:"retries"`
+}
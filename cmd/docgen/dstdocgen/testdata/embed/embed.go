@@ -0,0 +1,19 @@
+package embed
+
+import (
+	"time"
+
+	"github.com/projectdiscovery/yamldoc-go/cmd/docgen/dstdocgen/testdata/base"
+)
+
+// Config is the configuration of the fixture.
+type Config struct {
+	base.Base `yaml:",inline"`
+
+	// description: |
+	//   Name of the configuration.
+	Name string `yaml:"name"`
+	// description: |
+	//   Interval between runs.
+	Interval time.Duration `yaml:"interval"`
+}
@@ -0,0 +1,6 @@
+package empty
+
+// Config has no fields to document.
+type Config struct {
+	state int `yaml:"state"`
+}
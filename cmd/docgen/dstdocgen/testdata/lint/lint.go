@@ -0,0 +1,12 @@
+package lint
+
+// Config is the configuration of the fixture.
+type Config struct {
+	// description: |
+	//   Name of the configuration.
+	// examples:
+	//   - value: "\"default\""
+	Name    string `yaml:"name"`
+	Version int    `yaml:"version"`
+	state   string `yaml:"state"`
+}
@@ -0,0 +1,19 @@
+package names
+
+// Config is the configuration of the fixture.
+type Config struct {
+	// description: |
+	//   Hosts to connect to, a host or a list of hosts.
+	// docgen:name=hosts
+	Targets []string `yaml:"-"`
+	// description: |
+	//   Port to connect to.
+	// docgen:name=port
+	Port int `yaml:"listen-port"`
+	// description: |
+	//   Name of the configuration.
+	Name string `yaml:"name"`
+	// description: |
+	//   Internal is not decoded.
+	Internal string
+}
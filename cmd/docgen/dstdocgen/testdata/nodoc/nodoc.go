@@ -0,0 +1,46 @@
+package nodoc
+
+// Config is the configuration of the fixture.
+type Config struct {
+	// description: |
+	//   Name of the configuration.
+	Name string `yaml:"name"`
+	// description: |
+	//   Options of the configuration.
+	Options Options `yaml:"options"`
+	// description: |
+	//   State of the configuration.
+	State State `yaml:"state"`
+}
+
+// Options are the documented options.
+type Options struct {
+	// description: |
+	//   Verbose enables verbose output.
+	Verbose bool `yaml:"verbose"`
+}
+
+// State is internal to the configuration.
+//
+// docgen:nodoc
+type State struct {
+	// description: |
+	//   Cursor of the configuration.
+	Cursor Cursor `yaml:"cursor"`
+}
+
+// Cursor is only referenced by the internal state.
+type Cursor struct {
+	// description: |
+	//   Offset of the cursor.
+	Offset int `yaml:"offset"`
+}
+
+// Hidden is an internal configuration.
+//
+// docgen:nodoc
+type Hidden struct {
+	// description: |
+	//   Name of the configuration.
+	Name string `yaml:"name"`
+}
@@ -0,0 +1,18 @@
+package unresolved
+
+import "io"
+
+// Runner runs the configured job.
+type Runner interface {
+	Run() error
+}
+
+// Config embeds types which are not structs.
+type Config struct {
+	Runner    `yaml:",inline"`
+	io.Reader `yaml:",inline"`
+
+	// description: |
+	//   Name of the configuration.
+	Name string `yaml:"name"`
+}
@@ -0,0 +1,146 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// friendlyTypes maps well-known types, by import path and name, to the type
+// documented for them. Their names mean little to users writing documents,
+// and their fields, if any, are not part of the documents.
+var friendlyTypes = map[string]string{
+	"time.Duration":               "duration (e.g. 10s)",
+	"time.Time":                   "timestamp (e.g. 2006-01-02T15:04:05Z)",
+	"encoding/json.RawMessage":    "any (raw JSON)",
+	"gopkg.in/yaml.v2.MapSlice":   "map",
+	"gopkg.in/yaml.v3.Node":       "any (raw YAML)",
+	"regexp.Regexp":               "regex",
+	"net/url.URL":                 "url",
+	"net.IP":                      "ip address",
+	"net.IPNet":                   "cidr (e.g. 10.0.0.0/8)",
+	"os.FileMode":                 "file mode (e.g. 0644)",
+	"io/fs.FileMode":              "file mode (e.g. 0644)",
+	"math/big.Int":                "integer",
+	"github.com/google/uuid.UUID": "uuid",
+	"github.com/gofrs/uuid.UUID":  "uuid",
+}
+
+// friendlyTypeFlags are the friendly type names set with -friendly-type,
+// which take precedence over the built-in ones.
+var friendlyTypeFlags friendlyTypeFlag
+
+func init() {
+	flag.Var(&friendlyTypeFlags, "friendly-type", "Friendly name documented for a type, as <import path>.<name>=<name>, e.g. time.Duration=duration (repeatable)")
+}
+
+// friendlyTypeFlag collects the friendly type names of repeated flags.
+type friendlyTypeFlag []string
+
+func (f *friendlyTypeFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *friendlyTypeFlag) Set(value string) error {
+	if name, friendly, ok := strings.Cut(value, "="); !ok || name == "" || friendly == "" {
+		return errors.Errorf("invalid friendly type %q, expected <import path>.<name>=<name>", value)
+	}
+	*f = append(*f, value)
+	return nil
+}
+
+// typeMapping documents a Go type of the -type-map file.
+type typeMapping struct {
+	// Type is the type documented in place of the Go type.
+	Type string `yaml:"type"`
+	// Schema is the type values are validated against and rendered as in
+	// schemas, such as string or []string, if Type is not one.
+	Schema string `yaml:"schema"`
+}
+
+// typeMap contains the types of the -type-map file, keyed by import path
+// and name.
+var typeMap map[string]typeMapping
+
+// loadTypeMap reads the file mapping Go types to documented types:
+//
+//	github.com/x/y/types.StringSlice:
+//	  type: "[]string"
+//	github.com/x/y/types.Timeout:
+//	  type: timeout (e.g. 10s or 1m)
+//	  schema: string
+func loadTypeMap(path string) (map[string]typeMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read type map")
+	}
+
+	mapping := map[string]typeMapping{}
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, errors.Wrap(err, "could not parse type map")
+	}
+	for name, m := range mapping {
+		if m.Type == "" {
+			return nil, errors.Errorf("type map entry %s has no type", name)
+		}
+	}
+	return mapping, nil
+}
+
+// typeKey returns the import path and name of the type of an identifier,
+// declared in the package at pkgPath unless qualified.
+func typeKey(ident *dst.Ident, pkgPath string) string {
+	if ident.Path != "" {
+		pkgPath = ident.Path
+	}
+	return pkgPath + "." + ident.Name
+}
+
+// friendlyType returns the name documented for the type of an identifier
+// by the flags, the type map or the built-in friendly names, if any.
+func friendlyType(ident *dst.Ident, pkgPath string) (string, bool) {
+	key := typeKey(ident, pkgPath)
+	for i := len(friendlyTypeFlags) - 1; i >= 0; i-- {
+		if name, friendly, _ := strings.Cut(friendlyTypeFlags[i], "="); name == key {
+			return friendly, true
+		}
+	}
+	if m, ok := typeMap[key]; ok {
+		return m.Type, true
+	}
+	if ident.Path == "" {
+		// built-in names only apply to types of other packages
+		return "", false
+	}
+	friendly, ok := friendlyTypes[key]
+	return friendly, ok
+}
+
+// mappedSchemaType returns the field type with the types of the type map
+// replaced by their schema type, if any of them sets one.
+func mappedSchemaType(expr dst.Expr, pkgPath string) (string, bool) {
+	switch t := expr.(type) {
+	case *dst.Ident:
+		if m, ok := typeMap[typeKey(t, pkgPath)]; ok && m.Schema != "" {
+			return m.Schema, true
+		}
+	case *dst.StarExpr:
+		return mappedSchemaType(t.X, pkgPath)
+	case *dst.ArrayType:
+		elem, ok := mappedSchemaType(t.Elt, pkgPath)
+		return "[]" + elem, ok
+	case *dst.MapType:
+		value, ok := mappedSchemaType(t.Value, pkgPath)
+		return fmt.Sprintf("map[%s]%s", formatFieldType(t.Key, "", pkgPath, false), value), ok
+	}
+	return "", false
+}
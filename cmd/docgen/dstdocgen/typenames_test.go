@@ -0,0 +1,80 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/dave/dst"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFriendlyType(t *testing.T) {
+	defer func() {
+		friendlyTypeFlags, typeMap = nil, nil
+	}()
+
+	duration := &dst.Ident{Name: "Duration", Path: "time"}
+	timeout := &dst.Ident{Name: "Timeout", Path: "github.com/x/y/types"}
+	local := &dst.Ident{Name: "Duration"}
+
+	tests := []struct {
+		name     string
+		flags    []string
+		typeMap  map[string]typeMapping
+		ident    *dst.Ident
+		expected string
+	}{
+		{name: "built-in", ident: duration, expected: "duration (e.g. 10s)"},
+		{name: "unknown", ident: timeout},
+		{name: "built-in names skip local types", ident: local},
+		{
+			name:     "type map over built-in",
+			typeMap:  map[string]typeMapping{"time.Duration": {Type: "timeout"}},
+			ident:    duration,
+			expected: "timeout",
+		},
+		{
+			name:     "flag over type map",
+			flags:    []string{"time.Duration=interval"},
+			typeMap:  map[string]typeMapping{"time.Duration": {Type: "timeout"}},
+			ident:    duration,
+			expected: "interval",
+		},
+		{
+			name:     "last flag wins",
+			flags:    []string{"time.Duration=interval", "github.com/x/y/types.Timeout=seconds", "time.Duration=duration"},
+			ident:    duration,
+			expected: "duration",
+		},
+		{
+			name:     "flag of a local type",
+			flags:    []string{"github.com/x/y/config.Duration=duration"},
+			ident:    local,
+			expected: "duration",
+		},
+	}
+	for _, test := range tests {
+		friendlyTypeFlags, typeMap = nil, test.typeMap
+		for _, value := range test.flags {
+			require.NoError(t, friendlyTypeFlags.Set(value), test.name)
+		}
+
+		friendly, ok := friendlyType(test.ident, "github.com/x/y/config")
+		require.Equal(t, test.expected != "", ok, test.name)
+		require.Equal(t, test.expected, friendly, test.name)
+	}
+}
+
+func TestFriendlyTypeFlag(t *testing.T) {
+	var flags friendlyTypeFlag
+	require.NoError(t, flags.Set("time.Duration=duration"))
+	require.NoError(t, flags.Set("net.IP=ip=v4"))
+	require.Equal(t, "time.Duration=duration,net.IP=ip=v4", flags.String())
+
+	for _, value := range []string{"time.Duration", "=duration", "time.Duration="} {
+		require.EqualError(t, flags.Set(value), "invalid friendly type \""+value+"\", expected <import path>.<name>=<name>")
+	}
+}
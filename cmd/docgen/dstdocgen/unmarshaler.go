@@ -0,0 +1,107 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"go/token"
+	"strings"
+
+	"github.com/dave/dst"
+)
+
+// unmarshalerDirective marks types decoded by a custom yaml.Unmarshaler.
+// Their fields do not match the accepted yaml, so they are not documented
+// and the comment of the type documents the accepted input instead.
+const unmarshalerDirective = "docgen:unmarshaler"
+
+// unmarshalerShape returns the documentation of the input accepted by the
+// type of the field if it is marked with the unmarshaler directive, looking
+// through pointers and slices. Types of slices are accepted as lists of
+// the input.
+func unmarshalerShape(expr dst.Expr, collectOpts *collectStructOptions) (*Text, bool) {
+	var list bool
+	for {
+		switch t := expr.(type) {
+		case *dst.StarExpr:
+			expr = t.X
+			continue
+		case *dst.ArrayType:
+			expr, list = t.Elt, true
+			continue
+		}
+		break
+	}
+
+	ident, ok := expr.(*dst.Ident)
+	if !ok {
+		return nil, false
+	}
+	pkg := collectOpts.pkg
+	if ident.Path != "" {
+		if pkg, ok = collectOpts.state.importPackage(pkg, ident.Path); !ok {
+			return nil, false
+		}
+	} else if ident.Obj == nil || ident.Obj.Kind != dst.Typ {
+		// predeclared types
+		return nil, false
+	}
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			g, ok := decl.(*dst.GenDecl)
+			if !ok || g.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range g.Specs {
+				t, ok := spec.(*dst.TypeSpec)
+				if !ok || t.Name.Name != ident.Name {
+					continue
+				}
+				return parseShape(uncommentDecorationNode(declarationNode(t, t, pkg)), list)
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseShape parses the comment of a type marked with the unmarshaler
+// directive, if it is marked.
+func parseShape(comment string, list bool) (*Text, bool) {
+	var marked bool
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		if strings.TrimSpace(line) == unmarshalerDirective {
+			marked = true
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if !marked {
+		return nil, false
+	}
+
+	text := parseComment([]byte(strings.Join(lines, "\n")))
+	for i, accepted := range text.Accepts {
+		text.Accepts[i] = acceptedType(accepted)
+		if list {
+			text.Accepts[i] = "[]" + text.Accepts[i]
+		}
+	}
+	return text, true
+}
+
+// addShape adds the documentation of the input accepted by the type of a
+// field to the documentation of the field, which takes precedence.
+func addShape(text, shape *Text) {
+	if shape.Description != "" {
+		text.Description = strings.TrimSpace(text.Description + "\n\n" + shape.Description)
+	}
+	if len(text.Accepts) == 0 {
+		text.Accepts = shape.Accepts
+	}
+	if len(text.Examples) == 0 {
+		text.Examples = shape.Examples
+	}
+}
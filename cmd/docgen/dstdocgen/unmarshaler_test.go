@@ -0,0 +1,32 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShape(t *testing.T) {
+	comment := "Target is a host or a list of hosts.\n\naccepts: [string, string-slice]\ndocgen:unmarshaler"
+
+	shape, ok := parseShape(comment, false)
+	require.True(t, ok)
+	require.Equal(t, "Target is a host or a list of hosts.", shape.Description)
+	require.Equal(t, []string{"string", "[]string"}, shape.Accepts)
+
+	shape, ok = parseShape(comment, true)
+	require.True(t, ok)
+	require.Equal(t, []string{"[]string", "[][]string"}, shape.Accepts)
+
+	_, ok = parseShape("Target is a struct.", false)
+	require.False(t, ok)
+
+	text := &Text{Description: "Target of the job.", Accepts: []string{"string"}}
+	addShape(text, shape)
+	require.Equal(t, "Target of the job.\n\nTarget is a host or a list of hosts.", text.Description)
+	require.Equal(t, []string{"string"}, text.Accepts)
+}
@@ -0,0 +1,390 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+)
+
+// validateCommand collects the documentation for the structure and lints
+// the yaml files and directories given as arguments against it with a pool
+// of -workers, printing a file:line:column diagnostic for every problem and
+// a summary of the problems per rule. With -fix, the keys of deprecated
+// fields documented with replaced-by are renamed in place before linting.
+// With -allow-experimental, fields referencing experimental structs are not
+// reported. Directories ending with /... are searched recursively. The problems are
+// written to the -report file, e.g. as SARIF for code scanning annotations.
+func validateCommand(args []string) error {
+	fs := newFlagSet("validate")
+	schema := fs.String("schema", "", "Structure to validate the files against, in place of -structure")
+	fix := fs.Bool("fix", false, "Rename the keys of fields replaced by another field in the files, in place")
+	allowExperimental := fs.Bool("allow-experimental", false, "Do not warn about fields referencing experimental structs")
+	failOn := fs.String("fail-on", "error", "Comma separated severity (error, warning or none) and rule=max thresholds failing the validation, e.g. error,deprecated=10")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("expected yaml files or directories to validate")
+	}
+	if *schema != "" {
+		*structure = *schema
+	}
+	thresholds, err := parseFailOn(*failOn)
+	if err != nil {
+		return err
+	}
+
+	// only print the diagnostics to stdout
+	progress = os.Stderr
+
+	doc, err := collect()
+	if err != nil {
+		return errors.Wrap(err, "could not collect documentation")
+	}
+
+	files, err := yamlFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	// the report only lists the problems of the files
+	resetDiagnostics()
+
+	options := &encoder.LinterOptions{AllowExperimental: *allowExperimental}
+	summary, err := validateFiles(os.Stdout, doc.toFileDoc(), files, *workers, *fix, options)
+	if err != nil {
+		return err
+	}
+	summary.write(progress)
+	if *reportFile != "" {
+		if err := writeReport(*reportFile); err != nil {
+			return err
+		}
+	}
+	return thresholds.check(summary)
+}
+
+// validationSummary counts the problems found in the validated files.
+type validationSummary struct {
+	// Files is the number of validated files.
+	Files int
+	// Failed is the number of files with problems.
+	Failed int
+	// Fixed is the number of keys renamed with -fix.
+	Fixed int
+	// Severities counts the problems by severity.
+	Severities map[encoder.Severity]int
+	// Rules counts the problems by rule.
+	Rules map[string]int
+}
+
+// problems returns the total number of problems.
+func (s *validationSummary) problems() int {
+	var total int
+	for _, count := range s.Severities {
+		total += count
+	}
+	return total
+}
+
+// write prints the summary followed by the number of problems of every
+// rule, the most frequent first.
+func (s *validationSummary) write(w io.Writer) {
+	fmt.Fprintf(w, "validated %d files: %d errors, %d warnings in %d files\n", s.Files, s.Severities[encoder.SeverityError], s.Severities[encoder.SeverityWarning], s.Failed)
+	if s.Fixed > 0 {
+		fmt.Fprintf(w, "fixed %d keys\n", s.Fixed)
+	}
+
+	rules := make([]string, 0, len(s.Rules))
+	width := 0
+	for rule := range s.Rules {
+		rules = append(rules, rule)
+		if len(rule) > width {
+			width = len(rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if s.Rules[rules[i]] != s.Rules[rules[j]] {
+			return s.Rules[rules[i]] > s.Rules[rules[j]]
+		}
+		return rules[i] < rules[j]
+	})
+	for _, rule := range rules {
+		fmt.Fprintf(w, "  %-*s %d\n", width, rule, s.Rules[rule])
+	}
+}
+
+// failThresholds are the conditions failing the validation, parsed from
+// the -fail-on flag.
+type failThresholds struct {
+	// severity is the lowest severity failing the validation, empty when
+	// no severity does.
+	severity encoder.Severity
+	// rules is the maximum number of problems allowed for a rule.
+	rules map[string]int
+}
+
+// parseFailOn parses a comma separated list of a severity and rule=max
+// thresholds. The severity defaults to error when only thresholds are
+// given.
+func parseFailOn(value string) (*failThresholds, error) {
+	thresholds := &failThresholds{severity: encoder.SeverityError, rules: map[string]int{}}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if rule, limit, ok := strings.Cut(item, "="); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(limit))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid -fail-on threshold %q, expected rule=max", item)
+			}
+			thresholds.rules[strings.TrimSpace(rule)] = n
+			continue
+		}
+		switch item {
+		case "error":
+			thresholds.severity = encoder.SeverityError
+		case "warning":
+			thresholds.severity = encoder.SeverityWarning
+		case "none":
+			thresholds.severity = ""
+		default:
+			return nil, fmt.Errorf("invalid -fail-on severity %q, expected error, warning or none", item)
+		}
+	}
+	return thresholds, nil
+}
+
+// check returns an error when the problems of the summary exceed the
+// thresholds.
+func (t *failThresholds) check(s *validationSummary) error {
+	var exceeded []string
+	for rule, limit := range t.rules {
+		if count := s.Rules[rule]; count > limit {
+			exceeded = append(exceeded, fmt.Sprintf("%s: %d > %d", rule, count, limit))
+		}
+	}
+	sort.Strings(exceeded)
+	if len(exceeded) > 0 {
+		return fmt.Errorf("thresholds exceeded (%s)", strings.Join(exceeded, ", "))
+	}
+
+	count := s.Severities[encoder.SeverityError]
+	if t.severity == encoder.SeverityWarning {
+		count += s.Severities[encoder.SeverityWarning]
+	}
+	if t.severity != "" && count > 0 {
+		return fmt.Errorf("%d problems found in %d of %d files", count, s.Failed, s.Files)
+	}
+	return nil
+}
+
+// validateFiles lints the files against the root struct of the file
+// documentation with the given number of workers, writing the problems
+// found to w in the order of the files and recording them as diagnostics.
+// With fix, the files are migrated in place before linting them.
+func validateFiles(w io.Writer, fd *encoder.FileDoc, files []string, workers int, fix bool, opts *encoder.LinterOptions) (*validationSummary, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	linter := encoder.NewLinter(fd, opts)
+
+	type result struct {
+		migrations []encoder.Migration
+		issues     []encoder.LintIssue
+		err        error
+	}
+	results := make([]result, len(files))
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				data, err := os.ReadFile(files[index])
+				if err != nil {
+					results[index].err = errors.Wrapf(err, "could not read %s", files[index])
+					continue
+				}
+				var issues []encoder.LintIssue
+				if fix {
+					data, results[index].migrations, issues, err = fixFile(files[index], data, fd)
+					if err != nil {
+						results[index].err = err
+						continue
+					}
+				}
+				results[index].issues = append(issues, linter.Lint(data)...)
+			}
+		}()
+	}
+	for index := range files {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	summary := &validationSummary{
+		Files:      len(files),
+		Severities: map[encoder.Severity]int{},
+		Rules:      map[string]int{},
+	}
+	for index, file := range files {
+		if err := results[index].err; err != nil {
+			return nil, err
+		}
+		for _, migration := range results[index].migrations {
+			summary.Fixed++
+			fmt.Fprintf(w, "%s:%s\n", file, migration)
+		}
+		issues := results[index].issues
+		if len(issues) > 0 {
+			summary.Failed++
+		}
+		for _, issue := range issues {
+			rule := issueRule(issue)
+			summary.Severities[issue.Severity]++
+			summary.Rules[rule]++
+			if issue.Severity == encoder.SeverityWarning {
+				fmt.Fprintf(w, "%s:%d:%d: warning: %s\n", file, issue.Line, issue.Column, issueMessage(issue.ValidationError))
+			} else {
+				fmt.Fprintf(w, "%s:%s\n", file, issue.Error())
+			}
+			addValidationDiagnostic(file, issue)
+		}
+	}
+	return summary, nil
+}
+
+// fixFile migrates the data of the file, writing the migrated data back to
+// the file if any key was renamed. Keys which cannot be renamed are
+// returned as problems of the fix rule, leaving the file unchanged, while
+// files which do not parse are left for the linter to report.
+func fixFile(file string, data []byte, fd *encoder.FileDoc) ([]byte, []encoder.Migration, []encoder.LintIssue, error) {
+	migrated, migrations, err := encoder.Migrate(data, fd)
+	var migrateErr encoder.ValidationError
+	if errors.As(err, &migrateErr) {
+		return data, nil, []encoder.LintIssue{{ValidationError: migrateErr, Rule: "fix", Severity: encoder.SeverityError}}, nil
+	}
+	if err != nil || len(migrations) == 0 {
+		return data, nil, nil, nil
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := os.WriteFile(file, migrated, info.Mode().Perm()); err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "could not write %s", file)
+	}
+	return migrated, migrations, nil, nil
+}
+
+// issueRule returns the rule of the issue, using the validation rule, such
+// as unknown-field, for the issues reported by validation.
+func issueRule(issue encoder.LintIssue) string {
+	if issue.Rule == "validate" && issue.ValidationError.Rule != "" {
+		return issue.ValidationError.Rule
+	}
+	return issue.Rule
+}
+
+// issueMessage returns the message of the validation error prefixed with
+// its path.
+func issueMessage(e encoder.ValidationError) string {
+	if e.Path != "" {
+		return e.Path + ": " + e.Message
+	}
+	return e.Message
+}
+
+// addValidationDiagnostic records the issue of the file as a diagnostic
+// with the level of its severity.
+func addValidationDiagnostic(file string, issue encoder.LintIssue) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		abs = file
+	}
+
+	// syntax errors without a position are reported on the first line
+	position := token.Position{Filename: abs, Line: issue.Line, Column: issue.Column}
+	if position.Line == 0 {
+		position.Line = 1
+	}
+
+	level := levelError
+	if issue.Severity == encoder.SeverityWarning {
+		level = levelWarning
+	}
+	addDiagnostic(level, position, issueRule(issue), issueMessage(issue.ValidationError))
+}
+
+// yamlFiles returns the files given as arguments along with the yaml files
+// of the directories, recursively for directories ending with /..., sorted
+// and without duplicates.
+func yamlFiles(args []string) ([]string, error) {
+	seen := map[string]bool{}
+
+	var files []string
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, arg := range args {
+		root, recursive := arg, strings.HasSuffix(arg, "...")
+		if recursive {
+			root = filepath.Clean(strings.TrimSuffix(arg, "..."))
+		}
+
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(arg)
+			continue
+		}
+
+		err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if path != root && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,195 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAMLFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"a.yaml", "b.yml", "notes.txt", "nested/c.yaml"} {
+		path := filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("name: a\n"), 0o600))
+	}
+
+	files, err := yamlFiles([]string{dir})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yml")}, files)
+
+	files, err = yamlFiles([]string{dir + "/...", filepath.Join(dir, "notes.txt")})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "a.yaml"),
+		filepath.Join(dir, "b.yml"),
+		filepath.Join(dir, "nested", "c.yaml"),
+		filepath.Join(dir, "notes.txt"),
+	}, files)
+
+	_, err = yamlFiles([]string{filepath.Join(dir, "missing")})
+	require.Error(t, err)
+}
+
+func TestValidateFiles(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte("name: a\n"), 0o600))
+	require.NoError(t, os.WriteFile(invalid, []byte("name: a\nnmae: b\n"), 0o600))
+
+	fd := &encoder.FileDoc{Structs: []*encoder.Doc{{Type: "Config", Fields: []encoder.Doc{{Name: "name", Type: "string"}}}}}
+
+	resetDiagnostics()
+	defer resetDiagnostics()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("name: [a\n"), 0o600))
+
+	var buf bytes.Buffer
+	summary, err := validateFiles(&buf, fd, []string{invalid, valid}, 2, false, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, summary.problems())
+	require.Equal(t, 1, summary.Failed)
+	require.Equal(t, map[string]int{"unknown-field": 1}, summary.Rules)
+	require.Equal(t, invalid+":2:1: nmae: unknown field \"nmae\" in Config, did you mean \"name\"?\n", buf.String())
+
+	_, err = validateFiles(io.Discard, fd, []string{filepath.Join(dir, "broken.yaml")}, 1, false, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, []diagnostic{
+		{Rule: "syntax", Level: levelError, Message: "yaml: line 1: did not find expected ',' or ']'", File: filepath.ToSlash(filepath.Join(dir, "broken.yaml")), Line: 1},
+		{Rule: "unknown-field", Level: levelError, Message: "nmae: unknown field \"nmae\" in Config, did you mean \"name\"?", File: filepath.ToSlash(invalid), Line: 2, Column: 1},
+	}, sortedDiagnostics())
+}
+
+func TestValidateFilesWarnings(t *testing.T) {
+	dir := t.TempDir()
+	fd := &encoder.FileDoc{Structs: []*encoder.Doc{{Type: "Config", Fields: []encoder.Doc{
+		{Name: "name", Type: "string"},
+		{Name: "host", Type: "string", Description: "Host to connect to.\n\nDeprecated: use name instead."},
+	}}}}
+
+	var files []string
+	for i := 0; i < 20; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("%02d.yaml", i))
+		require.NoError(t, os.WriteFile(file, []byte("host: a\n"), 0o600))
+		files = append(files, file)
+	}
+
+	resetDiagnostics()
+	defer resetDiagnostics()
+
+	var buf bytes.Buffer
+	summary, err := validateFiles(&buf, fd, files, 4, false, nil)
+	require.NoError(t, err)
+	require.Equal(t, 20, summary.Failed)
+	require.Equal(t, map[string]int{"deprecated": 20}, summary.Rules)
+	require.Equal(t, 20, summary.Severities[encoder.SeverityWarning])
+	require.True(t, strings.HasPrefix(buf.String(), files[0]+":1:1: warning: host: host is deprecated: use name instead.\n"))
+
+	var out bytes.Buffer
+	summary.write(&out)
+	require.Equal(t, "validated 20 files: 0 errors, 20 warnings in 20 files\n  deprecated 20\n", out.String())
+}
+
+func TestValidateFilesExperimental(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("name: a\nheadless:\n  url: b\n"), 0o600))
+
+	fd := &encoder.FileDoc{Structs: []*encoder.Doc{
+		{Type: "Config", Fields: []encoder.Doc{{Name: "name", Type: "string"}, {Name: "headless", Type: "Headless"}}},
+		{Type: "Headless", Stability: encoder.StabilityExperimental, Fields: []encoder.Doc{{Name: "url", Type: "string"}}},
+	}}
+
+	resetDiagnostics()
+	defer resetDiagnostics()
+
+	summary, err := validateFiles(io.Discard, fd, []string{file}, 1, false, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"experimental": 1}, summary.Rules)
+
+	resetDiagnostics()
+	summary, err = validateFiles(io.Discard, fd, []string{file}, 1, false, &encoder.LinterOptions{AllowExperimental: true})
+	require.NoError(t, err)
+	require.Equal(t, 0, summary.problems())
+}
+
+func TestFailThresholds(t *testing.T) {
+	summary := &validationSummary{
+		Files:      3,
+		Failed:     2,
+		Severities: map[encoder.Severity]int{encoder.SeverityWarning: 3},
+		Rules:      map[string]int{"deprecated": 3},
+	}
+
+	thresholds, err := parseFailOn("error")
+	require.NoError(t, err)
+	require.NoError(t, thresholds.check(summary))
+
+	thresholds, err = parseFailOn("warning")
+	require.NoError(t, err)
+	require.EqualError(t, thresholds.check(summary), "3 problems found in 2 of 3 files")
+
+	thresholds, err = parseFailOn("none,deprecated=2")
+	require.NoError(t, err)
+	require.EqualError(t, thresholds.check(summary), "thresholds exceeded (deprecated: 3 > 2)")
+
+	thresholds, err = parseFailOn("warning,deprecated=5")
+	require.NoError(t, err)
+	require.Error(t, thresholds.check(summary))
+
+	_, err = parseFailOn("fatal")
+	require.Error(t, err)
+	_, err = parseFailOn("deprecated=-1")
+	require.Error(t, err)
+}
+
+func TestValidateFilesFix(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("# the host\nhost: a # primary\n"), 0o600))
+
+	fd := &encoder.FileDoc{Structs: []*encoder.Doc{{Type: "Config", Fields: []encoder.Doc{
+		{Name: "name", Type: "string"},
+		{Name: "host", Type: "string", Description: "Host to connect to.\n\nDeprecated: use name instead.", ReplacedBy: "name"},
+	}}}}
+
+	resetDiagnostics()
+	defer resetDiagnostics()
+
+	var buf bytes.Buffer
+	summary, err := validateFiles(&buf, fd, []string{file}, 1, true, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, summary.Fixed)
+	require.Equal(t, 0, summary.problems())
+	require.Equal(t, file+":2:1: host: renamed host to name\n", buf.String())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "# the host\nname: a # primary\n", string(data))
+
+	// keys with escapes can not be renamed in place
+	require.NoError(t, os.WriteFile(file, []byte("\"\\x68ost\": a\n"), 0o600))
+
+	buf.Reset()
+	summary, err = validateFiles(&buf, fd, []string{file}, 1, true, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, summary.Fixed)
+	require.Equal(t, 1, summary.Rules["fix"])
+	require.Contains(t, buf.String(), file+":1:1: host: could not locate key \"host\"\n")
+
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "\"\\x68ost\": a\n", string(data))
+}
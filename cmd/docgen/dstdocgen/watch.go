@@ -0,0 +1,140 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fileState is the state of a watched file used to detect changes.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watch returns a function which calls run once and then every time
+// a go file under the input path changes. Errors returned by run are
+// logged instead of stopping the watch.
+func watch(run func() error) func() error {
+	return func() error {
+		abs, ignored, err := watchedPaths()
+		if err != nil {
+			return err
+		}
+
+		previous, err := snapshot(abs, ignored)
+		if err != nil {
+			return errors.Wrap(err, "could not read input path")
+		}
+		if err := run(); err != nil {
+			log.Printf("[watch] %s\n", err)
+		}
+		return poll(abs, ignored, previous, run)
+	}
+}
+
+// watchChanges calls run every time a go file under the input path
+// changes, without an initial run.
+func watchChanges(run func() error) error {
+	abs, ignored, err := watchedPaths()
+	if err != nil {
+		return err
+	}
+
+	previous, err := snapshot(abs, ignored)
+	if err != nil {
+		return errors.Wrap(err, "could not read input path")
+	}
+	return poll(abs, ignored, previous, run)
+}
+
+// watchedPaths returns the absolute input path along with the generated
+// go file, which is ignored so that writing it does not trigger another run.
+func watchedPaths() (abs, ignored string, err error) {
+	abs, err = filepath.Abs(*inputPath)
+	if err != nil {
+		return "", "", errors.Wrap(err, "could not get absolute path")
+	}
+	ignored, _ = filepath.Abs(goOutputPath())
+	return abs, ignored, nil
+}
+
+// poll checks the files under abs for changes at the watch interval and
+// calls run on every change.
+func poll(abs, ignored string, previous map[string]fileState, run func() error) error {
+	log.Printf("[watch] watching %s for changes\n", abs)
+
+	ticker := time.NewTicker(*watchInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		current, err := snapshot(abs, ignored)
+		if err != nil {
+			log.Printf("[watch] could not read input path: %s\n", err)
+			continue
+		}
+		if !changed(previous, current) {
+			continue
+		}
+		previous = current
+
+		log.Printf("[watch] change detected, regenerating\n")
+		if err := run(); err != nil {
+			log.Printf("[watch] %s\n", err)
+		}
+	}
+	return nil
+}
+
+// snapshot returns the state of all the go files under root.
+func snapshot(root, ignored string) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" || path == ignored {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files, err
+}
+
+// changed returns true if the two snapshots differ.
+func changed(previous, current map[string]fileState) bool {
+	if len(previous) != len(current) {
+		return true
+	}
+	for path, state := range current {
+		if previousState, ok := previous[path]; !ok || previousState != state {
+			return true
+		}
+	}
+	return false
+}
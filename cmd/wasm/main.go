@@ -0,0 +1,124 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+//go:build js && wasm
+
+// Command wasm exposes the validation and field lookup of the encoder to
+// JavaScript, for validating configs and explaining keys in the browser.
+//
+// It registers a global `yamldoc` object with the following functions:
+//
+//	yamldoc.load(model)     loads the documentation model rendered by -format json
+//	yamldoc.validate(yaml)  returns the validation errors of the yaml document
+//	yamldoc.explain(path)   returns the documentation of the field at the dotted path
+//
+// Failures are returned as objects with an `error` property.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// doc is the documentation model loaded by yamldoc.load.
+var doc *encoder.FileDoc
+
+type explainResponse struct {
+	Path        string   `json:"path"`
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Required    bool     `json:"required,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Values      []string `json:"values,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func main() {
+	js.Global().Set("yamldoc", js.ValueOf(map[string]interface{}{
+		"load":     js.FuncOf(load),
+		"validate": js.FuncOf(validate),
+		"explain":  js.FuncOf(explain),
+	}))
+
+	// keep the functions registered for the lifetime of the page
+	select {}
+}
+
+func load(_ js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		return toJS(&errorResponse{Error: "expected the documentation model"})
+	}
+
+	loaded := &encoder.FileDoc{}
+	if err := json.Unmarshal([]byte(args[0].String()), loaded); err != nil {
+		return toJS(&errorResponse{Error: fmt.Sprintf("could not decode documentation model: %s", err)})
+	}
+	doc = loaded
+	return js.Null()
+}
+
+func validate(_ js.Value, args []js.Value) interface{} {
+	if doc == nil {
+		return toJS(&errorResponse{Error: "no documentation model loaded"})
+	}
+	if len(args) != 1 {
+		return toJS(&errorResponse{Error: "expected the yaml document"})
+	}
+
+	errs := encoder.Validate([]byte(args[0].String()), doc)
+	if errs == nil {
+		errs = []encoder.ValidationError{}
+	}
+	return toJS(errs)
+}
+
+func explain(_ js.Value, args []js.Value) interface{} {
+	if doc == nil {
+		return toJS(&errorResponse{Error: "no documentation model loaded"})
+	}
+	if len(args) != 1 {
+		return toJS(&errorResponse{Error: "expected the field path"})
+	}
+
+	path := args[0].String()
+	field, err := doc.Field(path)
+	if err != nil {
+		return toJS(&errorResponse{Error: err.Error()})
+	}
+
+	response := &explainResponse{
+		Path:        path,
+		Type:        field.Type,
+		Description: field.Description,
+		Required:    field.Required,
+		Default:     field.Default,
+		Values:      field.AllowedValues(),
+	}
+	for _, example := range field.Examples {
+		data, err := yaml.Marshal(example.GetValue())
+		if err != nil {
+			continue
+		}
+		response.Examples = append(response.Examples, string(data))
+	}
+	return toJS(response)
+}
+
+// toJS converts the value to a JavaScript object through JSON, applying
+// the json tags of the value.
+func toJS(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return js.ValueOf(map[string]interface{}{"error": err.Error()})
+	}
+	return js.Global().Get("JSON").Call("parse", string(data))
+}
@@ -0,0 +1,179 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package docquery answers the hover and completion requests of yaml
+// language servers from generated documentation, so that editor plugins
+// can be built directly on a FileDoc without a JSON Schema.
+package docquery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+)
+
+// Completion is a key which can be inserted in a mapping, shaped after the
+// CompletionItem of the language server protocol.
+type Completion struct {
+	// Label is the key.
+	Label string `json:"label"`
+	// Detail is the type of the key.
+	Detail string `json:"detail,omitempty"`
+	// Documentation is the markdown documentation of the key, as returned
+	// by HoverFor.
+	Documentation string `json:"documentation,omitempty"`
+	// InsertText is the text inserted by the completion, the key followed
+	// by the start of its value.
+	InsertText string `json:"insertText"`
+	// Required is set for keys which must be set in the mapping.
+	Required bool `json:"required,omitempty"`
+	// Deprecated is set for keys documented as deprecated.
+	Deprecated bool `json:"deprecated,omitempty"`
+}
+
+// HoverFor returns the markdown documentation of the field at the dotted
+// yaml path, e.g. `requests[0].matchers.type`, with its type, description,
+// allowed values, default, examples and documentation link. Indexes and map
+// keys in the path are skipped. An empty path documents the root struct.
+func HoverFor(doc *encoder.FileDoc, yamlPath string) (string, error) {
+	field, err := doc.Field(yamlPath)
+	if err != nil {
+		return "", err
+	}
+	return hover(doc, field, yamlPath), nil
+}
+
+// CompletionsAt returns the keys which can be inserted in the mapping at
+// the dotted yaml path, in the order of their declaration. An empty path
+// completes the keys of the root struct. Keys already set in the mapping
+// are to be filtered by the caller. No keys are returned for the mapping
+// of a map field, whose keys are not documented.
+func CompletionsAt(doc *encoder.FileDoc, parentPath string) ([]Completion, error) {
+	parent, err := doc.Field(parentPath)
+	if err != nil {
+		return nil, err
+	}
+	if parentPath != "" {
+		if strings.HasPrefix(parent.Type, "map[") && lastKey(parentPath) == parent.Name {
+			return nil, nil
+		}
+		nested := doc.Resolve(parent)
+		if nested == nil {
+			return nil, fmt.Errorf("field %q of type %s has no nested fields", parent.Name, parent.Type)
+		}
+		parent = nested
+	}
+
+	completions := make([]Completion, 0, len(parent.Fields))
+	for i := range parent.Fields {
+		field := &parent.Fields[i]
+		if field.Name == "" {
+			continue
+		}
+		_, deprecated := encoder.Deprecation(field.Description)
+		completions = append(completions, Completion{
+			Label:         field.Name,
+			Detail:        field.Type,
+			Documentation: hover(doc, field, joinPath(parentPath, field.Name)),
+			InsertText:    insertText(doc, field),
+			Required:      field.Required,
+			Deprecated:    deprecated,
+		})
+	}
+	return completions, nil
+}
+
+// hover returns the markdown documentation of the field at the path.
+func hover(doc *encoder.FileDoc, field *encoder.Doc, path string) string {
+	var sections []string
+
+	heading := fmt.Sprintf("`%s`", field.Type)
+	if path != "" {
+		heading = fmt.Sprintf("**%s** `%s`", path, field.Type)
+	}
+	if field.Required {
+		heading += " (required)"
+	}
+	sections = append(sections, heading)
+
+	description := strings.TrimSpace(field.Description)
+	if description == "" && path != "" {
+		if nested := doc.Resolve(field); nested != nil {
+			description = strings.TrimSpace(nested.Description)
+		}
+	}
+	if description != "" {
+		sections = append(sections, description)
+	}
+
+	if len(field.Values) > 0 || len(field.Enum()) > 0 {
+		values := []string{"Values:"}
+		for _, value := range field.Values {
+			values = append(values, fmt.Sprintf("- `%s`", value))
+		}
+		for _, value := range field.Enum() {
+			if value.Description != "" {
+				values = append(values, fmt.Sprintf("- `%s`: %s", value.Value, value.Description))
+			} else {
+				values = append(values, fmt.Sprintf("- `%s`", value.Value))
+			}
+		}
+		sections = append(sections, strings.Join(values, "\n"))
+	}
+
+	if field.Default != "" {
+		sections = append(sections, fmt.Sprintf("Default: `%s`", field.Default))
+	}
+
+	for _, example := range field.Examples {
+		value := example.GetValue()
+		if path != "" {
+			value = map[string]interface{}{field.Name: value}
+		}
+		data, err := encoder.Marshal(value, encoder.WithComments(encoder.CommentsAll))
+		if err != nil {
+			continue
+		}
+		snippet := strings.TrimRight(string(data), "\n")
+		if name := example.GetName(); name != "" {
+			snippet = "# " + name + "\n" + snippet
+		}
+		sections = append(sections, "```yaml\n"+snippet+"\n```")
+	}
+
+	if field.DocsURL != "" {
+		sections = append(sections, fmt.Sprintf("[Learn more](%s)", field.DocsURL))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// insertText returns the key of the field followed by the start of its
+// value: a nested line for structs and maps, and the first item for lists.
+func insertText(doc *encoder.FileDoc, field *encoder.Doc) string {
+	switch {
+	case strings.HasPrefix(field.Type, "[]"):
+		return field.Name + ":\n  - "
+	case strings.HasPrefix(field.Type, "map[") || doc.Resolve(field) != nil:
+		return field.Name + ":\n  "
+	default:
+		return field.Name + ": "
+	}
+}
+
+// lastKey returns the last key of the dotted path without its index.
+func lastKey(path string) string {
+	key := path[strings.LastIndexByte(path, '.')+1:]
+	if index := strings.IndexByte(key, '['); index >= 0 {
+		key = key[:index]
+	}
+	return key
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,90 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package docquery
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	"github.com/stretchr/testify/require"
+)
+
+func testFileDoc() *encoder.FileDoc {
+	job := &encoder.Doc{Type: "Job", Description: "Job to run."}
+	job.Fields = []encoder.Doc{
+		{Name: "name", Type: "string", Description: "Name of the job.", Required: true},
+		{Name: "threads", Type: "int", Description: "Number of threads.\n\nDeprecated: use workers instead.", Default: "10"},
+		{Name: "steps", Type: "[]Step", DocsURL: "https://example.com/steps"},
+		{Name: "profiles", Type: "map[string]Step"},
+	}
+	job.Fields[0].AddExample("short name", "scan")
+
+	step := &encoder.Doc{Type: "Step", Description: "Step of the job."}
+	step.Fields = []encoder.Doc{
+		{Name: "type", Type: "string", Description: "Type of the step.", Values: []string{"dns"}, EnumValues: []encoder.EnumValue{{Value: "http", Description: "HTTP request."}}},
+		{Name: "headers", Type: "map[string]string"},
+	}
+
+	return &encoder.FileDoc{Name: "Job", Structs: []*encoder.Doc{job, step}}
+}
+
+func TestHoverFor(t *testing.T) {
+	fd := testFileDoc()
+
+	hover, err := HoverFor(fd, "name")
+	require.NoError(t, err)
+	require.Equal(t, "**name** `string` (required)\n\nName of the job.\n\n```yaml\n# short name\nname: scan\n```", hover)
+
+	hover, err = HoverFor(fd, "steps[1].type")
+	require.NoError(t, err)
+	require.Equal(t, "**steps[1].type** `string`\n\nType of the step.\n\nValues:\n- `dns`\n- `http`: HTTP request.", hover)
+
+	hover, err = HoverFor(fd, "steps")
+	require.NoError(t, err)
+	require.Equal(t, "**steps** `[]Step`\n\nStep of the job.\n\n[Learn more](https://example.com/steps)", hover)
+
+	hover, err = HoverFor(fd, "")
+	require.NoError(t, err)
+	require.Equal(t, "`Job`\n\nJob to run.", hover)
+
+	_, err = HoverFor(fd, "steps.method")
+	require.EqualError(t, err, `unknown field "method" in Step`)
+}
+
+func TestCompletionsAt(t *testing.T) {
+	fd := testFileDoc()
+
+	completions, err := CompletionsAt(fd, "")
+	require.NoError(t, err)
+	require.Len(t, completions, 4)
+	require.Equal(t, "name", completions[0].Label)
+	require.True(t, completions[0].Required)
+	require.Equal(t, Completion{
+		Label:         "threads",
+		Detail:        "int",
+		Documentation: "**threads** `int`\n\nNumber of threads.\n\nDeprecated: use workers instead.\n\nDefault: `10`",
+		InsertText:    "threads: ",
+		Deprecated:    true,
+	}, completions[1])
+	require.Equal(t, "steps:\n  - ", completions[2].InsertText)
+	require.Equal(t, "profiles:\n  ", completions[3].InsertText)
+
+	completions, err = CompletionsAt(fd, "steps[0]")
+	require.NoError(t, err)
+	require.Len(t, completions, 2)
+	require.Equal(t, "type", completions[0].Label)
+	require.Equal(t, "headers:\n  ", completions[1].InsertText)
+
+	completions, err = CompletionsAt(fd, "profiles.default")
+	require.NoError(t, err)
+	require.Len(t, completions, 2)
+
+	completions, err = CompletionsAt(fd, "profiles")
+	require.NoError(t, err)
+	require.Empty(t, completions)
+
+	_, err = CompletionsAt(fd, "name")
+	require.EqualError(t, err, `field "name" of type string has no nested fields`)
+}
@@ -0,0 +1,80 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// anchors records the struct nodes of a document which can be anchored.
+type anchors struct {
+	types map[*yaml.Node]reflect.Type
+}
+
+// record records the node encoding a value of the struct type.
+func (a *anchors) record(node *yaml.Node, t reflect.Type) {
+	if a == nil || node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return
+	}
+	a.types[node] = t
+}
+
+// apply anchors the first struct node of the document for every repeated
+// struct value and replaces the following ones with aliases to it, in
+// document order. The fields of a value are documented at its anchor.
+func (a *anchors) apply(root *yaml.Node, indent int) {
+	var (
+		defined = map[string]*yaml.Node{}
+		names   = map[string]int{}
+	)
+
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		for i, child := range node.Content {
+			t, ok := a.types[child]
+			if !ok {
+				walk(child)
+				continue
+			}
+
+			data, err := encodeYAML(child, indent)
+			if err != nil {
+				walk(child)
+				continue
+			}
+
+			key := t.String() + "\n" + string(data)
+			anchor, ok := defined[key]
+			if !ok {
+				defined[key] = child
+				walk(child)
+				continue
+			}
+
+			if anchor.Anchor == "" {
+				name := strings.ToLower(t.Name())
+				if name == "" {
+					name = "value"
+				}
+				if names[name]++; names[name] > 1 {
+					name += "-" + strconv.Itoa(names[name])
+				}
+				anchor.Anchor = name
+			}
+
+			node.Content[i] = &yaml.Node{
+				Kind:  yaml.AliasNode,
+				Alias: anchor,
+				Value: anchor.Anchor,
+			}
+		}
+	}
+
+	walk(root)
+}
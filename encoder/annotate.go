@@ -0,0 +1,166 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Annotate adds the documentation of the root struct of the file
+// documentation to the keys of an existing yaml document which are not
+// commented yet. The values, their order and the existing comments are
+// preserved, so that hand-written configs can be upgraded to documented
+// ones. Every document of a multi-document stream is annotated. The
+// comments are written at the level and with the marker of the options.
+func Annotate(existingYAML []byte, fd *FileDoc, opts ...Option) ([]byte, error) {
+	root := fd.Root()
+	if root == nil {
+		return existingYAML, nil
+	}
+
+	var documents []*yaml.Node
+
+	decoder := yaml.NewDecoder(bytes.NewReader(existingYAML))
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		documents = append(documents, &node)
+	}
+	if len(documents) == 0 {
+		return existingYAML, nil
+	}
+
+	a := &annotator{fd: fd, opts: newOptions(opts...)}
+	for _, document := range documents {
+		if len(document.Content) > 0 {
+			a.annotateStruct(document.Content[0], root)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(documents[0]))
+	for _, document := range documents {
+		if err := enc.Encode(document); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type annotator struct {
+	fd   *FileDoc
+	opts *Options
+}
+
+func (a *annotator) annotateStruct(node *yaml.Node, doc *Doc) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	if d := doc.Discriminator; d != nil {
+		value := mappingValue(node, d.Field)
+		if value == nil {
+			return
+		}
+		if doc = a.fd.Struct(d.Mapping[value.Value]); doc == nil {
+			return
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		field := doc.FieldByName(key.Value)
+		if field == nil {
+			continue
+		}
+
+		if key.HeadComment == "" && key.LineComment == "" && value.LineComment == "" {
+			comment := docComment(field, a.opts.Comments)
+			if a.opts.Marker {
+				comment = markComment(comment)
+			}
+			annotateKey(key, value, comment)
+		}
+		a.annotateValue(value, field.Type)
+	}
+}
+
+func (a *annotator) annotateValue(node *yaml.Node, typ string) {
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, item := range node.Content {
+			a.annotateValue(item, typ[2:])
+		}
+	case strings.HasPrefix(typ, "map["):
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		elem := typ[mapKeyEnd(typ)+1:]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			a.annotateValue(node.Content[i+1], elem)
+		}
+	default:
+		if doc := a.fd.Struct(strings.TrimPrefix(typ, "*")); doc != nil {
+			a.annotateStruct(node, doc)
+		}
+	}
+}
+
+// annotateKey comments the key as the encoder does, inline after scalar
+// values and above the keys of other values.
+func annotateKey(key, value *yaml.Node, comment string) {
+	if comment == "" {
+		return
+	}
+
+	if value.Kind == yaml.ScalarNode && !strings.Contains(comment, "\n") {
+		value.LineComment = comment
+		return
+	}
+	key.HeadComment = comment
+}
+
+// detectIndent returns the indentation of the first nested mapping of the
+// document, or the indentation of the encoder if there is none.
+func detectIndent(node *yaml.Node) int {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.MappingNode && len(value.Content) > 0 && value.Style&yaml.FlowStyle == 0 {
+				if indent := value.Content[0].Column - key.Column; indent > 0 {
+					return indent
+				}
+			}
+		}
+	}
+
+	for _, child := range node.Content {
+		if indent := detectIndent(child); indent != yamlIndent {
+			return indent
+		}
+	}
+
+	return yamlIndent
+}
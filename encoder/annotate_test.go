@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotate(t *testing.T) {
+	fd := testFileDoc()
+	job, step := fd.Structs[0], fd.Structs[1]
+	job.Fields[0].Comments[LineComment] = "Name of the job."
+	job.Fields[1].Comments[LineComment] = "Number of workers."
+	job.Fields[2].Comments[LineComment] = "Steps run by the job."
+	step.Fields[0].Comments[LineComment] = "Type of the step."
+	step.Fields[1].Comments[LineComment] = "Headers sent\nwith the requests."
+
+	data, err := Annotate([]byte(`# my job
+name: scan
+workers: 4 # tuned by hand
+steps:
+  - type: "http"
+    headers:
+      a: b
+    extra: true
+`), fd)
+	require.NoError(t, err)
+	require.Equal(t, `# my job
+name: scan
+workers: 4 # tuned by hand
+# Steps run by the job.
+steps:
+  - type: "http" # Type of the step.
+    # Headers sent
+    # with the requests.
+    headers:
+      a: b
+    extra: true
+`, string(data))
+
+	data, err = Annotate([]byte("name: a\n---\nname: b\n"), fd)
+	require.NoError(t, err)
+	require.Equal(t, "name: a # Name of the job.\n---\nname: b # Name of the job.\n", string(data))
+
+	_, err = Annotate([]byte("name: [a"), fd)
+	require.Error(t, err)
+}
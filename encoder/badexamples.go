@@ -0,0 +1,79 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// BadExample is an invalid value of a field documenting a common mistake.
+type BadExample struct {
+	// Name is a short title of the mistake.
+	Name string
+	// Value is the yaml encoded invalid value.
+	Value string
+	// Reason explains why the value is invalid.
+	Reason string
+}
+
+// ValidateField validates a yaml value of a field, checking its type,
+// documented values and item constraints.
+func ValidateField(data []byte, fd *FileDoc, field *Doc) []ValidationError {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return []ValidationError{{Path: field.Name, Message: err.Error()}}
+	}
+	if len(node.Content) == 0 {
+		return nil
+	}
+
+	v := &validator{fd: fd}
+	v.validateValue(node.Content[0], field.Type, field.Name)
+	v.validateEnum(node.Content[0], field, field.Name)
+	v.validateItems(node.Content[0], field, field.Name)
+	return v.errs
+}
+
+// CheckBadExamples returns an error for every bad example which is
+// accepted by the validator, so that tests can assert the documented
+// mistakes are actually rejected.
+func (fd *FileDoc) CheckBadExamples() []error {
+	var errs []error
+	for _, s := range fd.Structs {
+		for i := range s.Fields {
+			field := &s.Fields[i]
+			for _, bad := range field.BadExamples {
+				if len(ValidateField([]byte(bad.Value), fd, field)) == 0 {
+					errs = append(errs, fmt.Errorf("%s.%s: bad example %q is accepted", s.Type, field.Name, badExampleTitle(bad)))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func badExampleTitle(bad BadExample) string {
+	if bad.Name != "" {
+		return bad.Name
+	}
+	return strings.TrimSpace(bad.Value)
+}
+
+// badExampleSnippet returns the yaml of the bad example under the key.
+func badExampleSnippet(key string, bad BadExample) string {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(bad.Value), &node); err != nil || len(node.Content) == 0 {
+		return fmt.Sprintf("%s: %s", key, strings.TrimSpace(bad.Value))
+	}
+
+	data, err := yaml.Marshal(map[string]*yaml.Node{key: node.Content[0]})
+	if err != nil {
+		return fmt.Sprintf("%s: %s", key, strings.TrimSpace(bad.Value))
+	}
+	return strings.TrimRight(string(data), "\n")
+}
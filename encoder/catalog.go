@@ -0,0 +1,47 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"strings"
+)
+
+// CatalogEntry is an entry of the schemas of a schemastore.org catalog.json,
+// describing a schema and the files it applies to.
+type CatalogEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	FileMatch   []string `json:"fileMatch,omitempty"`
+	URL         string   `json:"url"`
+}
+
+// CatalogEntry returns the schemastore.org catalog entry of the schema of
+// the file documentation published at the url, for files matching the glob
+// patterns. Without patterns, the entry matches the files ending with
+// .<name>.yaml or .<name>.yml, e.g. scan.template.yaml. The description is
+// the first line of the description of the file, or of the root struct.
+func (fd *FileDoc) CatalogEntry(url string, fileMatch ...string) *CatalogEntry {
+	if len(fileMatch) == 0 {
+		name := strings.ToLower(fd.Name)
+		fileMatch = []string{"*." + name + ".yaml", "*." + name + ".yml"}
+	}
+
+	description := strings.TrimSpace(fd.Description)
+	if description == "" {
+		if root := fd.Root(); root != nil {
+			description = strings.TrimSpace(root.Description)
+		}
+	}
+	if description == "" {
+		description = fd.Name + " configuration file"
+	}
+
+	return &CatalogEntry{
+		Name:        fd.Name,
+		Description: strings.Split(description, "\n")[0],
+		FileMatch:   fileMatch,
+		URL:         url,
+	}
+}
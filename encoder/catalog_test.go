@@ -0,0 +1,33 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogEntry(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Description = "Job run by the scheduler.\n\nJobs are made of steps."
+
+	data, err := json.Marshal(fd.CatalogEntry("https://example.com/job.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"name": "Job",
+		"description": "Job run by the scheduler.",
+		"fileMatch": ["*.job.yaml", "*.job.yml"],
+		"url": "https://example.com/job.json"
+	}`, string(data))
+
+	fd.Description = "Scheduler jobs."
+	entry := fd.CatalogEntry("https://example.com/job.json", "jobs/*.yaml")
+	require.Equal(t, "Scheduler jobs.", entry.Description)
+	require.Equal(t, []string{"jobs/*.yaml"}, entry.FileMatch)
+
+	require.Equal(t, "Empty configuration file", (&FileDoc{Name: "Empty"}).CatalogEntry("").Description)
+}
@@ -0,0 +1,221 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorMode selects whether the encoded yaml is colored with ANSI escape
+// sequences, e.g. to print the documented config in a terminal.
+type ColorMode int
+
+const (
+	// ColorNever writes plain yaml.
+	ColorNever ColorMode = iota
+	// ColorAuto colors the yaml written to a terminal, unless the NO_COLOR
+	// environment variable is set or TERM is dumb. Marshal checks the
+	// standard output, encoders check their writer.
+	ColorAuto
+	// ColorAlways colors the yaml, e.g. when it is piped to a pager.
+	ColorAlways
+)
+
+const (
+	colorKey     = "\x1b[36m"
+	colorValue   = "\x1b[32m"
+	colorComment = "\x1b[90m"
+	colorReset   = "\x1b[0m"
+)
+
+// enabled reports whether the yaml written to w is colored.
+func (m ColorMode) enabled(w io.Writer) bool {
+	switch m {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		return isTerminal(w)
+	default:
+		return false
+	}
+}
+
+// isTerminal reports whether w is a terminal which can display colors.
+func isTerminal(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize colors the keys, values and comments of the yaml.
+func colorize(data []byte) []byte {
+	var buf bytes.Buffer
+
+	// block is the indentation of the key of the block scalar being
+	// written, or -1
+	block := -1
+
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		text := strings.TrimSuffix(line, "\n")
+		rest := strings.TrimLeft(text, " ")
+		indent := len(text) - len(rest)
+
+		buf.WriteString(text[:indent])
+
+		if block >= 0 && (rest == "" || indent > block) {
+			paint(&buf, rest, colorValue)
+		} else {
+			block = -1
+			if column, ok := colorizeLine(&buf, rest); ok {
+				block = indent + column
+			}
+		}
+
+		buf.WriteString(line[len(text):])
+	}
+
+	return buf.Bytes()
+}
+
+// colorizeLine colors the line without its indentation, and reports
+// whether it starts a block scalar along with the column of its key or
+// sequence item in the line, below which the scalar ends.
+func colorizeLine(buf *bytes.Buffer, line string) (int, bool) {
+	switch {
+	case strings.HasPrefix(line, "#"):
+		paint(buf, line, colorComment)
+		return 0, false
+	case line == "---" || line == "...":
+		buf.WriteString(line)
+		return 0, false
+	}
+
+	column, offset := 0, 0
+	for strings.HasPrefix(line, "- ") || line == "-" {
+		n := len("- ")
+		if line == "-" {
+			n = 1
+		}
+		buf.WriteString(line[:n])
+		line = line[n:]
+		column = offset
+		offset += n
+	}
+
+	comment := commentStart(line)
+	if key := keyEnd(line[:comment]); key > 0 {
+		column = offset
+		paint(buf, line[:key], colorKey)
+		buf.WriteByte(':')
+		line = line[key+1:]
+		comment -= key + 1
+	}
+
+	value := line[:comment]
+	paint(buf, value, colorValue)
+	paint(buf, line[comment:], colorComment)
+
+	value = strings.TrimSpace(value)
+	return column, strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">")
+}
+
+// keyEnd returns the index of the colon ending the mapping key starting
+// the line, or -1 if the line does not start with a key.
+func keyEnd(line string) int {
+	if line == "" {
+		return -1
+	}
+
+	start := 0
+	if line[0] == '"' || line[0] == '\'' {
+		end := quoteEnd(line)
+		if end < 0 {
+			return -1
+		}
+		start = end + 1
+	}
+
+	for i := start; i < len(line); i++ {
+		if line[i] == ':' && (i+1 == len(line) || line[i+1] == ' ') {
+			return i
+		}
+		if start > 0 || (i == 0 && (line[i] == '{' || line[i] == '[')) {
+			// quoted or flow values are not keys
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// commentStart returns the index of the comment of the line, or the length
+// of the line if it has none.
+func commentStart(line string) int {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"', '\'':
+			if i == 0 || line[i-1] == ' ' {
+				end := quoteEnd(line[i:])
+				if end < 0 {
+					return len(line)
+				}
+				i += end
+			}
+		case '#':
+			if i == 0 || line[i-1] == ' ' {
+				return i
+			}
+		}
+	}
+
+	return len(line)
+}
+
+// quoteEnd returns the index of the quote closing the scalar starting the
+// line, or -1 if it is not closed on the line.
+func quoteEnd(line string) int {
+	quote := line[0]
+	for i := 1; i < len(line); i++ {
+		switch {
+		case quote == '"' && line[i] == '\\':
+			i++
+		case line[i] == quote && quote == '\'' && i+1 < len(line) && line[i+1] == '\'':
+			i++
+		case line[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// paint writes the text in the color, keeping its surrounding spaces
+// uncolored.
+func paint(buf *bytes.Buffer, text, color string) {
+	trimmed := strings.TrimLeft(text, " ")
+	buf.WriteString(text[:len(text)-len(trimmed)])
+
+	core := strings.TrimRight(trimmed, " ")
+	if core != "" {
+		buf.WriteString(color + core + colorReset)
+	}
+
+	buf.WriteString(trimmed[len(core):])
+}
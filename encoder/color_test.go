@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// uncolor replaces the escape sequences of the colors with readable tags.
+var uncolor = strings.NewReplacer(colorKey, "<k>", colorValue, "<v>", colorComment, "<c>", colorReset, "</>")
+
+func TestColorize(t *testing.T) {
+	data := colorize([]byte(`# head
+name: scan # the name
+"a: b": 'x # y'
+list:
+    - a
+    - key: |
+        line: one
+        # not a comment
+      other: {a: 1}
+url: http://x#y
+---
+- &a
+  k: v
+- *a
+`))
+	require.Equal(t, `<c># head</>
+<k>name</>: <v>scan</> <c># the name</>
+<k>"a: b"</>: <v>'x # y'</>
+<k>list</>:
+    - <v>a</>
+    - <k>key</>: <v>|</>
+        <v>line: one</>
+        <v># not a comment</>
+      <k>other</>: <v>{a: 1}</>
+<k>url</>: <v>http://x#y</>
+---
+- <v>&a</>
+  <k>k</>: <v>v</>
+- <v>*a</>
+`, uncolor.Replace(string(data)))
+}
+
+func TestColorMode(t *testing.T) {
+	data, err := Marshal(&Listener{Address: ":80"}, WithComments(CommentsShort), WithColor(ColorAlways))
+	require.NoError(t, err)
+	require.Equal(t, "<k>address</>: <v>:80</> <c># Address to listen on,</>\n<c># served hosts</>\n<k>hosts</>: <v>[]</>\n", uncolor.Replace(string(data)))
+
+	var buf bytes.Buffer
+	require.NoError(t, NewEncoder(&buf, WithComments(CommentsNone), WithColor(ColorAuto)).Encode(&Listener{Address: ":80"}))
+	require.Equal(t, "address: :80\nhosts: []\n", buf.String())
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "config.yaml"))
+	require.NoError(t, err)
+	defer f.Close()
+	require.False(t, ColorAuto.enabled(f))
+	require.False(t, ColorNever.enabled(f))
+
+	t.Setenv("NO_COLOR", "1")
+	require.False(t, ColorAuto.enabled(os.Stdout))
+}
@@ -0,0 +1,216 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"sort"
+	"strings"
+)
+
+// Completion kinds, matching the kinds of vim complete-items.
+const (
+	// CompletionKey completes a yaml key.
+	CompletionKey = "k"
+	// CompletionValue completes a value of a key.
+	CompletionValue = "v"
+)
+
+// Completion is a completion candidate for a documented yaml document,
+// compatible with the complete-items of vim and neovim omnifuncs.
+type Completion struct {
+	// Word is the text inserted by the completion.
+	Word string `json:"word"`
+	// Kind is CompletionKey or CompletionValue.
+	Kind string `json:"kind"`
+	// Menu is the type of the key, or the key of the value.
+	Menu string `json:"menu,omitempty"`
+	// Info is the short description of the key.
+	Info string `json:"info,omitempty"`
+	// Path is the dotted path of the parent keys of the key, or the path
+	// of the key for values. Lists are transparent in paths.
+	Path string `json:"path"`
+}
+
+// Completions returns the keys reachable from the root struct of the file
+// documentation, along with their documented values. An omnifunc completes
+// the candidates whose path matches the keys above the cursor.
+func (fd *FileDoc) Completions() []Completion {
+	var completions []Completion
+	fd.walkKeys(func(path string, field *Doc) {
+		completions = append(completions, Completion{
+			Word: field.Name,
+			Kind: CompletionKey,
+			Menu: field.Type,
+			Info: shortDescription(field),
+			Path: path,
+		})
+
+		fieldPath := joinPath(path, field.Name)
+		for _, value := range field.Values {
+			completions = append(completions, Completion{
+				Word: value,
+				Kind: CompletionValue,
+				Menu: field.Name,
+				Path: fieldPath,
+			})
+		}
+		for _, value := range field.Enum() {
+			completions = append(completions, Completion{
+				Word: value.Value,
+				Kind: CompletionValue,
+				Menu: field.Name,
+				Info: value.Description,
+				Path: fieldPath,
+			})
+		}
+	})
+	return completions
+}
+
+// walkKeys calls fn for every field reachable from the root struct of the
+// file documentation, with the dotted path of its parent keys, before the
+// fields of its struct.
+func (fd *FileDoc) walkKeys(fn func(path string, field *Doc)) {
+	if root := fd.Root(); root != nil {
+		fd.walkStructKeys(root, "", map[string]bool{}, fn)
+	}
+}
+
+func (fd *FileDoc) walkStructKeys(doc *Doc, path string, visiting map[string]bool, fn func(path string, field *Doc)) {
+	// recursive structs are only completed at their first level
+	if visiting[doc.Type] {
+		return
+	}
+	visiting[doc.Type] = true
+	defer delete(visiting, doc.Type)
+
+	for i := range doc.Fields {
+		field := &doc.Fields[i]
+		if field.Name == "" {
+			continue
+		}
+
+		fn(path, field)
+		if nested := fd.Resolve(field); nested != nil {
+			fd.walkStructKeys(nested, joinPath(path, field.Name), visiting, fn)
+		}
+	}
+}
+
+// shortDescription returns the first line of the description of the field.
+func shortDescription(field *Doc) string {
+	return strings.Split(strings.TrimSpace(field.Description), "\n")[0]
+}
+
+// Dictionary returns the sorted, unique keys and values of the file
+// documentation, one per line, for use with the vim `dictionary` option.
+func (fd *FileDoc) Dictionary() []byte {
+	words := map[string]bool{}
+	for _, completion := range fd.Completions() {
+		words[completion.Word] = true
+	}
+
+	sorted := make([]string, 0, len(words))
+	for word := range words {
+		sorted = append(sorted, word)
+	}
+	sort.Strings(sorted)
+
+	if len(sorted) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(sorted, "\n") + "\n")
+}
+
+// KeyPaths returns the dotted paths of the keys reachable from the root
+// struct of the file documentation, sorted and each followed by a tab and
+// its short description, one per line. Lists are transparent in paths, e.g.
+// matchers.type. The lines are consumed by fish completions as is, and by
+// bash and zsh completion scripts after splitting them on the tab.
+func (fd *FileDoc) KeyPaths() []byte {
+	descriptions := map[string]string{}
+	fd.walkKeys(func(path string, field *Doc) {
+		fieldPath := joinPath(path, field.Name)
+		if _, ok := descriptions[fieldPath]; !ok {
+			descriptions[fieldPath] = strings.ReplaceAll(shortDescription(field), "\t", " ")
+		}
+	})
+
+	paths := make([]string, 0, len(descriptions))
+	for path := range descriptions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		b.WriteString(path)
+		if description := descriptions[path]; description != "" {
+			b.WriteString("\t" + description)
+		}
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+// Snippet is a snippet in the format of VS Code snippet files, which is
+// also loaded by snippet engines of other editors, such as LuaSnip.
+type Snippet struct {
+	// Prefix is the dotted path of the key triggering the snippet.
+	Prefix string `json:"prefix"`
+	// Body is the lines inserted by the snippet, with tab stops.
+	Body []string `json:"body"`
+	// Description is the short description of the key.
+	Description string `json:"description,omitempty"`
+}
+
+// Snippets returns a snippet inserting every key reachable from the root
+// struct of the file documentation, keyed by its dotted path, so that
+// typing the path of a key, e.g. matchers.type, inserts the key with a
+// choice of its documented values or the item of its list.
+func (fd *FileDoc) Snippets() map[string]Snippet {
+	snippets := map[string]Snippet{}
+	fd.walkKeys(func(path string, field *Doc) {
+		fieldPath := joinPath(path, field.Name)
+		if _, ok := snippets[fieldPath]; ok {
+			return
+		}
+		snippets[fieldPath] = Snippet{
+			Prefix:      fieldPath,
+			Body:        fd.snippetBody(field),
+			Description: shortDescription(field),
+		}
+	})
+	return snippets
+}
+
+// snippetReplacer escapes the characters with a meaning in snippet choices.
+var snippetReplacer = strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`, `,`, `\,`, `|`, `\|`)
+
+// snippetBody returns the lines of the snippet inserting the field.
+func (fd *FileDoc) snippetBody(field *Doc) []string {
+	var values []string
+	values = append(values, field.Values...)
+	for _, value := range field.Enum() {
+		values = append(values, value.Value)
+	}
+	if len(values) == 0 && field.Type == "bool" {
+		values = []string{"true", "false"}
+	}
+
+	switch {
+	case len(values) > 0:
+		for i, value := range values {
+			values[i] = snippetReplacer.Replace(value)
+		}
+		return []string{field.Name + ": ${1|" + strings.Join(values, ",") + "|}"}
+	case strings.HasPrefix(field.Type, "[]"):
+		return []string{field.Name + ":", "  - $0"}
+	case strings.HasPrefix(field.Type, "map[") || fd.Resolve(field) != nil:
+		return []string{field.Name + ":", "  $0"}
+	default:
+		return []string{field.Name + ": $0"}
+	}
+}
@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletions(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields[1].Description = "Number of workers.\n\nDefaults to 1."
+
+	completions := fd.Completions()
+	require.Len(t, completions, 7)
+	require.Equal(t, Completion{Word: "workers", Kind: CompletionKey, Menu: "int", Info: "Number of workers.", Path: ""}, completions[1])
+	require.Equal(t, Completion{Word: "type", Kind: CompletionKey, Menu: "string", Path: "steps"}, completions[3])
+	require.Equal(t, Completion{Word: "http", Kind: CompletionValue, Menu: "type", Path: "steps.type"}, completions[5])
+
+	require.Equal(t, "dns\nheaders\nhttp\nname\nsteps\ntype\nworkers\n", string(fd.Dictionary()))
+}
+
+func TestKeyPaths(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields[1].Description = "Number of workers.\n\nDefaults to 1."
+	fd.Structs[1].Fields[0].Description = "Type of the step."
+
+	require.Equal(t, "name\nsteps\nsteps.headers\nsteps.type\tType of the step.\nworkers\tNumber of workers.\n", string(fd.KeyPaths()))
+}
+
+func TestSnippets(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields = append(fd.Structs[0].Fields, Doc{Name: "debug", Type: "bool", Description: "Enables debug output."})
+
+	snippets := fd.Snippets()
+	require.Len(t, snippets, 6)
+	require.Equal(t, Snippet{Prefix: "steps.type", Body: []string{"type: ${1|dns,http|}"}}, snippets["steps.type"])
+	require.Equal(t, Snippet{Prefix: "debug", Body: []string{"debug: ${1|true,false|}"}, Description: "Enables debug output."}, snippets["debug"])
+	require.Equal(t, []string{"steps:", "  - $0"}, snippets["steps"].Body)
+	require.Equal(t, []string{"headers:", "  $0"}, snippets["steps.headers"].Body)
+	require.Equal(t, []string{"name: $0"}, snippets["name"].Body)
+}
@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ApplyDefaults sets the zero-valued fields of v, a pointer to a struct, to
+// the yaml encoded defaults documented for them, typically after unmarshal.
+// Nested structs are handled using their own documentation, so the doc of
+// the root struct is enough.
+func ApplyDefaults(v interface{}, doc *Doc) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("expected a non-nil pointer, got %T", v)
+	}
+	return applyDefaults(value.Elem(), doc)
+}
+
+func applyDefaults(v reflect.Value, doc *Doc) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return applyDefaults(v.Elem(), doc)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := applyDefaults(v.Index(i), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// map values are not addressable, only pointers are updated
+			if iter.Value().Kind() == reflect.Ptr {
+				if err := applyDefaults(iter.Value(), nil); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	if doc == nil && v.CanAddr() {
+		doc = getDoc(v.Addr().Interface())
+	}
+
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		name, options := fieldKey(t.Field(i))
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(t.Field(i).Name)
+		}
+
+		inline := isInline(options)
+		if inline {
+			if err := applyDefaults(field, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if doc != nil {
+			if fieldDoc := doc.FieldByName(name); fieldDoc != nil && fieldDoc.Default != "" && field.IsZero() {
+				if err := yaml.Unmarshal([]byte(fieldDoc.Default), field.Addr().Interface()); err != nil {
+					return fmt.Errorf("invalid default of %s: %w", name, err)
+				}
+				continue
+			}
+		}
+		if err := applyDefaults(field, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+type defaultsJob struct {
+	Name    string                   `yaml:"name"`
+	Workers int                      `yaml:"workers"`
+	Tags    []string                 `yaml:"tags"`
+	Steps   []*defaultsStep          `yaml:"steps"`
+	Named   map[string]*defaultsStep `yaml:"named"`
+}
+
+type defaultsStep struct {
+	Timeout string `yaml:"timeout"`
+}
+
+func (defaultsJob) Doc() *Doc {
+	return &Doc{Fields: []Doc{
+		{Name: "name"},
+		{Name: "workers", Default: "10"},
+		{Name: "tags", Default: "[a, b]"},
+	}}
+}
+
+func (defaultsStep) Doc() *Doc {
+	return &Doc{Fields: []Doc{{Name: "timeout", Default: "5s"}}}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	var job defaultsJob
+	require.NoError(t, yaml.Unmarshal([]byte("name: a\ntags: [c]\nsteps:\n  - timeout: 1s\n  - {}\nnamed:\n  x: {}\n"), &job))
+	require.NoError(t, ApplyDefaults(&job, job.Doc()))
+
+	require.Equal(t, 10, job.Workers)
+	require.Equal(t, []string{"c"}, job.Tags)
+	require.Equal(t, "1s", job.Steps[0].Timeout)
+	require.Equal(t, "5s", job.Steps[1].Timeout)
+	require.Equal(t, "5s", job.Named["x"].Timeout)
+
+	require.Error(t, ApplyDefaults(job, job.Doc()))
+}
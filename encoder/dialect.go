@@ -0,0 +1,426 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Dialect is a configuration language the documented structs can be written in.
+type Dialect string
+
+const (
+	// DialectYAML is the YAML configuration language.
+	DialectYAML Dialect = "yaml"
+	// DialectJSON is the JSON configuration language.
+	DialectJSON Dialect = "json"
+	// DialectTOML is the TOML configuration language.
+	DialectTOML Dialect = "toml"
+)
+
+// Key returns the key of the field in the dialect, taken from the dialect's
+// struct tag when present and the yaml name otherwise.
+func (d *Doc) Key(dialect Dialect) string {
+	if key, ok := d.Tags[string(dialect)]; ok && key != "" {
+		return key
+	}
+	return d.Name
+}
+
+// EncodeDialect encodes the value in the dialect. Struct fields are named
+// after the dialect's struct tag, falling back to the yaml tag.
+func EncodeDialect(in interface{}, dialect Dialect) ([]byte, error) {
+	switch dialect {
+	case DialectYAML:
+		return yaml.Marshal(in)
+	case DialectJSON:
+		return json.MarshalIndent(plainValue(reflect.ValueOf(in), dialect, nil), "", "  ")
+	case DialectTOML:
+		value := plainValue(reflect.ValueOf(in), dialect, nil)
+		table, ok := value.(orderedMap)
+		if !ok {
+			return nil, fmt.Errorf("toml documents must be tables, got %T", value)
+		}
+		buf := &bytes.Buffer{}
+		writeTOMLTable(buf, nil, table)
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown dialect %q", dialect)
+	}
+}
+
+// orderedMap is a map preserving the order of its keys.
+type orderedMap []mapItem
+
+type mapItem struct {
+	Key   string
+	Value interface{}
+	// Comment documents the item in the dialects supporting comments.
+	Comment string
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, item := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(item.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// plainValue converts a go value into scalars, slices and ordered maps,
+// naming struct fields after the dialect. Struct fields are commented and
+// redacted as configured by the options, if any.
+//
+//nolint:gocyclo
+func plainValue(v reflect.Value, dialect Dialect, opts *Options) interface{} {
+	if v.IsValid() && v.CanInterface() {
+		if e, ok := v.Interface().(yamlExample); ok {
+			return yamlExampleValue(e)
+		}
+	}
+
+	//nolint:exhaustive
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return plainValue(v.Elem(), dialect, opts)
+	case reflect.Struct:
+		m := orderedMap{}
+		appendStructFields(&m, v, dialect, opts)
+		if opts != nil && v.CanInterface() {
+			if less := fieldLess(opts.getDoc(v.Interface()), opts.Order, dialect); less != nil {
+				sort.SliceStable(m, func(i, j int) bool {
+					return less(m[i].Key, m[j].Key)
+				})
+			}
+		}
+		return m
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		m := make(orderedMap, 0, len(keys))
+		for _, key := range keys {
+			m = append(m, mapItem{Key: fmt.Sprint(key.Interface()), Value: plainValue(v.MapIndex(key), dialect, opts)})
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = plainValue(v.Index(i), dialect, opts)
+		}
+		return items
+	default:
+		if !v.CanInterface() {
+			return nil
+		}
+		return v.Interface()
+	}
+}
+
+// yamlExampleValue converts the example snippet into scalars, slices and
+// ordered maps, keeping the order of its keys.
+func yamlExampleValue(e yamlExample) interface{} {
+	value, err := e.MarshalYAML()
+	if err != nil {
+		return string(e)
+	}
+	node, ok := value.(*yaml.Node)
+	if !ok {
+		return value
+	}
+	return nodeValue(node)
+}
+
+// nodeValue converts the yaml node into scalars, slices and ordered maps.
+func nodeValue(node *yaml.Node) interface{} {
+	//nolint:exhaustive
+	switch node.Kind {
+	case yaml.AliasNode:
+		return nodeValue(node.Alias)
+	case yaml.MappingNode:
+		m := make(orderedMap, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			m = append(m, mapItem{Key: node.Content[i].Value, Value: nodeValue(node.Content[i+1])})
+		}
+		return m
+	case yaml.SequenceNode:
+		items := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			items[i] = nodeValue(item)
+		}
+		return items
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return node.Value
+		}
+		return value
+	}
+}
+
+func appendStructFields(m *orderedMap, v reflect.Value, dialect Dialect, opts *Options) {
+	t := v.Type()
+
+	var doc *Doc
+	if opts != nil && v.CanInterface() {
+		doc = opts.getDoc(v.Interface())
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Field(i).CanInterface() {
+			continue
+		}
+
+		name, options := fieldTag(t.Field(i), dialect)
+		if name == "-" {
+			continue
+		}
+		if options["inline"] || options["squash"] || (t.Field(i).Anonymous && name == "") {
+			if v.Field(i).Kind() == reflect.Ptr && v.Field(i).IsNil() {
+				continue
+			}
+			field := reflect.Indirect(v.Field(i))
+			if field.Kind() == reflect.Struct {
+				appendStructFields(m, field, dialect, opts)
+				continue
+			}
+		}
+		if options["omitempty"] && isEmpty(v.Field(i)) {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(t.Field(i).Name)
+		}
+
+		item := mapItem{Key: name, Value: plainValue(v.Field(i), dialect, opts)}
+		if opts != nil {
+			fieldDoc := opts.getDoc(v.Field(i).Interface())
+			if doc != nil {
+				fieldDoc = mergeDoc(fieldDoc, doc.Field(i))
+			}
+
+			if opts.Redact && fieldDoc != nil && fieldDoc.Secret && !isEmpty(v.Field(i)) {
+				item.Value = Redacted
+			}
+			item.Comment = dialectComment(fieldDoc, opts)
+		}
+		*m = append(*m, item)
+	}
+}
+
+// fieldTag returns the name and options of the dialect tag of the field,
+// falling back to its key tag.
+func fieldTag(field reflect.StructField, dialect Dialect) (string, map[string]bool) {
+	tag, ok := field.Tag.Lookup(string(dialect))
+	if !ok {
+		tag, _ = KeyTag(field.Tag)
+	}
+
+	parts := strings.Split(tag, ",")
+	options := make(map[string]bool, len(parts)-1)
+	for _, part := range parts[1:] {
+		options[part] = true
+	}
+	return parts[0], options
+}
+
+var bareKeyRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if bareKeyRe.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
+
+// writeTOMLTable writes the table at the path, writing scalar values
+// first followed by the nested tables and arrays of tables.
+func writeTOMLTable(buf *bytes.Buffer, path []string, table orderedMap) {
+	var nested []mapItem
+
+	for _, item := range table {
+		switch value := item.Value.(type) {
+		case nil:
+			continue
+		case orderedMap:
+			nested = append(nested, item)
+			continue
+		case []interface{}:
+			if isTableArray(value) {
+				nested = append(nested, item)
+				continue
+			}
+		}
+		if item.Comment != "" && !strings.Contains(item.Comment, "\n") {
+			fmt.Fprintf(buf, "%s = %s # %s\n", tomlKey(item.Key), tomlValue(item.Value), item.Comment)
+			continue
+		}
+		writeTOMLComment(buf, item.Comment)
+		fmt.Fprintf(buf, "%s = %s\n", tomlKey(item.Key), tomlValue(item.Value))
+	}
+
+	for _, item := range nested {
+		childPath := append(append([]string{}, path...), item.Key)
+
+		switch value := item.Value.(type) {
+		case orderedMap:
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			writeTOMLComment(buf, item.Comment)
+			fmt.Fprintf(buf, "[%s]\n", tomlPath(childPath))
+			writeTOMLTable(buf, childPath, value)
+		case []interface{}:
+			for i, element := range value {
+				if buf.Len() > 0 {
+					buf.WriteByte('\n')
+				}
+				if i == 0 {
+					writeTOMLComment(buf, item.Comment)
+				}
+				fmt.Fprintf(buf, "[[%s]]\n", tomlPath(childPath))
+				writeTOMLTable(buf, childPath, element.(orderedMap))
+			}
+		}
+	}
+}
+
+// writeTOMLComment writes the lines of the comment, if any.
+func writeTOMLComment(buf *bytes.Buffer, comment string) {
+	if comment == "" {
+		return
+	}
+
+	for _, line := range strings.Split(comment, "\n") {
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			buf.WriteString("#" + strings.TrimPrefix(line, "#") + "\n")
+		default:
+			buf.WriteString("# " + line + "\n")
+		}
+	}
+}
+
+// dialectComment returns the comment documenting the field at the level of
+// the options.
+func dialectComment(doc *Doc, opts *Options) string {
+	if !opts.Comments.enabled(CommentsDocs) || doc == nil {
+		return ""
+	}
+
+	head := doc.Comments[HeadComment]
+	if opts.Comments.enabled(CommentsFirstLine) {
+		head = firstLine(head)
+	}
+
+	comment := joinComments(head, docComment(doc, opts.Comments))
+	if opts.WrapColumns > 0 {
+		comment = wrapComment(comment, opts.WrapColumns-commentPrefix)
+	}
+
+	return comment
+}
+
+// isTableArray returns true if all the elements of the array are tables.
+func isTableArray(values []interface{}) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, value := range values {
+		if _, ok := value.(orderedMap); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlValue returns the inline representation of a value.
+func tomlValue(value interface{}) string {
+	switch t := value.(type) {
+	case string:
+		return strconv.Quote(t)
+	case orderedMap:
+		items := make([]string, 0, len(t))
+		for _, item := range t {
+			if item.Value == nil {
+				continue
+			}
+			items = append(items, fmt.Sprintf("%s = %s", tomlKey(item.Key), tomlValue(item.Value)))
+		}
+		return "{ " + strings.Join(items, ", ") + " }"
+	case []interface{}:
+		items := make([]string, 0, len(t))
+		for _, item := range t {
+			if item == nil {
+				continue
+			}
+			items = append(items, tomlValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case nil:
+		return `""`
+	default:
+		v := reflect.ValueOf(value)
+		//nolint:exhaustive
+		switch v.Kind() {
+		case reflect.String:
+			return strconv.Quote(v.String())
+		case reflect.Float32, reflect.Float64:
+			value := strconv.FormatFloat(v.Float(), 'f', -1, 64)
+			if !strings.Contains(value, ".") {
+				// keep floats distinguishable from integers
+				value += ".0"
+			}
+			return value
+		default:
+			return fmt.Sprint(value)
+		}
+	}
+}
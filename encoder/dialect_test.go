@@ -0,0 +1,121 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type dialectConfig struct {
+	Name     string            `yaml:"name" toml:"title"`
+	Ratio    float64           `yaml:"ratio"`
+	Labels   map[string]string `yaml:"labels" json:"labels,omitempty"`
+	Backends []dialectBackend  `yaml:"backends" json:"backend_list"`
+	Skipped  string            `yaml:"-"`
+}
+
+type dialectBackend struct {
+	Host  string   `yaml:"host"`
+	Ports []int    `yaml:"ports,omitempty"`
+	Inner *Mixin   `yaml:",inline"`
+	Tags  []string `yaml:"tags,flow"`
+}
+
+func TestEncodeDialect(t *testing.T) {
+	value := &dialectConfig{
+		Name:  "test",
+		Ratio: 1,
+		Backends: []dialectBackend{
+			{Host: "a", Ports: []int{80, 443}, Inner: &Mixin{MixedIn: "x"}},
+			{Host: "b"},
+		},
+	}
+
+	data, err := EncodeDialect(value, DialectJSON)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "name": "test",
+  "ratio": 1,
+  "backend_list": [
+    {"host": "a", "ports": [80, 443], "mixed_in": "x", "tags": null},
+    {"host": "b", "tags": null}
+  ]
+}`, string(data))
+
+	data, err = EncodeDialect(value, DialectTOML)
+	require.NoError(t, err)
+	require.Equal(t, `title = "test"
+ratio = 1.0
+
+[[backends]]
+host = "a"
+ports = [80, 443]
+mixed_in = "x"
+
+[[backends]]
+host = "b"
+`, string(data))
+
+	_, err = EncodeDialect([]string{"a"}, DialectTOML)
+	require.Error(t, err)
+}
+
+func TestDocKey(t *testing.T) {
+	doc := &Doc{Name: "name", Tags: map[string]string{"toml": "title"}}
+
+	require.Equal(t, "title", doc.Key(DialectTOML))
+	require.Equal(t, "name", doc.Key(DialectJSON))
+}
+
+func TestMarshalTOML(t *testing.T) {
+	value := &Config{
+		Integer:      3,
+		Slice:        []string{"a"},
+		ComplexSlice: []*Endpoint{{Host: "h", Port: 80}},
+		Map:          map[string]*Endpoint{"x": {Host: "y"}},
+	}
+
+	data, err := MarshalTOML(value)
+	require.NoError(t, err)
+	require.Equal(t, `# test configuration
+
+integer = 3
+slice = ["a"] # <<<
+
+# complex slice
+[[complex_slice]]
+host = "h" # endpoint host
+port = 80 # custom port
+
+[map]
+
+[map.x]
+host = "y" # endpoint host
+`, string(data))
+
+	data, err = MarshalTOML(&Listener{Address: ":80"}, WithComments(CommentsShort))
+	require.NoError(t, err)
+	require.Equal(t, "address = \":80\" # Address to listen on,\n", string(data))
+
+	doc := &Doc{Fields: []Doc{{Secret: true}}}
+	data, err = MarshalTOML(&Listener{Address: ":80"}, WithComments(CommentsNone), WithDoc(&Listener{}, doc), WithRedaction())
+	require.NoError(t, err)
+	require.Equal(t, "address = \"<redacted>\"\n", string(data))
+
+	data, err = MarshalTOML(&dialectConfig{Name: "test"}, WithComments(CommentsNone))
+	require.NoError(t, err)
+	require.Equal(t, "title = \"test\"\nratio = 0.0\n", string(data))
+
+	_, err = MarshalTOML([]string{"a"})
+	require.Error(t, err)
+}
+
+func TestEncodeDialectYAMLExample(t *testing.T) {
+	data, err := EncodeDialect(map[string]interface{}{"job": yamlExample("name: a\nworkers: 2\nsteps:\n  - type: dns\n")}, DialectTOML)
+	require.NoError(t, err)
+	require.Equal(t, "[job]\nname = \"a\"\nworkers = 2\n\n[[job.steps]]\ntype = \"dns\"\n", string(data))
+}
@@ -0,0 +1,174 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeKind is the kind of a change between two versions of a field.
+type ChangeKind string
+
+const (
+	// ChangeAdded is reported for fields only present in the new version.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is reported for fields only present in the old version.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeRenamed is reported for fields moved to a different key of the
+	// same struct, keeping their type and description.
+	ChangeRenamed ChangeKind = "renamed"
+	// ChangeType is reported for fields whose type changed.
+	ChangeType ChangeKind = "type"
+	// ChangeDescription is reported for fields whose description changed.
+	ChangeDescription ChangeKind = "description"
+)
+
+// FieldChange is a change of a field between two versions of a file
+// documentation.
+type FieldChange struct {
+	Kind ChangeKind `json:"kind"`
+	// Path is the path of the field in the new version, or in the old
+	// version for removed fields.
+	Path string `json:"path"`
+	// OldPath is the path of renamed fields in the old version.
+	OldPath string `json:"old_path,omitempty"`
+	// Old and New are the old and new types or descriptions.
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// DocDiff contains the changes between two versions of a file documentation.
+type DocDiff struct {
+	Changes []FieldChange `json:"changes"`
+}
+
+// Diff returns the changes of the fields reachable from the root struct
+// between the old and the new file documentation. Changes are reported in
+// the order of the fields, removed fields last.
+func Diff(old, new *FileDoc) *DocDiff {
+	oldFlat, newFlat := old.Flatten(), new.Flatten()
+
+	oldFields := map[string]FlatField{}
+	for _, field := range oldFlat {
+		oldFields[field.Path] = field
+	}
+	newFields := map[string]bool{}
+	for _, field := range newFlat {
+		newFields[field.Path] = true
+	}
+
+	diff := &DocDiff{}
+	var added []FlatField
+	for _, field := range newFlat {
+		previous, ok := oldFields[field.Path]
+		if !ok {
+			added = append(added, field)
+			continue
+		}
+		if previous.Type != field.Type {
+			diff.Changes = append(diff.Changes, FieldChange{Kind: ChangeType, Path: field.Path, Old: previous.Type, New: field.Type})
+		}
+		if previous.Description != field.Description {
+			diff.Changes = append(diff.Changes, FieldChange{Kind: ChangeDescription, Path: field.Path, Old: previous.Description, New: field.Description})
+		}
+	}
+
+	var removed []FlatField
+	for _, field := range oldFlat {
+		if !newFields[field.Path] {
+			removed = append(removed, field)
+		}
+	}
+
+	renamed := map[string]bool{}
+	for _, field := range added {
+		if previous, ok := renamedFrom(field, removed, renamed); ok {
+			renamed[previous.Path] = true
+			diff.Changes = append(diff.Changes, FieldChange{Kind: ChangeRenamed, Path: field.Path, OldPath: previous.Path})
+			continue
+		}
+		diff.Changes = append(diff.Changes, FieldChange{Kind: ChangeAdded, Path: field.Path, New: field.Type})
+	}
+	for _, field := range removed {
+		if !renamed[field.Path] {
+			diff.Changes = append(diff.Changes, FieldChange{Kind: ChangeRemoved, Path: field.Path, Old: field.Type})
+		}
+	}
+	return diff
+}
+
+// renamedFrom returns the removed field of the same parent with the same
+// type and description as the added field. Fields without description are
+// never considered renamed.
+func renamedFrom(field FlatField, removed []FlatField, renamed map[string]bool) (FlatField, bool) {
+	if field.Description == "" {
+		return FlatField{}, false
+	}
+	for _, previous := range removed {
+		if !renamed[previous.Path] && parentPath(previous.Path) == parentPath(field.Path) &&
+			previous.Type == field.Type && previous.Description == field.Description {
+			return previous, true
+		}
+	}
+	return FlatField{}, false
+}
+
+func parentPath(path string) string {
+	if index := strings.LastIndexByte(path, '.'); index >= 0 {
+		return path[:index]
+	}
+	return ""
+}
+
+// Empty returns true when there are no changes.
+func (d *DocDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// Changelog returns the changes as a human readable markdown changelog,
+// grouped by kind.
+func (d *DocDiff) Changelog() string {
+	sections := []struct {
+		kind  ChangeKind
+		title string
+	}{
+		{ChangeAdded, "Added"},
+		{ChangeRemoved, "Removed"},
+		{ChangeRenamed, "Renamed"},
+		{ChangeType, "Changed types"},
+		{ChangeDescription, "Changed descriptions"},
+	}
+
+	var b strings.Builder
+	for _, section := range sections {
+		var lines []string
+		for _, change := range d.Changes {
+			if change.Kind != section.kind {
+				continue
+			}
+			switch change.Kind {
+			case ChangeAdded:
+				lines = append(lines, fmt.Sprintf("- `%s` (%s)", change.Path, change.New))
+			case ChangeRemoved:
+				lines = append(lines, fmt.Sprintf("- `%s` (%s)", change.Path, change.Old))
+			case ChangeRenamed:
+				lines = append(lines, fmt.Sprintf("- `%s` to `%s`", change.OldPath, change.Path))
+			case ChangeType:
+				lines = append(lines, fmt.Sprintf("- `%s`: %s to %s", change.Path, change.Old, change.New))
+			case ChangeDescription:
+				lines = append(lines, fmt.Sprintf("- `%s`", change.Path))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "### %s\n\n%s\n", section.title, strings.Join(lines, "\n"))
+	}
+	return b.String()
+}
@@ -0,0 +1,44 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	old := testFileDoc()
+	old.Structs[0].Fields[0].Description = "Name of the job."
+	old.Structs[1].Fields[1].Description = "Headers of the step."
+
+	new := testFileDoc()
+	new.Structs[0].Fields[0].Description = "Name of the job."
+	new.Structs[0].Fields[1].Type = "string"
+	new.Structs[0].Fields = append(new.Structs[0].Fields, Doc{Name: "retries", Type: "int"})
+	new.Structs[1].Fields[0].Description = "Protocol of the step."
+	new.Structs[1].Fields[1] = Doc{Name: "header", Type: "map[string]string", Description: "Headers of the step."}
+
+	require.True(t, Diff(old, old).Empty())
+
+	diff := Diff(old, new)
+	require.Equal(t, []FieldChange{
+		{Kind: ChangeType, Path: "workers", Old: "int", New: "string"},
+		{Kind: ChangeDescription, Path: "steps.type", New: "Protocol of the step."},
+		{Kind: ChangeRenamed, Path: "steps.header", OldPath: "steps.headers"},
+		{Kind: ChangeAdded, Path: "retries", New: "int"},
+	}, diff.Changes)
+
+	require.Equal(t, "### Added\n\n- `retries` (int)\n\n"+
+		"### Renamed\n\n- `steps.headers` to `steps.header`\n\n"+
+		"### Changed types\n\n- `workers`: int to string\n\n"+
+		"### Changed descriptions\n\n- `steps.type`\n", diff.Changelog())
+
+	new.Structs[1].Fields[1].Description = "Request headers."
+	diff = Diff(old, new)
+	require.Contains(t, diff.Changes, FieldChange{Kind: ChangeAdded, Path: "steps.header", New: "map[string]string"})
+	require.Contains(t, diff.Changes, FieldChange{Kind: ChangeRemoved, Path: "steps.headers", Old: "map[string]string"})
+}
@@ -0,0 +1,71 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import "sort"
+
+// Discriminator selects the struct documenting the shape of a value from
+// the value of one of its fields, such as the `type` of a request.
+type Discriminator struct {
+	// Field is the key holding the discriminating value.
+	Field string
+	// Mapping maps the discriminating values to the documented struct types.
+	Mapping map[string]string
+}
+
+// Values returns the sorted discriminating values.
+func (d *Discriminator) Values() []string {
+	values := make([]string, 0, len(d.Mapping))
+	for value := range d.Mapping {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// discriminatedSchema returns the schema of a struct with a discriminator,
+// applying the schema of the struct mapped to the value of the field. JSON
+// Schema documents use if/then conditions while OpenAPI documents use oneOf
+// along with the OpenAPI discriminator object.
+func (fd *FileDoc) discriminatedSchema(doc *Doc, refPrefix string) *Schema {
+	d := doc.Discriminator
+	property := &Schema{Type: "string"}
+	if field := doc.FieldByName(d.Field); field != nil {
+		property.Description = field.Description
+	}
+
+	schema := &Schema{
+		Type:        "object",
+		Description: doc.Description,
+		Properties:  map[string]*Schema{d.Field: property},
+		Required:    []string{d.Field},
+	}
+
+	values := d.Values()
+	for _, value := range values {
+		property.Enum = append(property.Enum, value)
+	}
+
+	if refPrefix == componentsPrefix {
+		schema.Discriminator = &SchemaDiscriminator{PropertyName: d.Field, Mapping: map[string]string{}}
+		for _, value := range values {
+			ref := refPrefix + d.Mapping[value]
+			schema.OneOf = append(schema.OneOf, &Schema{Ref: ref})
+			schema.Discriminator.Mapping[value] = ref
+		}
+		return schema
+	}
+
+	for _, value := range values {
+		schema.AllOf = append(schema.AllOf, &Schema{
+			If: &Schema{
+				Properties: map[string]*Schema{d.Field: {Const: value}},
+				Required:   []string{d.Field},
+			},
+			Then: &Schema{Ref: refPrefix + d.Mapping[value]},
+		})
+	}
+	return schema
+}
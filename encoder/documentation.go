@@ -5,6 +5,8 @@
 package encoder
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
@@ -30,6 +32,8 @@ type Doc struct {
 	Fields []Doc
 	// Examples list of example values for the item.
 	Examples []*Example
+	// BadExamples list of invalid values documenting common mistakes.
+	BadExamples []BadExample
 	// Values is only used to render valid values list in the documentation.
 	Values []string
 	// Description represents the full description for the item.
@@ -42,11 +46,79 @@ type Doc struct {
 	Note string
 	// AppearsIn describes back references for the type.
 	AppearsIn []Appearance
-
-	EnumFields      []string
+	// Tags contains the key of the field in other dialects, keyed by the
+	// struct tag name, e.g. json or toml.
+	Tags map[string]string
+	// DocsURL links to long-form documentation for the item.
+	DocsURL string
+	// Diagram illustrates the struct in the documentation.
+	Diagram *Diagram
+	// Required marks fields which must be set in a document.
+	Required bool
+	// Secret marks fields holding credentials, such as API keys or tokens,
+	// whose values are redacted by encoders created WithRedaction.
+	Secret bool
+	// Default is the yaml encoded default value of a field.
+	Default string
+	// MinItems is the minimum number of items of a list field, if not zero.
+	MinItems int
+	// MaxItems is the maximum number of items of a list field, if not zero.
+	MaxItems int
+	// UniqueItems requires the items of a list field to be unique.
+	UniqueItems bool
+	// Discriminator selects the struct documenting a value of the struct.
+	Discriminator *Discriminator
+	// Stability is the stability level of a struct, one of experimental,
+	// beta or stable.
+	Stability string
+	// Accepts lists the types accepted by a field decoded by a custom
+	// unmarshaler, e.g. string and []string, documented in place of its type.
+	Accepts []string
+	// KeyDoc describes what the keys of a map field represent.
+	KeyDoc string
+	// ReplacedBy is the key of the field replacing a deprecated field, to
+	// which Migrate renames the key in documents.
+	ReplacedBy string
+	// Source is the position of the Go declaration of the struct or field.
+	Source *Source
+
+	// EnumFields are the values of the enum type of the field.
+	EnumFields []string
+	// EnumValues are the values of the enum type of the field along with
+	// their descriptions.
+	EnumValues      []EnumValue
 	PartDefinitions []KeyValue
 }
 
+// EnumValue is a value of an enum type along with its description.
+type EnumValue struct {
+	Value       string
+	Description string
+}
+
+// Diagram is a diagram embedded in the documentation of a struct.
+type Diagram struct {
+	// Path is the path of the diagram file, used for linking images.
+	Path string
+	// Mermaid is the source of Mermaid diagrams, rendered inline.
+	Mermaid string
+	// Data is the contents of other diagrams, such as images, copied
+	// into the sites written by WriteSite.
+	Data []byte
+}
+
+// Source is the position of a Go declaration, with the file relative to the
+// root of its module, e.g. pkg/templates/http/request.go.
+type Source struct {
+	File string
+	Line int
+}
+
+// String returns the position as file:line.
+func (s *Source) String() string {
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
 type KeyValue struct {
 	Key   string
 	Value string
@@ -64,6 +136,65 @@ func (d *Doc) AddExample(name string, value interface{}) {
 	})
 }
 
+// AddYAMLExample adds a new example snippet written as yaml to the doc. The
+// snippet is rendered as is instead of marshaling a go value.
+func (d *Doc) AddYAMLExample(name, value string) {
+	d.AddExample(name, yamlExample(value))
+}
+
+// ExampleYAML returns the i-th example value of the doc marshaled as yaml,
+// with the documentation of the fields of documented structs as comments.
+func (d *Doc) ExampleYAML(i int) ([]byte, error) {
+	if i < 0 || i >= len(d.Examples) {
+		return nil, fmt.Errorf("example %d out of range, %d examples documented", i, len(d.Examples))
+	}
+
+	example := d.Examples[i]
+	example.Populate(i)
+
+	node, err := toYamlNode(example.GetValue(), &Options{Comments: CommentsDocs})
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(node)
+}
+
+// yamlExample is an example value written as yaml.
+type yamlExample string
+
+// MarshalYAML returns the node of the snippet, or the snippet as a string
+// if it is not valid yaml.
+func (e yamlExample) MarshalYAML() (interface{}, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(e), &node); err != nil || len(node.Content) == 0 {
+		return string(e), nil
+	}
+	return node.Content[0], nil
+}
+
+// Enum returns the values of the enum type of the field along with their
+// descriptions, falling back to EnumFields when EnumValues is not set.
+func (d *Doc) Enum() []EnumValue {
+	if len(d.EnumValues) > 0 {
+		return d.EnumValues
+	}
+	values := make([]EnumValue, 0, len(d.EnumFields))
+	for _, value := range d.EnumFields {
+		values = append(values, EnumValue{Value: value})
+	}
+	return values
+}
+
+// AllowedValues returns the documented values of the field followed by the
+// values of its enum type.
+func (d *Doc) AllowedValues() []string {
+	values := append([]string(nil), d.Values...)
+	for _, value := range d.Enum() {
+		values = append(values, value.Value)
+	}
+	return values
+}
+
 // Describe returns a field description.
 func (d *Doc) Describe(field string, short bool) string {
 	desc := ""
@@ -127,6 +258,33 @@ func (e *Example) GetName() string {
 	return e.Name
 }
 
+type jsonExample struct {
+	Name  string
+	Value interface{}
+}
+
+// MarshalJSON encodes the name of the example along with its value
+// converted to plain yaml types, so that the keys match the yaml keys.
+func (e *Example) MarshalJSON() ([]byte, error) {
+	value, _ := exampleValue(e)
+	return json.Marshal(&jsonExample{Name: e.Name, Value: value})
+}
+
+// UnmarshalJSON decodes an example encoded by MarshalJSON.
+func (e *Example) UnmarshalJSON(data []byte) error {
+	var example jsonExample
+	if err := json.Unmarshal(data, &example); err != nil {
+		return err
+	}
+
+	e.valueMutex.Lock()
+	defer e.valueMutex.Unlock()
+
+	e.Name = example.Name
+	e.value = example.Value
+	return nil
+}
+
 // Field gets field from the list of fields.
 func (d *Doc) Field(i int) *Doc {
 	if i < len(d.Fields) {
@@ -140,6 +298,51 @@ func (d *Doc) Field(i int) *Doc {
 type Appearance struct {
 	TypeName  string
 	FieldName string
+	// Kind is how the field references the type, one of ReferenceList,
+	// ReferenceMap and ReferencePointer, or empty for a single value.
+	Kind string
+	// Path is the dotted path of the field from the root struct, with []
+	// marking the items of lists and * the values of maps, e.g.
+	// http[].matchers. It is empty if the field is not reachable from the
+	// root struct.
+	Path string
+}
+
+const (
+	// ReferenceList references a type as the items of a list.
+	ReferenceList = "list"
+	// ReferenceMap references a type as the values of a map.
+	ReferenceMap = "map"
+	// ReferencePointer references a type as an optional value.
+	ReferencePointer = "pointer"
+)
+
+// ReferenceKind returns how a field of the type references the struct
+// its type resolves to, given whether the field is a pointer.
+func ReferenceKind(fieldType string, pointer bool) string {
+	switch {
+	case strings.HasPrefix(fieldType, "[]"):
+		return ReferenceList
+	case strings.HasPrefix(fieldType, "map["):
+		return ReferenceMap
+	case pointer:
+		return ReferencePointer
+	}
+	return ""
+}
+
+// Cardinality describes how the type appears in the field, e.g. "a list",
+// or returns an empty string if the field holds a single value.
+func (a Appearance) Cardinality() string {
+	switch a.Kind {
+	case ReferenceList:
+		return "a list"
+	case ReferenceMap:
+		return "map values"
+	case ReferencePointer:
+		return "an optional value"
+	}
+	return ""
 }
 
 // Documented is used to check if struct has any documentation defined for it.
@@ -168,6 +371,22 @@ func mergeDoc(a, b *Doc) *Doc {
 		res.Examples = b.Examples
 	}
 
+	if b.Description != "" {
+		res.Description = b.Description
+	}
+
+	if b.Secret {
+		res.Secret = true
+	}
+
+	if b.Required {
+		res.Required = true
+	}
+
+	if len(b.Values) > 0 || len(b.EnumFields) > 0 || len(b.EnumValues) > 0 {
+		res.Values, res.EnumFields, res.EnumValues = b.Values, b.EnumFields, b.EnumValues
+	}
+
 	return &res
 }
 
@@ -181,7 +400,7 @@ func getDoc(in interface{}) *Doc {
 		return d.Doc()
 	}
 
-	return nil
+	return Lookup(in)
 }
 
 func addComments(node *yaml.Node, doc *Doc, comments ...int) {
@@ -208,12 +427,49 @@ func addComments(node *yaml.Node, doc *Doc, comments ...int) {
 	}
 }
 
+// docComment returns the comment documenting the item at the verbosity of
+// the flags, or an empty string if docs are not rendered.
+func docComment(doc *Doc, flags CommentsFlags) string {
+	if doc == nil || !flags.enabled(CommentsDocs) {
+		return ""
+	}
+
+	comment := doc.Comments[LineComment]
+	if flags.enabled(CommentsDescription) && doc.Description != "" {
+		comment = strings.TrimSpace(doc.Description)
+	}
+	if flags.enabled(CommentsFirstLine) {
+		comment = firstLine(comment)
+	}
+
+	if flags.enabled(CommentsValues) {
+		values := doc.Values
+		if len(values) == 0 {
+			for _, v := range doc.Enum() {
+				values = append(values, v.Value)
+			}
+		}
+		if len(values) > 0 {
+			comment = joinComments(comment, "Values: "+strings.Join(values, ", "))
+		}
+	}
+
+	return comment
+}
+
 //nolint:gocyclo
-func renderExample(key string, doc *Doc, flags CommentsFlags) string {
+func renderExample(key string, doc *Doc, opts *Options) string {
 	if doc == nil {
 		return ""
 	}
 
+	// examples are not repeated within examples
+	if opts.FieldExamples {
+		opts = opts.with(func(o *Options) {
+			o.FieldExamples = false
+		})
+	}
+
 	examples := []string{}
 
 	for i, e := range doc.Examples {
@@ -231,7 +487,7 @@ func renderExample(key string, doc *Doc, flags CommentsFlags) string {
 
 		e.Populate(i)
 
-		node, err := toYamlNode(defaultValue, flags)
+		node, err := toYamlNode(defaultValue, opts)
 		if err != nil {
 			continue
 		}
@@ -239,13 +495,13 @@ func renderExample(key string, doc *Doc, flags CommentsFlags) string {
 		if key != "" {
 			node, err = toYamlNode(map[string]*yaml.Node{
 				key: node,
-			}, flags)
+			}, opts)
 			if err != nil {
 				continue
 			}
 		}
 
-		if i == 0 && flags.enabled(CommentsDocs) {
+		if i == 0 && opts.Comments.enabled(CommentsDocs) {
 			addComments(node, doc, HeadComment, LineComment)
 		}
 
@@ -263,7 +519,7 @@ func renderExample(key string, doc *Doc, flags CommentsFlags) string {
 			node.HeadComment = node.HeadComment + e.Name + "\n"
 		}
 
-		data, err := yaml.Marshal(node)
+		data, err := encodeYAML(node, opts.indentSize())
 		if err != nil {
 			continue
 		}
@@ -282,6 +538,48 @@ func renderExample(key string, doc *Doc, flags CommentsFlags) string {
 	return strings.Join(examples, "")
 }
 
+// renderFieldExamples renders the examples of the field as an examples
+// block to be commented out beneath the field.
+func renderFieldExamples(key string, doc *Doc, opts *Options) string {
+	if doc == nil {
+		return ""
+	}
+
+	lines := []string{"examples:"}
+
+	for i, e := range doc.Examples {
+		if isEmpty(reflect.ValueOf(e.GetValue())) {
+			continue
+		}
+
+		e.Populate(i)
+
+		node, err := toYamlNode(map[string]interface{}{key: e.GetValue()}, &Options{})
+		if err != nil {
+			continue
+		}
+
+		data, err := encodeYAML(node, opts.indentSize())
+		if err != nil {
+			continue
+		}
+
+		if e.Name != "" {
+			lines = append(lines, "  # "+e.Name)
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			lines = append(lines, "  "+line)
+		}
+	}
+
+	if len(lines) == 1 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func getExample(v reflect.Value, doc *Doc, index int) *reflect.Value {
 	if doc == nil || len(doc.Examples) == 0 {
 		return nil
@@ -297,6 +595,10 @@ func getExample(v reflect.Value, doc *Doc, index int) *reflect.Value {
 		if v.Kind() != reflect.Ptr && defaultValue.Kind() == reflect.Ptr {
 			defaultValue = defaultValue.Elem()
 		}
+		// yaml snippets can not be assigned to the go value
+		if _, ok := defaultValue.Interface().(yamlExample); ok {
+			return nil
+		}
 	}
 
 	return &defaultValue
@@ -0,0 +1,58 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExampleJSON(t *testing.T) {
+	doc := &Doc{Type: "Endpoint"}
+	doc.AddExample("local", &Endpoint{Host: "localhost", Port: 8080})
+
+	data, err := json.Marshal(doc.Examples[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Name": "local", "Value": {"host": "localhost", "port": 8080}}`, string(data))
+
+	example := &Example{}
+	require.NoError(t, json.Unmarshal(data, example))
+	require.Equal(t, "local", example.GetName())
+	require.Equal(t, map[string]interface{}{"host": "localhost", "port": float64(8080)}, example.GetValue())
+}
+
+func TestYAMLExample(t *testing.T) {
+	doc := &Doc{Type: "Endpoint"}
+	doc.AddYAMLExample("local", "host: localhost # loopback\nport: 8080\n")
+
+	data, err := json.Marshal(doc.Examples[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Name": "local", "Value": {"host": "localhost", "port": 8080}}`, string(data))
+
+	rendered := renderExample("endpoint", doc, &Options{Comments: CommentsAll})
+	require.Equal(t, rendered, renderExample("endpoint", doc, &Options{Comments: CommentsAll}))
+	require.Equal(t, "# # local\nendpoint:\n    host: localhost # loopback\n    port: 8080\n", rendered)
+}
+
+func TestExampleYAML(t *testing.T) {
+	data, err := configDoc.Fields[2].ExampleYAML(0)
+	require.NoError(t, err)
+	require.Equal(t, "- host: 127.0.0.1 # endpoint host\n  port: 5554 # custom port\n", string(data))
+
+	data, err = endpointDoc.ExampleYAML(0)
+	require.Nil(t, data)
+	require.EqualError(t, err, "example 0 out of range, 0 examples documented")
+}
+
+func TestEnum(t *testing.T) {
+	doc := &Doc{EnumFields: []string{"tcp", "udp"}}
+	require.Equal(t, []EnumValue{{Value: "tcp"}, {Value: "udp"}}, doc.Enum())
+	require.Equal(t, []string{"tcp", "udp"}, doc.AllowedValues())
+
+	doc.EnumValues = []EnumValue{{Value: "tcp", Description: "Raw TCP connection."}, {Value: "udp"}}
+	require.Equal(t, doc.EnumValues, doc.Enum())
+}
@@ -0,0 +1,81 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// MarshalDocuments returns the values of the slice as a multi-document
+// yaml stream, separated by `---` markers. Each document starts with the
+// header of its file documentation, given WithFileDoc.
+func MarshalDocuments(values interface{}, opts ...Option) ([]byte, error) {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a slice of documents, got %T", values)
+	}
+
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf, opts...)
+	enc.terminal = os.Stdout
+	for i := 0; i < v.Len(); i++ {
+		if err := enc.Encode(v.Index(i).Interface()); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i+1, err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// fileDoc returns the file documentation whose root struct documents the
+// value, or nil if there is none.
+func (o *Options) fileDoc(value interface{}) *FileDoc {
+	doc := o.getDoc(value)
+	if doc == nil {
+		return nil
+	}
+
+	for _, fd := range o.FileDocs {
+		root := fd.Root()
+		if root == doc || (root != nil && root.Type != "" && root.Type == doc.Type) {
+			return fd
+		}
+	}
+
+	return nil
+}
+
+// fileHeader returns the comment describing the file documentation at the
+// top of its documents, followed by an empty line.
+func fileHeader(fd *FileDoc) []byte {
+	var lines []string
+	if fd.Name != "" {
+		lines = append(lines, fd.Name)
+	}
+
+	if description := strings.TrimSpace(fd.Description); description != "" {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,50 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalDocuments(t *testing.T) {
+	listeners := &FileDoc{Name: "Listener", Description: "Listener accepts connections.", Structs: []*Doc{&listenerDoc}}
+	machines := &FileDoc{Name: "Machine", Structs: []*Doc{&machineDoc, &machineConfigDoc}}
+
+	data, err := MarshalDocuments([]interface{}{
+		&Listener{Address: ":80"},
+		&Machine{State: 1},
+		&Listener{Address: ":443"},
+	}, WithComments(CommentsDocs), WithFileDoc(listeners), WithFileDoc(machines))
+	require.NoError(t, err)
+	require.Equal(t, `# Listener
+#
+# Listener accepts connections.
+
+# Address to listen on,
+# such as :8080.
+address: :80
+# served hosts
+hosts: []
+---
+# Machine
+
+state: 1
+---
+# Listener
+#
+# Listener accepts connections.
+
+# Address to listen on,
+# such as :8080.
+address: :443
+# served hosts
+hosts: []
+`, string(data))
+
+	_, err = MarshalDocuments(&Listener{})
+	require.EqualError(t, err, "expected a slice of documents, got *encoder.Listener")
+}
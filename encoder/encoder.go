@@ -5,55 +5,217 @@
 package encoder
 
 import (
+	"bytes"
+	"io"
+	"os"
 	"reflect"
 	"sort"
 	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v3"
 )
 
-// Encoder implements config encoder.
+// Redacted replaces the values of secret fields encoded WithRedaction.
+const Redacted = "<redacted>"
+
+// yamlIndent is the default indentation of nested mappings.
+const yamlIndent = 4
+
+// Encoder writes documented yaml documents to an io.Writer.
 type Encoder struct {
-	value   interface{}
-	options *Options
+	w         io.Writer
+	options   *Options
+	documents int
+
+	// terminal is checked in place of w by ColorAuto, if set.
+	terminal io.Writer
 }
 
-// NewEncoder initializes and returns an `Encoder`.
-func NewEncoder(value interface{}, opts ...Option) *Encoder {
+// NewEncoder initializes and returns an `Encoder` writing to w.
+func NewEncoder(w io.Writer, opts ...Option) *Encoder {
 	return &Encoder{
-		value:   value,
+		w:       w,
 		options: newOptions(opts...),
 	}
 }
 
-// Marshal converts value to YAML-serializable value (suitable for MarshalYAML).
-func (e *Encoder) Marshal() (*yaml.Node, error) {
-	node, err := toYamlNode(e.value, e.options.Comments)
+// Encode writes the yaml of the value to the writer, separated from the
+// documents written before by a document marker. Options override the
+// options of the encoder for this call, e.g. to select the comments
+// verbosity.
+func (e *Encoder) Encode(value interface{}, opts ...Option) error {
+	options := e.options
+	if len(opts) > 0 {
+		options = options.with(opts...)
+	}
+
+	data, err := marshal(value, options)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case e.documents > 0:
+		data = append([]byte("---\n"), data...)
+	case options.SchemaURL != "":
+		// the modeline applies to the whole stream
+		data = append([]byte(LanguageServerHeader(options.SchemaURL)+"\n"), data...)
+	}
+	e.documents++
+
+	terminal := e.terminal
+	if terminal == nil {
+		terminal = e.w
+	}
+	if options.Color.enabled(terminal) {
+		data = colorize(data)
+	}
+
+	_, err = e.w.Write(data)
+
+	return err
+}
+
+// Marshal returns the documented yaml of the value.
+func Marshal(value interface{}, opts ...Option) ([]byte, error) {
+	options := newOptions(opts...)
+
+	data, err := marshal(value, options)
 	if err != nil {
 		return nil, err
 	}
 
-	if e.options.Comments.enabled(CommentsDocs) {
-		addComments(node, getDoc(e.value), HeadComment, LineComment)
+	if options.SchemaURL != "" {
+		data = append([]byte(LanguageServerHeader(options.SchemaURL)+"\n"), data...)
+	}
+	if options.Color.enabled(os.Stdout) {
+		data = colorize(data)
+	}
+
+	return data, nil
+}
+
+// MarshalNode converts value to YAML-serializable value (suitable for
+// MarshalYAML).
+func MarshalNode(value interface{}, opts ...Option) (*yaml.Node, error) {
+	return marshalNode(value, newOptions(opts...))
+}
+
+// ValueEncoder encodes a single value bound to it, as the Encoder did before
+// version 2.0.0.
+//
+// Deprecated: use Marshal and MarshalNode. ValueEncoder will be removed in
+// the next major version.
+type ValueEncoder struct {
+	value interface{}
+	opts  []Option
+}
+
+// NewValueEncoder initializes and returns a `ValueEncoder` for value.
+//
+// Deprecated: use Marshal and MarshalNode.
+func NewValueEncoder(value interface{}, opts ...Option) *ValueEncoder {
+	return &ValueEncoder{
+		value: value,
+		opts:  opts,
+	}
+}
+
+// Encode converts the value to yaml.
+//
+// Deprecated: use Marshal.
+func (e *ValueEncoder) Encode() ([]byte, error) {
+	return Marshal(e.value, e.opts...)
+}
+
+// Marshal converts the value to YAML-serializable value (suitable for
+// MarshalYAML).
+//
+// Deprecated: use MarshalNode.
+func (e *ValueEncoder) Marshal() (*yaml.Node, error) {
+	return MarshalNode(e.value, e.opts...)
+}
+
+func marshalNode(value interface{}, opts *Options) (*yaml.Node, error) {
+	if opts.Anchors {
+		opts = opts.with(func(o *Options) {
+			o.anchors = &anchors{types: map[*yaml.Node]reflect.Type{}}
+		})
+	}
+
+	node, err := toYamlNode(value, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.anchors != nil {
+		opts.anchors.apply(node, opts.indentSize())
+	}
+
+	if opts.Comments.enabled(CommentsDocs) {
+		doc := opts.getDoc(value)
+
+		addComments(node, doc, HeadComment)
+		if comment := docComment(doc, opts.Comments); comment != "" {
+			node.LineComment = comment
+		}
+
+		if opts.WrapColumns > 0 {
+			node.HeadComment = wrapComment(node.HeadComment, opts.WrapColumns-commentPrefix)
+		}
+	}
+
+	if opts.Marker {
+		markComments(node)
 	}
 
 	return node, nil
 }
 
-// Encode converts value to yaml.
+// marshal converts value to yaml, reporting the encoding to the metrics.
+func marshal(value interface{}, opts *Options) ([]byte, error) {
+	start := time.Now()
+
+	data, err := marshalYAML(value, opts)
+	if err == nil && opts.Comments.enabled(CommentsDocs) {
+		if fd := opts.fileDoc(value); fd != nil {
+			header := fileHeader(fd)
+			if opts.Marker {
+				header = []byte(markComment(string(header)))
+			}
+			data = append(header, data...)
+		}
+	}
+
+	event := &Event{
+		Kind:     EventEncode,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if doc := opts.getDoc(value); doc != nil {
+		event.Name = doc.Type
+		event.Structs = 1
+		event.Fields = len(doc.Fields)
+	}
+	Observe(event)
+
+	return data, err
+}
+
 //nolint:gocyclo
-func (e *Encoder) Encode() ([]byte, error) {
-	if e.options.Comments == CommentsDisabled {
-		return yaml.Marshal(e.value)
+func marshalYAML(value interface{}, opts *Options) ([]byte, error) {
+	if opts.plain() {
+		return encodeYAML(value, opts.indentSize())
 	}
 
-	node, err := e.Marshal()
+	node, err := marshalNode(value, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	// special handling for case when we get an empty output
-	if node.Kind == yaml.MappingNode && len(node.Content) == 0 && node.FootComment != "" && e.options.Comments.enabled(CommentsExamples) {
+	if node.Kind == yaml.MappingNode && len(node.Content) == 0 && node.FootComment != "" && opts.Comments.enabled(CommentsExamples) {
 		res := ""
 
 		if node.HeadComment != "" {
@@ -67,9 +229,32 @@ func (e *Encoder) Encode() ([]byte, error) {
 			}
 			lines[i] = "# " + line
 		}
-		return []byte(strings.Join(lines, "\n")), nil
+
+		res = strings.Join(lines, "\n")
+		if !strings.HasSuffix(res, "\n") {
+			res += "\n"
+		}
+		return []byte(res), nil
 	}
-	return yaml.Marshal(node)
+	return encodeYAML(node, opts.indentSize())
+}
+
+// encodeYAML marshals the value to yaml indented by indent spaces.
+func encodeYAML(value interface{}, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
 }
 
 func isEmpty(value reflect.Value) bool {
@@ -105,7 +290,7 @@ func isNil(value reflect.Value) bool {
 }
 
 //nolint:gocyclo,cyclop
-func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
+func toYamlNode(in interface{}, opts *Options) (*yaml.Node, error) {
 	node := &yaml.Node{}
 
 	// do not wrap yaml.Node into yaml.Node
@@ -133,7 +318,7 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 		v = v.Elem()
 	}
 
-	doc := getDoc(in)
+	doc := opts.getDoc(in)
 
 	//nolint:exhaustive
 	switch v.Kind() {
@@ -141,21 +326,26 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 		node.Kind = yaml.MappingNode
 
 		t := v.Type()
+		structName := t.Name()
+		if doc != nil && doc.Type != "" {
+			structName = doc.Type
+		}
 
 		examples := []string{}
 
+		// commented are the empty fields written as comments above the
+		// next field
+		var commented []string
+
 		for i := 0; i < v.NumField(); i++ {
 			// skip unexported fields
 			if !v.Field(i).CanInterface() {
 				continue
 			}
 
-			tag := t.Field(i).Tag.Get("yaml")
-			parts := strings.Split(tag, ",")
-			fieldName := parts[0]
-			parts = parts[1:]
+			fieldName, parts := fieldKey(t.Field(i))
 
-			tag = t.Field(i).Tag.Get("talos")
+			tag := t.Field(i).Tag.Get("talos")
 			if tag != "" {
 				parts = append(parts, strings.Split(tag, ",")...)
 			}
@@ -173,6 +363,7 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 				null  = isNil(v.Field(i))
 
 				skip   bool
+				keep   bool
 				inline bool
 				flow   bool
 			)
@@ -184,9 +375,10 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 
 				if part == "omitonlyifnil" && !null {
 					skip = false
+					keep = true
 				}
 
-				if part == "inline" {
+				if part == "inline" || part == "squash" {
 					inline = true
 				}
 
@@ -204,18 +396,34 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 			var fieldDoc *Doc
 
 			if doc != nil {
-				fieldDoc = mergeDoc(getDoc(value), doc.Field(i))
+				fieldDoc = mergeDoc(opts.getDoc(value), doc.Field(i))
 			} else {
-				fieldDoc = getDoc(value)
+				fieldDoc = opts.getDoc(value)
+			}
+
+			if opts.Redact && !empty && fieldDoc != nil && fieldDoc.Secret {
+				value = Redacted
+			}
+
+			// empty optional fields are omitted or commented out on request
+			var comment string
+
+			if empty && !keep && !inline && (opts.OmitEmpty || opts.CommentEmpty) && (fieldDoc == nil || !fieldDoc.Required) {
+				skip = true
+
+				if opts.CommentEmpty {
+					comment = renderEmptyField(fieldName, value, fieldDoc, opts)
+					commented = append(commented, comment)
+				}
 			}
 
 			// inlineExample is rendered after the value
 			var inlineExample string
 
-			if empty && flags.enabled(CommentsExamples) && fieldDoc != nil {
+			if empty && opts.Comments.enabled(CommentsExamples) && fieldDoc != nil && comment == "" {
 				if skip {
 					// render example to be appended to the end of the rendered struct
-					example := renderExample(fieldName, fieldDoc, flags)
+					example := renderExample(fieldName, fieldDoc, opts)
 
 					if example != "" {
 						examples = append(examples, example)
@@ -225,10 +433,17 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 					fieldDocCopy := *fieldDoc
 					fieldDocCopy.Comments = [3]string{}
 
-					inlineExample = renderExample("", &fieldDocCopy, flags)
+					inlineExample = renderExample("", &fieldDocCopy, opts)
 				}
 			}
 
+			// fieldExamples is rendered beneath the field
+			var fieldExamples string
+
+			if opts.FieldExamples && inlineExample == "" && !skip && !inline {
+				fieldExamples = renderFieldExamples(fieldName, fieldDoc, opts)
+			}
+
 			if skip {
 				continue
 			}
@@ -238,8 +453,11 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 				style |= yaml.FlowStyle
 			}
 
+			// the field starts at the first key it appends
+			first := len(node.Content)
+
 			if inline {
-				child, err := toYamlNode(value, flags)
+				child, err := toYamlNode(value, opts)
 				if err != nil {
 					return nil, err
 				}
@@ -247,7 +465,7 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 				if child.Kind == yaml.MappingNode || child.Kind == yaml.SequenceNode {
 					appendNodes(node, child.Content...)
 				}
-			} else if err := addToMap(node, fieldDoc, fieldName, value, style, flags); err != nil {
+			} else if err := addToMap(node, fieldDoc, fieldName, value, style, opts.placement(structName, fieldName), opts); err != nil {
 				return nil, err
 			}
 
@@ -260,8 +478,33 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 
 				nodeToAttach.FootComment += inlineExample
 			}
+
+			if fieldExamples != "" {
+				key := node.Content[len(node.Content)-2]
+				key.FootComment = joinComments(key.FootComment, fieldExamples)
+			}
+
+			if len(commented) > 0 && len(node.Content) > first {
+				key := node.Content[first]
+				key.HeadComment = joinComments(strings.Join(commented, "\n"), key.HeadComment)
+				commented = nil
+			}
 		}
 
+		if len(commented) > 0 {
+			// the last fields are commented out beneath the struct
+			if len(node.Content) > 0 {
+				key := node.Content[len(node.Content)-2]
+				key.FootComment = joinComments(key.FootComment, strings.Join(commented, "\n"))
+			} else {
+				node.FootComment = joinComments(node.FootComment, strings.Join(commented, "\n"))
+			}
+		}
+
+		orderPairs(node, doc, opts.Order)
+
+		opts.anchors.record(node, t)
+
 		if len(examples) > 0 {
 			comment := strings.Join(examples, "\n")
 			// add rendered example to the foot comment of the last node
@@ -275,16 +518,16 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 	case reflect.Map:
 		node.Kind = yaml.MappingNode
 		keys := v.MapKeys()
-		// always interate keys in alphabetical order to preserve the same output for maps
+		// always iterate keys in order to preserve the same output for maps
 		sort.Slice(keys, func(i, j int) bool {
-			return keys[i].String() < keys[j].String()
+			return mapKeyLess(keys[i], keys[j])
 		})
 
 		for _, k := range keys {
 			element := v.MapIndex(k)
 			value := element.Interface()
 
-			if err := addToMap(node, nil, k.Interface(), value, 0, flags); err != nil {
+			if err := addToMap(node, nil, k.Interface(), value, 0, CommentPlacementDefault, opts); err != nil {
 				return nil, err
 			}
 		}
@@ -297,7 +540,7 @@ func toYamlNode(in interface{}, flags CommentsFlags) (*yaml.Node, error) {
 
 			var err error
 
-			nodes[i], err = toYamlNode(element.Interface(), flags)
+			nodes[i], err = toYamlNode(element.Interface(), opts.nested(len("- ")))
 			if err != nil {
 				return nil, err
 			}
@@ -320,26 +563,46 @@ func appendNodes(dest *yaml.Node, nodes ...*yaml.Node) {
 	dest.Content = append(dest.Content, nodes...)
 }
 
-func addToMap(dest *yaml.Node, doc *Doc, fieldName, in interface{}, style yaml.Style, flags CommentsFlags) error {
-	key, err := toYamlNode(fieldName, flags)
+func addToMap(dest *yaml.Node, doc *Doc, fieldName, in interface{}, style yaml.Style, placement CommentPlacement, opts *Options) error {
+	key, err := toYamlNode(fieldName, opts)
 	if err != nil {
 		return err
 	}
 
-	value, err := toYamlNode(in, flags)
+	value, err := toYamlNode(in, opts.nested(opts.indentSize()))
 	if err != nil {
 		return err
 	}
 
 	value.Style = style
 
-	if flags.enabled(CommentsDocs) {
+	docs := opts.Comments.enabled(CommentsDocs)
+	if docs {
 		addComments(key, doc, HeadComment, FootComment)
-		addComments(value, doc, LineComment)
+
+		if opts.Comments.enabled(CommentsFirstLine) {
+			key.HeadComment = firstLine(key.HeadComment)
+			key.FootComment = ""
+		}
+	}
+
+	comment := docComment(doc, opts.Comments)
+
+	if docs && placement != CommentPlacementDefault {
+		if value.Kind != yaml.ScalarNode {
+			value.LineComment = ""
+		}
+
+		placeComment(key, value, comment, placement)
+	} else if docs && strings.Contains(comment, "\n") {
+		// comments spanning several lines can not be written inline
+		key.HeadComment = joinComments(key.HeadComment, comment)
+	} else if comment != "" {
+		value.LineComment = comment
 	}
 
 	// override head comment with line comment for non-scalar nodes
-	if value.Kind != yaml.ScalarNode {
+	if placement == CommentPlacementDefault && value.Kind != yaml.ScalarNode {
 		if key.HeadComment == "" {
 			key.HeadComment = value.LineComment
 		}
@@ -347,7 +610,67 @@ func addToMap(dest *yaml.Node, doc *Doc, fieldName, in interface{}, style yaml.S
 		value.LineComment = ""
 	}
 
+	if docs && opts.WrapColumns > 0 {
+		wrapComments(key, value, opts)
+	}
+
 	appendNodes(dest, key, value)
 
 	return nil
 }
+
+// renderEmptyField renders the empty field to be written as a comment.
+func renderEmptyField(fieldName string, value interface{}, doc *Doc, opts *Options) string {
+	// nil pointers are not marshaled by their yaml.Marshaler
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		value = nil
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	if err := addToMap(node, doc, fieldName, value, 0, CommentPlacementDefault, opts); err != nil {
+		return ""
+	}
+
+	data, err := encodeYAML(node, opts.indentSize())
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// placeComment places the comment documenting a field at the placement,
+// inline comments of block lists and maps following the key.
+func placeComment(key, value *yaml.Node, comment string, placement CommentPlacement) {
+	if comment == "" {
+		return
+	}
+
+	// comments spanning several lines can not be written inline
+	if placement == CommentPlacementLine && strings.Contains(comment, "\n") {
+		placement = CommentPlacementHead
+	}
+
+	//nolint:exhaustive
+	switch placement {
+	case CommentPlacementHead:
+		key.HeadComment = joinComments(key.HeadComment, comment)
+	case CommentPlacementFoot:
+		key.FootComment = joinComments(comment, key.FootComment)
+	case CommentPlacementLine:
+		// empty and flow lists and maps are written on the line of the key
+		if value.Kind == yaml.ScalarNode || len(value.Content) == 0 || value.Style&yaml.FlowStyle != 0 {
+			value.LineComment = comment
+		} else {
+			key.LineComment = comment
+		}
+	}
+}
+
+// joinComments joins the non-empty comments with a line break.
+func joinComments(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n" + b
+}
@@ -5,6 +5,7 @@
 package encoder
 
 import (
+	"bytes"
 	"sync"
 	"testing"
 
@@ -230,6 +231,58 @@ map: {}
 				WithComments(CommentsDocs),
 			},
 		},
+		{
+			name:  "default struct with schema header",
+			value: &Config{},
+			expectedYAML: `# yaml-language-server: $schema=https://example.com/config.json
+integer: 0
+slice: []
+complex_slice: []
+map: {}
+`,
+			options: []Option{
+				WithComments(CommentsDisabled),
+				WithSchemaURL("https://example.com/config.json"),
+			},
+		},
+		{
+			name: "comments above keys",
+			value: &Config{
+				ComplexSlice: []*Endpoint{e},
+			},
+			expectedYAML: `integer: 0
+# <<<
+slice: []
+# complex slice
+complex_slice:
+    - # endpoint host
+      host: ""
+      # custom port
+      port: 8080
+map: {}
+# some text example for map
+`,
+			options: []Option{
+				WithComments(CommentsDocs),
+				WithCommentPlacement(CommentPlacementHead),
+			},
+		},
+		{
+			name:  "comments placed per field",
+			value: &Config{},
+			expectedYAML: `integer: 0
+slice: [] # <<<
+# complex slice
+complex_slice: []
+map: {}
+# some text example for map
+`,
+			options: []Option{
+				WithComments(CommentsDocs),
+				WithCommentPlacement(CommentPlacementFoot),
+				WithFieldCommentPlacement("Config.slice", CommentPlacementLine),
+			},
+		},
 		{
 			name: "struct with custom marshaller",
 			value: &Config{
@@ -498,8 +551,7 @@ nilslice: []
 	}
 
 	for _, test := range tests {
-		encoder := NewEncoder(test.value, test.options...)
-		data, err := encoder.Encode()
+		data, err := Marshal(test.value, test.options...)
 		suite.Assert().NoError(err)
 
 		// compare with expected string output
@@ -524,6 +576,327 @@ nilslice: []
 	}
 }
 
+type Listener struct {
+	Address string   `yaml:"address"`
+	Hosts   []string `yaml:"hosts"`
+}
+
+var listenerDoc Doc
+
+func init() {
+	listenerDoc.Fields = make([]Doc, 2)
+	listenerDoc.Fields[0].Comments[LineComment] = "Address to listen on,\nsuch as :8080."
+	listenerDoc.Fields[1].Comments[LineComment] = "served hosts"
+}
+
+func (l Listener) Doc() *Doc {
+	return &listenerDoc
+}
+
+func (suite *EncoderSuite) TestCommentPlacement() {
+	value := &Listener{Address: ":8080", Hosts: []string{"a", "b"}}
+
+	data, err := Marshal(value, WithComments(CommentsDocs), WithCommentPlacement(CommentPlacementLine))
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`# Address to listen on,
+# such as :8080.
+address: :8080
+hosts: # served hosts
+    - a
+    - b
+`, string(data))
+
+	data, err = Marshal(value,
+		WithComments(CommentsDocs),
+		WithCommentPlacement(CommentPlacementHead),
+		WithFieldCommentPlacement("Listener.hosts", CommentPlacementFoot),
+	)
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`# Address to listen on,
+# such as :8080.
+address: :8080
+hosts:
+    - a
+    - b
+# served hosts
+`, string(data))
+}
+
+func (suite *EncoderSuite) TestCommentWrap() {
+	defer func(doc Doc) {
+		listenerDoc = doc
+	}(listenerDoc)
+
+	listenerDoc.Fields = []Doc{
+		{Comments: [3]string{LineComment: "Address to listen on, given as a host and a port such as :8080."}},
+		{Comments: [3]string{HeadComment: "Hosts served by the listener.\n\nRequests for other hosts are rejected."}},
+	}
+
+	data, err := Marshal(map[string][]*Listener{
+		"listeners": {{Address: ":8080", Hosts: []string{"a"}}},
+	}, WithComments(CommentsDocs), WithCommentWrap(40))
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`listeners:
+    - # Address to listen on, given as a
+      # host and a port such as :8080.
+      address: :8080
+      # Hosts served by the listener.
+      #
+      # Requests for other hosts are
+      # rejected.
+      hosts:
+        - a
+`, string(data))
+}
+
+func (suite *EncoderSuite) TestCommentsLevels() {
+	defer func(doc Doc) {
+		listenerDoc = doc
+	}(listenerDoc)
+
+	listenerDoc.Fields = []Doc{
+		{
+			Comments:    [3]string{LineComment: "Address to listen on."},
+			Description: "Address to listen on.\nThe port defaults to 80.",
+		},
+		{
+			Comments:    [3]string{HeadComment: "Hosts served.\nOthers are rejected.", LineComment: "served hosts"},
+			Description: "served hosts",
+			Values:      []string{"a", "b"},
+		},
+	}
+
+	value := &Listener{Address: ":8080", Hosts: []string{"a"}}
+
+	data, err := Marshal(value, WithComments(CommentsShort))
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`address: :8080 # Address to listen on.
+# Hosts served.
+hosts:
+    - a
+`, string(data))
+
+	data, err = Marshal(value, WithComments(CommentsFull))
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`# Address to listen on.
+# The port defaults to 80.
+address: :8080
+# Hosts served.
+# Others are rejected.
+# served hosts
+# Values: a, b
+hosts:
+    - a
+`, string(data))
+
+	data, err = Marshal(value, WithComments(CommentsNone))
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`address: :8080
+hosts:
+    - a
+`, string(data))
+}
+
+func (suite *EncoderSuite) TestParseComments() {
+	for level, expected := range map[string]CommentsFlags{
+		"none":    CommentsNone,
+		"minimal": CommentsShort,
+		"default": CommentsAll,
+		"full":    CommentsFull,
+	} {
+		flags, err := ParseComments(level)
+		suite.Require().NoError(err)
+		suite.Assert().Equal(expected, flags, level)
+	}
+
+	_, err := ParseComments("verbose")
+	suite.Assert().EqualError(err, `unknown comments level "verbose", expected one of none, short, docs, all or full`)
+}
+
+func (suite *EncoderSuite) TestFieldExamples() {
+	value := &Config{
+		ComplexSlice: []*Endpoint{{Host: "localhost"}},
+		NilSlice:     Manifests{{Name: "bar"}},
+	}
+
+	data, err := Marshal(value, WithComments(CommentsDocs), WithFieldExamples())
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`integer: 0
+# <<<
+slice: []
+# complex slice
+complex_slice:
+    - host: localhost # endpoint host
+# examples:
+#   # slice example
+#   complex_slice:
+#       - host: 127.0.0.1
+#         port: 5554
+
+map: {}
+# some text example for map
+
+# A nilslice field is really cool.
+nilslice:
+    - name: bar
+# examples:
+#   # nilslice example
+#   nilslice:
+#       - name: foo
+`, string(data))
+
+	var decoded Config
+	suite.Require().NoError(yaml.Unmarshal(data, &decoded))
+	suite.Assert().Equal(value.NilSlice, decoded.NilSlice)
+}
+
+func (suite *EncoderSuite) TestRedaction() {
+	defer func(doc Doc) {
+		listenerDoc = doc
+	}(listenerDoc)
+
+	listenerDoc.Fields = []Doc{
+		{Comments: [3]string{LineComment: "listen address"}, Secret: true},
+		{Comments: [3]string{LineComment: "served hosts"}, Secret: true},
+	}
+
+	data, err := Marshal(&Listener{Address: ":8080"}, WithComments(CommentsDocs), WithRedaction())
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`address: <redacted> # listen address
+# served hosts
+hosts: []
+`, string(data))
+
+	data, err = Marshal(&Listener{Address: ":8080", Hosts: []string{"a"}}, WithComments(CommentsDisabled), WithRedaction())
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`address: <redacted>
+hosts: <redacted>
+`, string(data))
+}
+
+func (suite *EncoderSuite) TestStream() {
+	var buf bytes.Buffer
+
+	encoder := NewEncoder(&buf, WithComments(CommentsDocs), WithSchemaURL("https://example.com/listener.json"))
+	suite.Require().NoError(encoder.Encode(&Listener{Address: ":80"}))
+	suite.Require().NoError(encoder.Encode(&Listener{Address: ":443"}, WithComments(CommentsNone)))
+	suite.Assert().Equal(`# yaml-language-server: $schema=https://example.com/listener.json
+# Address to listen on,
+# such as :8080.
+address: :80
+# served hosts
+hosts: []
+---
+address: :443
+hosts: []
+`, buf.String())
+}
+
+func (suite *EncoderSuite) TestValueEncoder() {
+	value := &Listener{Address: ":80"}
+
+	expected, err := Marshal(value, WithComments(CommentsDocs))
+	suite.Require().NoError(err)
+
+	data, err := NewValueEncoder(value, WithComments(CommentsDocs)).Encode()
+	suite.Require().NoError(err)
+	suite.Assert().Equal(string(expected), string(data))
+
+	expectedNode, err := MarshalNode(value)
+	suite.Require().NoError(err)
+
+	node, err := NewValueEncoder(value).Marshal()
+	suite.Require().NoError(err)
+	suite.Assert().Equal(expectedNode, node)
+}
+
+func (suite *EncoderSuite) TestOptions() {
+	value := &Machine{State: 1, Config: &MachineConfig{Capabilities: []string{"reboot"}}}
+
+	data, err := Marshal(value,
+		WithComments(CommentsDocs),
+		WithIndent(2),
+		WithFieldOrder(FieldOrderAlphabetical),
+		WithOmitEmpty(),
+		WithDoc(MachineConfig{}, &Doc{Fields: []Doc{{}, {Comments: [3]string{LineComment: "allowed actions"}}}}),
+	)
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`config:
+  # allowed actions
+  capabilities:
+    - reboot
+state: 1
+`, string(data))
+}
+
+func (suite *EncoderSuite) TestEmptyFields() {
+	value := &Config{Slice: []string{"a"}}
+
+	data, err := Marshal(value, WithComments(CommentsDocs), WithOmitEmpty())
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`# <<<
+slice:
+    - a
+`, string(data))
+
+	data, err = Marshal(value, WithComments(CommentsDocs), WithCommentedEmpty())
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`# integer: 0
+# <<<
+slice:
+    - a
+# complex slice
+# complex_slice: []
+# map: {}
+# some text example for map
+# omit: 0
+# custommarshaller: null
+# bytes: []
+# A nilslice field is really cool.
+# nilslice: []
+`, string(data))
+
+	defer func(doc Doc) {
+		listenerDoc = doc
+	}(listenerDoc)
+
+	listenerDoc.Fields = []Doc{{Required: true}, {}}
+
+	data, err = Marshal(&Listener{}, WithComments(CommentsDocs), WithOmitEmpty())
+	suite.Require().NoError(err)
+	suite.Assert().Equal("address: \"\"\n", string(data))
+}
+
+func (suite *EncoderSuite) TestAnchors() {
+	endpoint := &Endpoint{Host: "localhost", Port: 80}
+	value := &Config{
+		ComplexSlice: []*Endpoint{endpoint, {Host: "localhost", Port: 80}, {Host: "remote"}},
+		Map:          map[string]*Endpoint{"local": endpoint},
+	}
+
+	data, err := Marshal(value, WithComments(CommentsDocs), WithAnchors())
+	suite.Require().NoError(err)
+	suite.Assert().Equal(`integer: 0
+# <<<
+slice: []
+# complex slice
+complex_slice:
+    - &endpoint
+      host: localhost # endpoint host
+      port: 80 # custom port
+    - *endpoint
+    - host: remote # endpoint host
+map:
+    local: *endpoint
+# some text example for map
+`, string(data))
+
+	var decoded Config
+	suite.Require().NoError(yaml.Unmarshal(data, &decoded))
+	suite.Assert().Equal(value.ComplexSlice, decoded.ComplexSlice)
+	suite.Assert().Equal(value.Map, decoded.Map)
+}
+
 func (suite *EncoderSuite) TestConcurrent() {
 	value := &Machine{}
 
@@ -535,8 +908,7 @@ func (suite *EncoderSuite) TestConcurrent() {
 		go func() {
 			defer wg.Done()
 
-			encoder := NewEncoder(value)
-			_, err := encoder.Encode()
+			_, err := Marshal(value)
 			suite.Assert().NoError(err)
 		}()
 	}
@@ -544,6 +916,24 @@ func (suite *EncoderSuite) TestConcurrent() {
 	wg.Wait()
 }
 
+func (suite *EncoderSuite) TestMetrics() {
+	var events []*Event
+
+	SetMetrics(MetricsFunc(func(event *Event) {
+		events = append(events, event)
+	}))
+	defer SetMetrics(nil)
+
+	_, err := Marshal(&Machine{})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(events, 1)
+	suite.Assert().Equal(EventEncode, events[0].Kind)
+	suite.Assert().Equal(1, events[0].Structs)
+	suite.Assert().Equal(2, events[0].Fields)
+	suite.Assert().NoError(events[0].Err)
+}
+
 func decodeToMap(data []byte) (map[interface{}]interface{}, error) {
 	raw := map[interface{}]interface{}{}
 	err := yaml.Unmarshal(data, &raw)
@@ -0,0 +1,99 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explain returns the documentation of the field at the dotted path
+// formatted for terminals, with its type, description, allowed values,
+// examples and the fields of nested structs, e.g. for a `config explain`
+// command. An empty path explains the root struct.
+func (fd *FileDoc) Explain(path string) (string, error) {
+	field, err := fd.Field(path)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if path != "" {
+		fmt.Fprintf(&b, "FIELD:    %s\n", path)
+	}
+	fmt.Fprintf(&b, "TYPE:     %s\n", field.Type)
+	if field.Required {
+		b.WriteString("REQUIRED: true\n")
+	}
+	if field.Default != "" {
+		fmt.Fprintf(&b, "DEFAULT:  %s\n", field.Default)
+	}
+
+	nested := field
+	if path != "" {
+		nested = fd.Resolve(field)
+	}
+	description := strings.TrimSpace(field.Description)
+	if description == "" && nested != nil {
+		description = strings.TrimSpace(nested.Description)
+	}
+	if description != "" {
+		fmt.Fprintf(&b, "\nDESCRIPTION:\n%s\n", indent(description))
+	}
+
+	if field.KeyDoc != "" {
+		fmt.Fprintf(&b, "\nKEYS:\n%s\n", indent(field.KeyDoc))
+	}
+
+	if len(field.Values) > 0 || len(field.Enum()) > 0 {
+		b.WriteString("\nVALUES:\n")
+		for _, value := range field.Values {
+			fmt.Fprintf(&b, "  - %s\n", value)
+		}
+		for _, value := range field.Enum() {
+			if value.Description != "" {
+				fmt.Fprintf(&b, "  - %s: %s\n", value.Value, value.Description)
+			} else {
+				fmt.Fprintf(&b, "  - %s\n", value.Value)
+			}
+		}
+	}
+
+	if len(field.Examples) > 0 {
+		b.WriteString("\nEXAMPLES:\n")
+		for i, example := range field.Examples {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s\n", indent(strings.TrimRight(yamlSnippet(example.GetValue(), field.Name, example.GetName()), "\n")))
+		}
+	}
+
+	if nested != nil && len(nested.Fields) > 0 {
+		b.WriteString("\nFIELDS:\n")
+		for _, f := range nested.Fields {
+			if f.Name == "" {
+				continue
+			}
+			fmt.Fprintf(&b, "  %s <%s>\n", f.Name, f.Type)
+			if short := strings.Split(strings.TrimSpace(f.Description), "\n")[0]; short != "" {
+				fmt.Fprintf(&b, "    %s\n", short)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+// indent indents every non-empty line of the text by two spaces.
+func indent(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "  " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
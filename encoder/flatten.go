@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import "strings"
+
+// FlatField is a field reachable from the root struct of a file
+// documentation, identified by its full path.
+type FlatField struct {
+	// Path is the dotted path of the field. Lists are transparent in paths
+	// and map keys are written as `*`, so paths can be passed to Field.
+	Path string `json:"path"`
+	// Type is the documented type of the field.
+	Type string `json:"type"`
+	// Description is the description of the field.
+	Description string `json:"description,omitempty"`
+	// Required is true for fields which have to be set.
+	Required bool `json:"required,omitempty"`
+	// Default is the default value of the field as yaml.
+	Default string `json:"default,omitempty"`
+	// Values are the allowed values of the field.
+	Values []string `json:"values,omitempty"`
+}
+
+// Flatten returns every field reachable from the root struct of the file
+// documentation in document order, with its full path, e.g. to generate
+// inventories of the configuration keys.
+func (fd *FileDoc) Flatten() []FlatField {
+	var fields []FlatField
+	if root := fd.Root(); root != nil {
+		fd.flatten(root, "", map[string]bool{}, &fields)
+	}
+	return fields
+}
+
+func (fd *FileDoc) flatten(doc *Doc, path string, visiting map[string]bool, fields *[]FlatField) {
+	// recursive structs are only listed at their first level
+	if visiting[doc.Type] {
+		return
+	}
+	visiting[doc.Type] = true
+	defer delete(visiting, doc.Type)
+
+	for i := range doc.Fields {
+		field := &doc.Fields[i]
+		if field.Name == "" {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.Name)
+		*fields = append(*fields, FlatField{
+			Path:        fieldPath,
+			Type:        field.Type,
+			Description: strings.TrimSpace(field.Description),
+			Required:    field.Required,
+			Default:     field.Default,
+			Values:      field.AllowedValues(),
+		})
+
+		if nested := fd.Resolve(field); nested != nil {
+			nestedPath := fieldPath + strings.Repeat(".*", strings.Count(field.Type, "map["))
+			fd.flatten(nested, nestedPath, visiting, fields)
+		}
+	}
+}
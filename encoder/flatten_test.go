@@ -0,0 +1,37 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatten(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields[0].Required = true
+	fd.Structs[0].Fields[1].Description = "Number of workers.\n"
+	fd.Structs[0].Fields[1].Default = "25"
+	fd.Structs[0].Fields = append(fd.Structs[0].Fields, Doc{Name: "named", Type: "map[string]Step"})
+
+	fields := fd.Flatten()
+
+	paths := make([]string, len(fields))
+	for i, field := range fields {
+		paths[i] = field.Path
+	}
+	require.Equal(t, []string{"name", "workers", "steps", "steps.type", "steps.headers", "named", "named.*.type", "named.*.headers"}, paths)
+
+	require.True(t, fields[0].Required)
+	require.Equal(t, FlatField{Path: "workers", Type: "int", Description: "Number of workers.", Default: "25"}, fields[1])
+	require.Equal(t, []string{"dns", "http"}, fields[3].Values)
+
+	for _, field := range fields {
+		doc, err := fd.Field(field.Path)
+		require.NoError(t, err)
+		require.Equal(t, field.Type, doc.Type)
+	}
+}
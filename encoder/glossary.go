@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GlossaryTerm is a term defined in the glossary of the documentation.
+type GlossaryTerm struct {
+	Term       string
+	Definition string
+}
+
+// GlossaryAnchor returns the anchor of the glossary entry for the term.
+func GlossaryAnchor(term string) string {
+	return "glossary-" + strings.ReplaceAll(strings.ToLower(strings.TrimSpace(term)), " ", "-")
+}
+
+// linkGlossary links the first occurrence of every glossary term in the
+// text using the link function, leaving inline code spans untouched.
+// Longer terms are matched first so that they win over the terms they contain.
+func linkGlossary(text string, glossary []GlossaryTerm, link func(match, term string) string) string {
+	if len(glossary) == 0 || text == "" {
+		return text
+	}
+
+	terms := make([]string, 0, len(glossary))
+	for _, entry := range glossary {
+		if entry.Term != "" {
+			terms = append(terms, entry.Term)
+		}
+	}
+	sort.SliceStable(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+
+	linked := map[string]bool{}
+	parts := strings.Split(text, "`")
+	for _, term := range terms {
+		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(term) + `\b`)
+
+		// parts with odd indexes are inside code spans
+		for i := 0; i < len(parts) && !linked[term]; i += 2 {
+			for _, loc := range pattern.FindAllStringIndex(parts[i], -1) {
+				if insideLink(parts[i], loc[0]) {
+					continue
+				}
+				parts[i] = parts[i][:loc[0]] + link(parts[i][loc[0]:loc[1]], term) + parts[i][loc[1]:]
+				linked[term] = true
+				break
+			}
+		}
+	}
+	return strings.Join(parts, "`")
+}
+
+// insideLink returns true if the offset falls inside a link added before,
+// either a markdown link text or an html anchor.
+func insideLink(text string, offset int) bool {
+	before := text[:offset]
+	return strings.LastIndex(before, "[") > strings.LastIndex(before, "]") ||
+		strings.LastIndex(before, "](") > strings.LastIndex(before, ")") ||
+		strings.LastIndex(before, "<a ") > strings.LastIndex(before, "</a>")
+}
@@ -0,0 +1,106 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GraphEdge is a reference from a struct to another documented struct.
+type GraphEdge struct {
+	From string
+	To   string
+	// Label is the key of the referencing field, or the discriminator value
+	// for the structs of discriminated unions.
+	Label string
+	// Discriminated is true for edges to the structs of a discriminated union.
+	Discriminated bool
+}
+
+// Graph returns the references between the documented structs, from their
+// fields, back references and discriminators, in document order.
+func (fd *FileDoc) Graph() []GraphEdge {
+	var edges []GraphEdge
+	seen := map[GraphEdge]bool{}
+	add := func(edge GraphEdge) {
+		if !seen[edge] {
+			seen[edge] = true
+			edges = append(edges, edge)
+		}
+	}
+
+	for _, s := range fd.Structs {
+		for i := range s.Fields {
+			field := &s.Fields[i]
+			if target := fd.Resolve(field); target != nil && field.Name != "" {
+				add(GraphEdge{From: s.Type, To: target.Type, Label: field.Name})
+			}
+		}
+		if s.Discriminator != nil {
+			for _, value := range s.Discriminator.Values() {
+				add(GraphEdge{From: s.Type, To: s.Discriminator.Mapping[value], Label: s.Discriminator.Field + ": " + value, Discriminated: true})
+			}
+		}
+	}
+	for _, s := range fd.Structs {
+		for _, appearance := range s.AppearsIn {
+			if fd.Struct(appearance.TypeName) != nil {
+				add(GraphEdge{From: appearance.TypeName, To: s.Type, Label: appearance.FieldName})
+			}
+		}
+	}
+	return edges
+}
+
+// DOT returns the struct references as a Graphviz DOT digraph. Edges to
+// the structs of discriminated unions are dashed.
+func (fd *FileDoc) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote(fd.Name))
+	b.WriteString("  node [shape=box];\n")
+	for _, s := range fd.Structs {
+		fmt.Fprintf(&b, "  %s;\n", strconv.Quote(s.Type))
+	}
+	for _, edge := range fd.Graph() {
+		style := ""
+		if edge.Discriminated {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), strconv.Quote(edge.Label), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid returns the struct references as a Mermaid flowchart. Edges to
+// the structs of discriminated unions are dotted.
+func (fd *FileDoc) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, s := range fd.Structs {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", mermaidID(s.Type), s.Type)
+	}
+	for _, edge := range fd.Graph() {
+		arrow := "-->"
+		if edge.Discriminated {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|\"%s\"| %s\n", mermaidID(edge.From), arrow, strings.ReplaceAll(edge.Label, `"`, "#quot;"), mermaidID(edge.To))
+	}
+	return b.String()
+}
+
+// mermaidID returns the type name with the characters not allowed in
+// Mermaid node ids, such as the dot of qualified names, replaced.
+func mermaidID(typ string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, typ)
+}
@@ -0,0 +1,43 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[1].AppearsIn = []Appearance{{TypeName: "Job", FieldName: "steps"}, {TypeName: "Pipeline", FieldName: "steps"}}
+	fd.Structs = append(fd.Structs, &Doc{
+		Type:          "Request",
+		Discriminator: &Discriminator{Field: "type", Mapping: map[string]string{"step": "Step"}},
+	})
+
+	require.Equal(t, []GraphEdge{
+		{From: "Job", To: "Step", Label: "steps"},
+		{From: "Request", To: "Step", Label: "type: step", Discriminated: true},
+	}, fd.Graph())
+
+	require.Equal(t, `digraph "Job" {
+  node [shape=box];
+  "Job";
+  "Step";
+  "Request";
+  "Job" -> "Step" [label="steps"];
+  "Request" -> "Step" [label="type: step", style=dashed];
+}
+`, fd.DOT())
+
+	require.Equal(t, `flowchart LR
+  Job["Job"]
+  Step["Step"]
+  Request["Request"]
+  Job -->|"steps"| Step
+  Request -.->|"type: step"| Step
+`, fd.Mermaid())
+}
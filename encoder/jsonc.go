@@ -0,0 +1,130 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// jsonIndent is the default indentation of the JSONC output.
+const jsonIndent = 2
+
+// MarshalJSONC encodes the value as JSON with `//` comments (JSONC), as
+// understood by VS Code, documenting the fields as Marshal does for yaml.
+// Struct fields are named after their json tag, falling back to the yaml
+// tag. The comments level, wrapping, indentation and redaction options are
+// honored.
+func MarshalJSONC(value interface{}, opts ...Option) ([]byte, error) {
+	o := newOptions(opts...)
+
+	indent := jsonIndent
+	if o.Indent > 0 {
+		indent = o.Indent
+	}
+
+	w := &jsoncWriter{indent: strings.Repeat(" ", indent)}
+
+	if o.Comments.enabled(CommentsDocs) {
+		if doc := o.getDoc(value); doc != nil {
+			w.comment(joinComments(doc.Comments[HeadComment], docComment(doc, o.Comments)), 0)
+		}
+	}
+
+	if err := w.value(plainValue(reflect.ValueOf(value), DialectJSON, o), 0); err != nil {
+		return nil, err
+	}
+	w.buf.WriteByte('\n')
+
+	return w.buf.Bytes(), nil
+}
+
+// jsoncWriter writes values as indented JSON with comments.
+type jsoncWriter struct {
+	buf    bytes.Buffer
+	indent string
+}
+
+// value writes the value at the depth, starting on the current line.
+func (w *jsoncWriter) value(value interface{}, depth int) error {
+	switch v := value.(type) {
+	case orderedMap:
+		if len(v) == 0 {
+			w.buf.WriteString("{}")
+			return nil
+		}
+		w.buf.WriteString("{\n")
+		for i, item := range v {
+			w.comment(item.Comment, depth+1)
+			w.line(depth + 1)
+
+			key, err := json.Marshal(item.Key)
+			if err != nil {
+				return err
+			}
+			w.buf.Write(key)
+			w.buf.WriteString(": ")
+
+			if err := w.value(item.Value, depth+1); err != nil {
+				return err
+			}
+			w.separator(i, len(v))
+		}
+		w.line(depth)
+		w.buf.WriteByte('}')
+	case []interface{}:
+		if len(v) == 0 {
+			w.buf.WriteString("[]")
+			return nil
+		}
+		w.buf.WriteString("[\n")
+		for i, item := range v {
+			w.line(depth + 1)
+			if err := w.value(item, depth+1); err != nil {
+				return err
+			}
+			w.separator(i, len(v))
+		}
+		w.line(depth)
+		w.buf.WriteByte(']')
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.buf.Write(data)
+	}
+
+	return nil
+}
+
+// comment writes the lines of the comment, if any, at the depth.
+func (w *jsoncWriter) comment(comment string, depth int) {
+	if comment == "" {
+		return
+	}
+
+	for _, line := range strings.Split(comment, "\n") {
+		w.line(depth)
+		// paragraph breaks of wrapped comments are written as "#"
+		line = strings.TrimPrefix(line, "#")
+		w.buf.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+}
+
+// line indents the current line at the depth.
+func (w *jsoncWriter) line(depth int) {
+	w.buf.WriteString(strings.Repeat(w.indent, depth))
+}
+
+// separator ends the i-th of n items of an object or array.
+func (w *jsoncWriter) separator(i, n int) {
+	if i < n-1 {
+		w.buf.WriteByte(',')
+	}
+	w.buf.WriteByte('\n')
+}
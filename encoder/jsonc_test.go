@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalJSONC(t *testing.T) {
+	value := &Config{
+		Integer:      3,
+		Slice:        []string{"a"},
+		ComplexSlice: []*Endpoint{{Host: "h", Port: 80}},
+		Map:          map[string]*Endpoint{"x": {Host: "y"}},
+	}
+
+	data, err := MarshalJSONC(value)
+	require.NoError(t, err)
+	require.Equal(t, `// test configuration
+{
+  "integer": 3,
+  // <<<
+  "slice": [
+    "a"
+  ],
+  // complex slice
+  "complex_slice": [
+    {
+      // endpoint host
+      "host": "h",
+      // custom port
+      "port": 80
+    }
+  ],
+  "map": {
+    "x": {
+      // endpoint host
+      "host": "y"
+    }
+  }
+}
+`, string(data))
+
+	data, err = MarshalJSONC(&Listener{Address: ":80"}, WithCommentWrap(30), WithIndent(4))
+	require.NoError(t, err)
+	require.Equal(t, `{
+    // Address to listen on, such
+    // as :8080.
+    "address": ":80",
+    // served hosts
+    "hosts": null
+}
+`, string(data))
+
+	data, err = MarshalJSONC(&dialectConfig{Name: "test", Backends: []dialectBackend{}}, WithComments(CommentsNone))
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"name\": \"test\",\n  \"ratio\": 0,\n  \"backend_list\": []\n}\n", string(data))
+}
@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"reflect"
+	"strings"
+)
+
+// KeyTags are the struct tags naming the keys of the fields, by priority:
+// the yaml tag, then the mapstructure tag of configs decoded by Viper.
+var KeyTags = []string{"yaml", "mapstructure"}
+
+// KeyTag returns the value of the first key tag of the field which is set.
+// It is shared with docgen so that documented keys and encoded keys agree.
+func KeyTag(tag reflect.StructTag) (string, bool) {
+	for _, key := range KeyTags {
+		if value, ok := tag.Lookup(key); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// fieldKey returns the key and the options of the first key tag of the
+// field, the key being empty when no tag names it.
+func fieldKey(field reflect.StructField) (string, []string) {
+	tag, _ := KeyTag(field.Tag)
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+// isInline returns true if the options inline the fields of the struct
+// into its parent, as with the yaml inline and the mapstructure squash
+// options.
+func isInline(options []string) bool {
+	for _, option := range options {
+		if option == "inline" || option == "squash" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ViperBase struct {
+	LogLevel string `mapstructure:"log_level"`
+}
+
+type viperConfig struct {
+	ViperBase `mapstructure:",squash"`
+	Listen    *viperListen `mapstructure:"listen_address"`
+}
+
+type viperListen struct {
+	Port int `mapstructure:"port"`
+}
+
+func (ViperBase) Doc() *Doc {
+	return &Doc{Fields: []Doc{{Name: "log_level", Default: "info"}}}
+}
+
+func TestKeyTag(t *testing.T) {
+	value, ok := KeyTag(`mapstructure:"log_level" json:"logLevel"`)
+	require.True(t, ok)
+	require.Equal(t, "log_level", value)
+
+	value, ok = KeyTag(`yaml:"level" mapstructure:"log_level"`)
+	require.True(t, ok)
+	require.Equal(t, "level", value)
+
+	_, ok = KeyTag(`json:"level"`)
+	require.False(t, ok)
+}
+
+func TestMapstructureKeys(t *testing.T) {
+	config := &viperConfig{Listen: &viperListen{Port: 8080}}
+
+	data, err := Marshal(config, WithComments(CommentsDocs))
+	require.NoError(t, err)
+	require.Equal(t, "log_level: \"\"\nlisten_address:\n    port: 8080\n", string(data))
+
+	require.NoError(t, ApplyDefaults(config, nil))
+	require.Equal(t, "info", config.LogLevel)
+
+	doc := DocFromType(reflect.TypeOf(viperConfig{}))
+	require.NotNil(t, doc.FieldByName("log_level"))
+	require.NotNil(t, doc.FieldByName("listen_address"))
+
+	require.Equal(t, reflect.TypeOf(viperListen{}), fieldType(reflect.TypeOf(viperConfig{}), "listen_address"))
+	require.Equal(t, reflect.TypeOf(""), fieldType(reflect.TypeOf(viperConfig{}), "log_level"))
+}
@@ -0,0 +1,60 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// LanguageServerHeader returns the modeline comment associating a yaml
+// document with the schema at the url in the yaml language server.
+func LanguageServerHeader(url string) string {
+	return "# yaml-language-server: $schema=" + url
+}
+
+// LanguageServerSchema returns the JSON Schema of the file documentation
+// annotated for editors. Every struct and field carries a
+// `markdownDescription` used by the yaml language server for hovers and
+// completions, and an `x-intellij-html-description` for JetBrains IDEs.
+func (fd *FileDoc) LanguageServerSchema() *Schema {
+	schema := fd.JSONSchema()
+
+	for _, s := range fd.Structs {
+		definition := schema.Definitions[s.Type]
+		annotateSchema(definition, s, "")
+
+		for i := range s.Fields {
+			field := &s.Fields[i]
+			if property, ok := definition.Properties[field.Name]; ok {
+				annotateSchema(property, field, field.Name)
+			}
+		}
+	}
+	return schema
+}
+
+func annotateSchema(schema *Schema, doc *Doc, name string) {
+	markdown := strings.TrimSpace(doc.Description)
+	if doc.DocsURL != "" {
+		markdown += fmt.Sprintf("\n\n[Learn more](%s)", doc.DocsURL)
+	}
+	for _, example := range doc.Examples {
+		markdown += fmt.Sprintf("\n\n```yaml\n%s\n```", strings.TrimRight(yamlSnippet(example.GetValue(), name, example.GetName()), "\n"))
+	}
+	schema.MarkdownDescription = strings.TrimSpace(markdown)
+
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(doc.Description), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, "<p>"+html.EscapeString(paragraph)+"</p>")
+		}
+	}
+	if doc.DocsURL != "" {
+		paragraphs = append(paragraphs, fmt.Sprintf(`<p><a href="%s">Learn more</a></p>`, html.EscapeString(doc.DocsURL)))
+	}
+	schema.HTMLDescription = strings.Join(paragraphs, "")
+}
@@ -0,0 +1,189 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Severity is the severity of a lint issue.
+type Severity string
+
+const (
+	// SeverityError is used for documents which do not load.
+	SeverityError Severity = "error"
+	// SeverityWarning is used for documents which load but do not follow
+	// best practices.
+	SeverityWarning Severity = "warning"
+)
+
+// LintIssue is a problem reported by the Linter.
+type LintIssue struct {
+	ValidationError
+	// Rule is the name of the rule reporting the issue.
+	Rule string `json:"rule"`
+	// Severity is the severity of the issue.
+	Severity Severity `json:"severity"`
+}
+
+// String returns the issue formatted for terminal output.
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s [%s]", i.Severity, i.Error(), i.Rule)
+}
+
+// LintRule is a best practice rule run on the root node of a document.
+type LintRule func(fd *FileDoc, root *yaml.Node) []LintIssue
+
+// LinterOptions configures the Linter.
+type LinterOptions struct {
+	// Rules are the best practice rules run in addition to validation.
+	Rules []LintRule
+	// AllowDeprecated disables the warnings for deprecated fields.
+	AllowDeprecated bool
+	// AllowExperimental disables the warnings for fields referencing
+	// experimental structs.
+	AllowExperimental bool
+}
+
+// Linter lints yaml documents against the file documentation, bundling
+// validation with suggestions for unknown keys, deprecation warnings and
+// best practice rules, for tools exposing a lint command for their configs.
+type Linter struct {
+	fd      *FileDoc
+	options *LinterOptions
+}
+
+// NewLinter returns a new linter for documents of the file documentation.
+func NewLinter(fd *FileDoc, opts *LinterOptions) *Linter {
+	if opts == nil {
+		opts = &LinterOptions{}
+	}
+	return &Linter{fd: fd, options: opts}
+}
+
+// Lint returns the issues of the document sorted by position.
+func (l *Linter) Lint(data []byte) []LintIssue {
+	var issues []LintIssue
+	for _, err := range Validate(data, l.fd) {
+		issues = append(issues, LintIssue{ValidationError: err, Rule: "validate", Severity: SeverityError})
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil || len(node.Content) == 0 {
+		return issues
+	}
+	root := node.Content[0]
+
+	if doc := l.fd.Root(); doc != nil {
+		issues = append(issues, l.fieldWarnings(root, doc, "")...)
+	}
+	for _, rule := range l.options.Rules {
+		issues = append(issues, rule(l.fd, root)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+	return issues
+}
+
+// fieldWarnings reports the keys of fields documented as deprecated or
+// referencing experimental structs.
+func (l *Linter) fieldWarnings(node *yaml.Node, doc *Doc, path string) []LintIssue {
+	var issues []LintIssue
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			issues = append(issues, l.fieldWarnings(item, doc, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field := doc.FieldByName(key.Value)
+			if field == nil {
+				continue
+			}
+			fieldPath := joinPath(path, key.Value)
+
+			if notice, ok := Deprecation(field.Description); ok && !l.options.AllowDeprecated {
+				message := fmt.Sprintf("%s is deprecated", key.Value)
+				if notice != "" {
+					message += ": " + notice
+				}
+				issues = append(issues, LintIssue{
+					ValidationError: ValidationError{Path: fieldPath, Line: key.Line, Column: key.Column, Message: message},
+					Rule:            "deprecated",
+					Severity:        SeverityWarning,
+				})
+			}
+			if l.fd.typeStability(field.Type) == StabilityExperimental && !l.options.AllowExperimental {
+				issues = append(issues, LintIssue{
+					ValidationError: ValidationError{Path: fieldPath, Line: key.Line, Column: key.Column, Message: fmt.Sprintf("%s is experimental and may change or be removed", key.Value)},
+					Rule:            "experimental",
+					Severity:        SeverityWarning,
+				})
+			}
+			if nested := l.fd.Resolve(field); nested != nil {
+				issues = append(issues, l.fieldWarnings(value, nested, fieldPath)...)
+			}
+		}
+	}
+	return issues
+}
+
+// Deprecation returns the deprecation notice of a description following the
+// go convention of a paragraph starting with `Deprecated:`.
+func Deprecation(description string) (string, bool) {
+	for _, paragraph := range strings.Split(description, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if strings.HasPrefix(paragraph, "Deprecated:") {
+			return strings.TrimSpace(strings.TrimPrefix(paragraph, "Deprecated:")), true
+		}
+	}
+	return "", false
+}
+
+// RequireFields returns a rule reporting missing keys at the dotted paths,
+// e.g. `info.author`, for metadata which is optional to load a document but
+// expected by convention.
+func RequireFields(paths ...string) LintRule {
+	return func(fd *FileDoc, root *yaml.Node) []LintIssue {
+		var issues []LintIssue
+		for _, path := range paths {
+			node := root
+			for _, key := range strings.Split(path, ".") {
+				parent := node
+				if node = mappingValue(node, key); node == nil {
+					issues = append(issues, LintIssue{
+						ValidationError: ValidationError{Path: path, Line: parent.Line, Column: parent.Column, Message: fmt.Sprintf("missing %s", path)},
+						Rule:            "require-fields",
+						Severity:        SeverityWarning,
+					})
+					break
+				}
+			}
+		}
+		return issues
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
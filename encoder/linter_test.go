@@ -0,0 +1,41 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinter(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[1].Fields[1].Description = "Headers of the request.\n\nDeprecated: use the request template instead."
+
+	linter := NewLinter(fd, &LinterOptions{
+		Rules: []LintRule{RequireFields("name")},
+	})
+
+	issues := linter.Lint([]byte("workers: 1\nsteps:\n  - typ: dns\n    headers:\n      a: b\n"))
+	require.Len(t, issues, 3)
+	require.Equal(t, "warning: 1:1: name: missing name [require-fields]", issues[0].String())
+	require.Equal(t, `error: 3:5: steps[0].typ: unknown field "typ" in Step, did you mean "type"? [validate]`, issues[1].String())
+	require.Equal(t, "warning: 4:5: steps[0].headers: headers is deprecated: use the request template instead. [deprecated]", issues[2].String())
+
+	linter = NewLinter(fd, &LinterOptions{AllowDeprecated: true})
+	require.Len(t, linter.Lint([]byte("name: a\nsteps:\n  - headers: {}\n")), 0)
+}
+
+func TestLinterExperimental(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[1].Stability = StabilityExperimental
+	data := []byte("name: a\nsteps:\n  - type: dns\n")
+
+	issues := NewLinter(fd, nil).Lint(data)
+	require.Len(t, issues, 1)
+	require.Equal(t, "warning: 2:1: steps: steps is experimental and may change or be removed [experimental]", issues[0].String())
+
+	require.Empty(t, NewLinter(fd, &LinterOptions{AllowExperimental: true}).Lint(data))
+}
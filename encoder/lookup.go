@@ -0,0 +1,184 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Root returns the documentation of the root struct of the file, which is
+// the first documented struct.
+func (fd *FileDoc) Root() *Doc {
+	if len(fd.Structs) == 0 {
+		return nil
+	}
+	return fd.Structs[0]
+}
+
+// Struct returns the documentation of the struct with the provided type name.
+func (fd *FileDoc) Struct(typeName string) *Doc {
+	for _, s := range fd.Structs {
+		if s.Type == typeName {
+			return s
+		}
+	}
+	return nil
+}
+
+// Resolve returns the documentation of the struct referenced by the field
+// type, looking through slices and maps. Nil is returned for fields which
+// do not reference a documented struct.
+func (fd *FileDoc) Resolve(field *Doc) *Doc {
+	if field == nil {
+		return nil
+	}
+	return fd.Struct(ElemType(field.Type))
+}
+
+// Field returns the documentation of the field at the dotted path starting
+// at the root struct, e.g. `requests.matchers.type`, following the types of
+// the fields to the nested structs. Indexes and map keys in the path, as in
+// the paths of validation errors, are skipped. An empty path returns the
+// root struct itself.
+func (fd *FileDoc) Field(path string) (*Doc, error) {
+	current := fd.Root()
+	if current == nil {
+		return nil, fmt.Errorf("no documented structs")
+	}
+	if path == "" {
+		return current, nil
+	}
+
+	parts := strings.Split(path, ".")
+	for i := 0; i < len(parts); i++ {
+		part := parts[i]
+		if index := strings.IndexByte(part, '['); index >= 0 {
+			part = part[:index]
+		}
+
+		field := current.FieldByName(part)
+		if field == nil {
+			return nil, fmt.Errorf("unknown field %q in %s", part, current.Type)
+		}
+
+		// skip the keys of map fields
+		i += strings.Count(field.Type, "map[")
+		if i >= len(parts)-1 {
+			return field, nil
+		}
+		if current = fd.Resolve(field); current == nil {
+			return nil, fmt.Errorf("field %q of type %s has no nested fields", part, field.Type)
+		}
+	}
+	return nil, fmt.Errorf("unknown path %q", path)
+}
+
+// FieldByName returns the documentation of the field with the provided
+// yaml key name.
+func (d *Doc) FieldByName(name string) *Doc {
+	for i := range d.Fields {
+		if d.Fields[i].Name == name {
+			return &d.Fields[i]
+		}
+	}
+	return nil
+}
+
+// StructPaths returns the dotted paths at which the values of every struct
+// reachable from the root struct appear, with [] marking the items of lists
+// and * the values of maps, e.g. http[] for the structs of the list of the
+// http field. The root struct is at the empty path. Structs reachable in
+// several ways are at their shortest path.
+func (fd *FileDoc) StructPaths() map[string]string {
+	root := fd.Root()
+	if root == nil {
+		return nil
+	}
+
+	paths := map[string]string{root.Type: ""}
+	queue := []*Doc{root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for i := range current.Fields {
+			field := &current.Fields[i]
+			nested := fd.Resolve(field)
+			if nested == nil {
+				continue
+			}
+			if _, ok := paths[nested.Type]; ok {
+				continue
+			}
+			paths[nested.Type] = joinPath(paths[current.Type], field.Name) + pathSuffix(field.Type)
+			queue = append(queue, nested)
+		}
+	}
+	return paths
+}
+
+// SetAppearancePaths sets the path of every appearance of the structs
+// whose parent struct is reachable from the root struct.
+func (fd *FileDoc) SetAppearancePaths() {
+	paths := fd.StructPaths()
+	for _, s := range fd.Structs {
+		for i := range s.AppearsIn {
+			appearance := &s.AppearsIn[i]
+			if parent, ok := paths[appearance.TypeName]; ok {
+				appearance.Path = joinPath(parent, appearance.FieldName)
+			}
+		}
+	}
+}
+
+// pathSuffix returns the suffix of the path of a field of the type marking
+// its list items and map values.
+func pathSuffix(t string) string {
+	var suffix string
+	for {
+		switch {
+		case strings.HasPrefix(t, "[]"):
+			suffix += "[]"
+			t = t[2:]
+		case strings.HasPrefix(t, "map["):
+			suffix += ".*"
+			t = t[mapKeyEnd(t)+1:]
+		default:
+			return suffix
+		}
+	}
+}
+
+// ElemType returns the element type of a documented field type, stripping
+// any slice and map qualifiers, e.g. `Request` for `map[string][]Request`.
+func ElemType(t string) string {
+	for {
+		switch {
+		case strings.HasPrefix(t, "[]"):
+			t = t[2:]
+		case strings.HasPrefix(t, "map["):
+			t = t[mapKeyEnd(t)+1:]
+		default:
+			return t
+		}
+	}
+}
+
+// mapKeyEnd returns the index of the bracket closing the key of a map type.
+func mapKeyEnd(t string) int {
+	depth := 0
+	for i, c := range t {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(t) - 1
+}
@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileDocField(t *testing.T) {
+	fd := testFileDoc()
+
+	root, err := fd.Field("")
+	require.NoError(t, err)
+	require.Equal(t, "Job", root.Type)
+
+	field, err := fd.Field("steps.type")
+	require.NoError(t, err)
+	require.Equal(t, []string{"dns", "http"}, field.Values)
+
+	field, err = fd.Field("steps[0].headers.accept")
+	require.NoError(t, err)
+	require.Equal(t, "headers", field.Name)
+
+	_, err = fd.Field("steps.kind")
+	require.EqualError(t, err, `unknown field "kind" in Step`)
+
+	_, err = fd.Field("name.first")
+	require.EqualError(t, err, `field "name" of type string has no nested fields`)
+}
+
+func TestStructPaths(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields = append(fd.Structs[0].Fields, Doc{Name: "matrix", Type: "map[string][]Step"})
+	fd.Structs[1].AppearsIn = []Appearance{
+		{TypeName: "Job", FieldName: "steps", Kind: ReferenceList},
+		{TypeName: "Job", FieldName: "matrix", Kind: ReferenceMap},
+		{TypeName: "Pipeline", FieldName: "steps", Kind: ReferenceList},
+	}
+
+	require.Equal(t, map[string]string{"Job": "", "Step": "steps[]"}, fd.StructPaths())
+
+	fd.SetAppearancePaths()
+	require.Equal(t, "steps", fd.Structs[1].AppearsIn[0].Path)
+	require.Equal(t, "matrix", fd.Structs[1].AppearsIn[1].Path)
+	require.Empty(t, fd.Structs[1].AppearsIn[2].Path)
+	require.Equal(t, "a list", fd.Structs[1].AppearsIn[0].Cardinality())
+
+	require.Equal(t, ".*[]", pathSuffix("map[string][]Step"))
+	require.Equal(t, ReferencePointer, ReferenceKind("Step", true))
+	require.Equal(t, ReferenceList, ReferenceKind("[]Step", true))
+}
+
+func TestFileDocExplain(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields[2].Description = "Steps of the job."
+	fd.Structs[1].Fields[0].Description = "Protocol of the step.\nDetails."
+	fd.Structs[1].Fields[0].Required = true
+	fd.Structs[1].Fields[0].EnumValues = []EnumValue{{Value: "tcp", Description: "Raw TCP connection."}, {Value: "udp"}}
+
+	text, err := fd.Explain("steps")
+	require.NoError(t, err)
+	require.Equal(t, `FIELD:    steps
+TYPE:     []Step
+
+DESCRIPTION:
+  Steps of the job.
+
+FIELDS:
+  type <string>
+    Protocol of the step.
+  headers <map[string]string>
+`, text)
+
+	text, err = fd.Explain("steps.type")
+	require.NoError(t, err)
+	require.Equal(t, `FIELD:    steps.type
+TYPE:     string
+REQUIRED: true
+
+DESCRIPTION:
+  Protocol of the step.
+  Details.
+
+VALUES:
+  - dns
+  - http
+  - tcp: Raw TCP connection.
+  - udp
+`, text)
+
+	_, err = fd.Explain("steps.kind")
+	require.Error(t, err)
+}
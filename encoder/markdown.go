@@ -12,6 +12,7 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	yaml "gopkg.in/yaml.v3"
 )
@@ -21,29 +22,62 @@ var markdownTemplate = `
 Examples:
 
 {{ range $example := .Examples }}
-{{ yaml $example.GetValue $.Name $example.GetName }}
+{{ example $example.GetValue (key $) $example.GetName }}
+{{- range $dialect := dialects }}
+
+{{ encodeDialect $example.GetValue (dialectKey $ $dialect) $example.GetName $dialect }}
+{{- end }}
 {{ end }}
 {{ end }}
 
+{{- define "fieldTable" }}
+| Field | Type | Description |
+|-------|------|-------------|
+{{ range $field := .Fields -}}
+| <code>{{ key $field }}</code> | {{ encodeType $field.Type }} | {{ tableCell $field }} |
+{{ end -}}
+{{ end }}
+
 {{ .Description }}
 {{- $anchors := .Anchors -}}
 {{- $tick := "` + "`" + `" -}}
 {{ range $struct := .Structs }}
-## {{ $struct.Type }}
+{{ heading }} {{ $struct.Type }}{{ headingID $struct.Type }}
+{{ with stabilityBanner $struct.Stability -}}
+> **{{ stabilityTitle $struct.Stability }}:** {{ . }}
+
+{{ end -}}
 {{ if $struct.Description -}}
-{{ $struct.Description }}
+{{ glossary $struct.Description }}
+{{ end }}
+{{- if $struct.DocsURL }}
+[Learn more]({{ $struct.DocsURL }})
+{{ end }}
+{{- if $struct.Diagram }}
+{{ diagram $struct.Diagram }}
 {{ end }}
 {{ if $struct.AppearsIn -}}
 Appears in:
 
 {{ range $appearance := $struct.AppearsIn }}
-- <code>{{ encodeType $appearance.TypeName }}.{{ $appearance.FieldName }}</code>
+- <code>{{ encodeType $appearance.TypeName }}.{{ $appearance.FieldName }}</code>{{ with $appearance.Cardinality }} as {{ . }}{{ end }}{{ with $appearance.Path }} under <code>{{ . }}</code>{{ end }}
+{{ end -}}
+{{ end }}
+{{ with $struct.Discriminator -}}
+The fields depend on the value of <code>{{ .Field }}</code>:
+
+{{ range $value := .Values }}
+- <code>{{ $value }}</code>: {{ encodeType (index $struct.Discriminator.Mapping $value) }}
 {{ end -}}
 {{ end }}
 {{ if $struct.Examples -}}
 
 {{ range $example := $struct.Examples }}
-{{ yaml $example.GetValue "" $example.GetName }}
+{{ example $example.GetValue "" $example.GetName }}
+{{- range $dialect := dialects }}
+
+{{ encodeDialect $example.GetValue "" $example.GetName $dialect }}
+{{- end }}
 {{- end -}}
 {{ end }}
 
@@ -56,18 +90,40 @@ Part Definitions:
 {{ end }}
 
 {{ if $struct.Fields -}}
+{{ if tableLayout -}}
+{{ template "fieldTable" $struct }}
+
+{{ else -}}
 
 <hr />
 
 {{ range $field := $struct.Fields -}}
 <div class="dd">
 
-<code>{{ $field.Name }}</code>  <i>{{ encodeType $field.Type }}</i>
+<code>{{ key $field }}</code>  <i>{{ encodeType $field.Type }}</i>
+{{- range $dialect := dialects }}{{ if ne (dialectKey $field $dialect) (key $field) }}  <code>{{ $dialect }}: {{ dialectKey $field $dialect }}</code>{{ end }}{{ end }}
 
 </div>
 <div class="dt">
 
-{{ $field.Description }}
+{{ with typeStability $field.Type -}}
+> **{{ stabilityTitle . }}:** {{ stabilityBanner . }}
+
+{{ end -}}
+{{ glossary $field.Description }}
+{{- if $field.DocsURL }} [Learn more]({{ $field.DocsURL }}){{ end }}
+
+{{ if $field.Default }}
+Default value: <code>{{ $field.Default }}</code>
+{{ end -}}
+
+{{ with itemConstraints $field }}
+Items: {{ . }}
+{{ end -}}
+
+{{ with $field.KeyDoc }}
+Keys: {{ . }}
+{{ end -}}
 
 {{ if $field.Values }}
 Valid values:
@@ -77,11 +133,11 @@ Valid values:
 {{ end -}}
 {{ end -}}
 
-{{ if $field.EnumFields }}
+{{ if $field.Enum }}
 Enum Values:
 
-{{ range $value := $field.EnumFields }}
-  - <code>{{ $value }}</code>
+{{ range $value := $field.Enum }}
+  - <code>{{ $value.Value }}</code>{{ with $value.Description }} - {{ . }}{{ end }}
 {{ end -}}
 {{ end -}}
 
@@ -94,13 +150,25 @@ Enum Values:
 {{ template "fieldExamples" $field }}
 {{ end -}}
 
+{{- if $field.BadExamples }}
+Common mistakes:
+
+{{ range $bad := $field.BadExamples }}
+{{ with $bad.Name }}**{{ . }}**{{ if $bad.Reason }}: {{ end }}{{ end }}{{ $bad.Reason }}
+
+` + "```yaml" + `
+{{ badExample $field.Name $bad }}
+` + "```" + `
+{{ end }}
+{{ end -}}
+
 </div>
 
 <hr />
 
 {{ end }}
 
-{{ end -}}
+{{ end }}{{ end -}}
 
 {{ if $struct.Values -}}
 
@@ -110,6 +178,12 @@ Enum Values:
 - {{ $tick }}{{ $value }}{{ $tick }}
 {{ end -}}
 {{- end }}
+{{ end }}
+{{- if .Glossary }}
+{{ heading }} Glossary
+{{ range $entry := .Glossary }}
+- <a id="{{ glossaryAnchor $entry.Term }}"></a>**{{ $entry.Term }}** - {{ $entry.Definition }}
+{{- end }}
 {{ end }}`
 
 // FileDoc represents a single go file documentation.
@@ -120,25 +194,162 @@ type FileDoc struct {
 	Description string
 	// Structs structs defined in the file.
 	Structs []*Doc
-	Anchors map[string]string
+	// Dialects are rendered alongside YAML for the keys and examples.
+	Dialects []Dialect
+	// Glossary contains the terms linked from descriptions.
+	Glossary []GlossaryTerm
+	Anchors  map[string]string
 
 	t *template.Template
 }
 
+// MarkdownLayout is the layout of the struct fields in the markdown output.
+type MarkdownLayout int
+
+const (
+	// LayoutDefinitionList renders every field as a definition with its
+	// examples.
+	LayoutDefinitionList MarkdownLayout = iota
+	// LayoutTable renders the fields of a struct as a single table, without
+	// the field examples.
+	LayoutTable
+)
+
+// AnchorStyle is the way struct headings are anchored and linked in the
+// markdown output.
+type AnchorStyle int
+
+const (
+	// AnchorLowercase links types to the lowercase type name, matching the
+	// heading ids generated by GitHub and most markdown renderers.
+	AnchorLowercase AnchorStyle = iota
+	// AnchorHeadingID sets the lowercase type name as explicit heading id
+	// with the {#id} attribute syntax of Hugo, Pandoc and MkDocs.
+	AnchorHeadingID
+	// AnchorNone does not link types, e.g. when printing to a terminal.
+	AnchorNone
+)
+
+// MarkdownOptions configures the markdown output of the file documentation.
+type MarkdownOptions struct {
+	// HeadingLevel is the level of the struct headings, 2 by default.
+	HeadingLevel int
+	// Layout is the layout of the struct fields.
+	Layout MarkdownLayout
+	// AnchorStyle is the way struct headings are anchored and linked.
+	AnchorStyle AnchorStyle
+	// Template replaces the built-in template. It is executed with the
+	// FileDoc and can use the "fieldExamples" and "fieldTable" templates.
+	Template string
+	// Dialect is the configuration language of the field keys and
+	// examples, YAML by default. The other dialects of the FileDoc are
+	// rendered alongside it.
+	Dialect Dialect
+}
+
 // Encode encodes file documentation as MD file.
 func (fd *FileDoc) Encode() ([]byte, error) {
+	return fd.ToMarkdown(nil)
+}
+
+// ToMarkdown renders the file documentation as markdown configured by the
+// options, e.g. to print the configuration reference from a command.
+func (fd *FileDoc) ToMarkdown(options *MarkdownOptions) ([]byte, error) {
+	if options == nil {
+		options = &MarkdownOptions{}
+	}
+
+	start := time.Now()
+
+	data, err := fd.encode(options)
+
+	event := &Event{
+		Kind:     EventMarkdown,
+		Name:     fd.Name,
+		Duration: time.Since(start),
+		Structs:  len(fd.Structs),
+		Err:      err,
+	}
+	for _, s := range fd.Structs {
+		event.Fields += len(s.Fields)
+	}
+	Observe(event)
+
+	return data, err
+}
+
+func (fd *FileDoc) encode(options *MarkdownOptions) ([]byte, error) {
 	anchors := map[string]string{}
-	for _, t := range fd.Structs {
-		anchors[t.Type] = strings.ToLower(t.Type)
+	if options.AnchorStyle != AnchorNone {
+		for _, t := range fd.Structs {
+			anchors[t.Type] = strings.ToLower(t.Type)
+		}
 	}
 	fd.Anchors = anchors
 
-	fd.t = template.Must(template.New("file_markdown.tpl").
+	primary := options.Dialect
+	if primary == "" {
+		primary = DialectYAML
+	}
+
+	level := options.HeadingLevel
+	if level <= 0 {
+		level = 2
+	}
+
+	t, err := template.New("file_markdown.tpl").
 		Funcs(template.FuncMap{
-			"yaml":       encodeYaml,
-			"encodeType": fd.encodeType,
+			"yaml":            encodeYaml,
+			"encodeType":      fd.encodeType,
+			"encodeDialect":   encodeDialect,
+			"dialectKey":      dialectKey,
+			"diagram":         encodeDiagram,
+			"glossary":        fd.glossaryMarkdown,
+			"glossaryAnchor":  GlossaryAnchor,
+			"typeStability":   fd.typeStability,
+			"itemConstraints": itemConstraints,
+			"badExample":      badExampleSnippet,
+			"stabilityTitle":  stabilityTitle,
+			"stabilityBanner": stabilityBanner,
+			"dialects": func() []Dialect {
+				var dialects []Dialect
+				for _, dialect := range fd.Dialects {
+					if dialect != primary {
+						dialects = append(dialects, dialect)
+					}
+				}
+				return dialects
+			},
+			"key": func(field Doc) string {
+				return field.Key(primary)
+			},
+			"example": func(in interface{}, name, description string) string {
+				return encodeExample(in, name, description, primary)
+			},
+			"heading": func() string {
+				return strings.Repeat("#", level)
+			},
+			"headingID": func(typ string) string {
+				if options.AnchorStyle != AnchorHeadingID {
+					return ""
+				}
+				return " {#" + strings.ToLower(typ) + "}"
+			},
+			"tableLayout": func() bool {
+				return options.Layout == LayoutTable
+			},
+			"tableCell": tableCell,
 		}).
-		Parse(markdownTemplate))
+		Parse(markdownTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if options.Template != "" {
+		if t, err = t.New("custom").Parse(options.Template); err != nil {
+			return nil, err
+		}
+	}
+	fd.t = t
 
 	buf := bytes.Buffer{}
 
@@ -194,6 +405,12 @@ func (fd *FileDoc) encodeType(t string) string {
 }
 
 func encodeYaml(in interface{}, name string, description string) string {
+	return fmt.Sprintf("```yaml\n%s```", yamlSnippet(in, name, description))
+}
+
+// yamlSnippet encodes the example value with all comments, prefixed by the
+// example description.
+func yamlSnippet(in interface{}, name string, description string) string {
 	if name != "" {
 		in = map[string]interface{}{
 			name: in,
@@ -204,7 +421,7 @@ func encodeYaml(in interface{}, name string, description string) string {
 		yamlPrefix = fmt.Sprintf("# %s\n", description)
 	}
 
-	node, err := toYamlNode(in, CommentsAll)
+	node, err := toYamlNode(in, &Options{Comments: CommentsAll})
 	if err != nil {
 		return fmt.Sprintf("yaml encoding failed %s", err)
 	}
@@ -219,7 +436,95 @@ func encodeYaml(in interface{}, name string, description string) string {
 		lines[i] = strings.TrimRight(line, " ")
 	}
 
-	return fmt.Sprintf("```yaml\n%s%s```", yamlPrefix, strings.Join(lines, "\n"))
+	return yamlPrefix + strings.Join(lines, "\n")
+}
+
+// encodeExample encodes the example value in the dialect, commented when
+// the dialect supports comments.
+func encodeExample(in interface{}, name, description string, dialect Dialect) string {
+	switch dialect {
+	case DialectYAML:
+		return encodeYaml(in, name, description)
+	case DialectJSON:
+		return encodeDialect(in, name, description, dialect)
+	}
+
+	if name != "" {
+		in = map[string]interface{}{
+			name: in,
+		}
+	}
+	var prefix string
+	if description != "" {
+		prefix = fmt.Sprintf("# %s\n", description)
+	}
+
+	data, err := MarshalTOML(in)
+	if err != nil {
+		return fmt.Sprintf("%s encoding failed %s", dialect, err)
+	}
+	return fmt.Sprintf("```%s\n%s%s\n```", dialect, prefix, strings.TrimRight(string(data), "\n"))
+}
+
+func encodeDialect(in interface{}, name, description string, dialect Dialect) string {
+	if name != "" {
+		in = map[string]interface{}{
+			name: in,
+		}
+	}
+	var prefix string
+	if description != "" && dialect != DialectJSON {
+		prefix = fmt.Sprintf("# %s\n", description)
+	}
+
+	data, err := EncodeDialect(in, dialect)
+	if err != nil {
+		return fmt.Sprintf("%s encoding failed %s", dialect, err)
+	}
+	return fmt.Sprintf("```%s\n%s%s\n```", dialect, prefix, strings.TrimRight(string(data), "\n"))
+}
+
+// glossaryMarkdown links the glossary terms in the text to the glossary section.
+func (fd *FileDoc) glossaryMarkdown(text string) string {
+	return linkGlossary(text, fd.Glossary, func(match, term string) string {
+		return fmt.Sprintf("[%s](#%s)", match, GlossaryAnchor(term))
+	})
+}
+
+// encodeDiagram embeds Mermaid diagrams as code blocks and links other
+// diagrams as images.
+func encodeDiagram(d *Diagram) string {
+	if d.Mermaid != "" {
+		return fmt.Sprintf("```mermaid\n%s\n```", strings.TrimSpace(d.Mermaid))
+	}
+	return fmt.Sprintf("![%s](%s)", filepath.Base(d.Path), d.Path)
+}
+
+// tableCell returns the description of the field along with its default,
+// keys and valid values on a single line, suitable for a markdown table cell.
+func tableCell(field Doc) string {
+	var parts []string
+	if description := strings.TrimSpace(field.Description); description != "" {
+		parts = append(parts, description)
+	}
+	if field.Default != "" {
+		parts = append(parts, fmt.Sprintf("Default value: <code>%s</code>", field.Default))
+	}
+	if field.KeyDoc != "" {
+		parts = append(parts, "Keys: "+field.KeyDoc)
+	}
+
+	if values := field.AllowedValues(); len(values) > 0 {
+		parts = append(parts, fmt.Sprintf("Valid values: <code>%s</code>", strings.Join(values, "</code>, <code>")))
+	}
+
+	cell := strings.Join(parts, "<br />")
+	cell = strings.ReplaceAll(cell, "|", "\\|")
+	return strings.ReplaceAll(cell, "\n", "<br />")
+}
+
+func dialectKey(field Doc, dialect Dialect) string {
+	return field.Key(dialect)
 }
 
 func formatLink(text, link string) string {
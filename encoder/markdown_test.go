@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownDocsURL(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].DocsURL = "https://example.com/job"
+	fd.Structs[0].Fields[0].DocsURL = "https://example.com/job#name"
+
+	data, err := fd.Encode()
+	require.NoError(t, err)
+	require.Contains(t, string(data), "[Learn more](https://example.com/job)\n")
+	require.Contains(t, string(data), " [Learn more](https://example.com/job#name)\n")
+}
+
+func TestMarkdownDiagram(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Diagram = &Diagram{Path: "docs/job.mmd", Mermaid: "flowchart LR\n  job --> step\n"}
+	fd.Structs[1].Diagram = &Diagram{Path: "docs/step.png"}
+
+	data, err := fd.Encode()
+	require.NoError(t, err)
+	require.Contains(t, string(data), "```mermaid\nflowchart LR\n  job --> step\n```")
+	require.Contains(t, string(data), "![step.png](docs/step.png)")
+}
+
+func TestMarkdownGlossary(t *testing.T) {
+	fd := testFileDoc()
+	fd.Glossary = []GlossaryTerm{
+		{Term: "worker", Definition: "A process executing steps."},
+		{Term: "worker pool", Definition: "The set of workers of a job."},
+	}
+	fd.Structs[0].Fields[1].Description = "Size of the worker pool, each `worker` runs one step.\nA worker exits when done."
+
+	data, err := fd.Encode()
+	require.NoError(t, err)
+	require.Contains(t, string(data), "Size of the [worker pool](#glossary-worker-pool), each `worker` runs one step.\nA [worker](#glossary-worker) exits when done.")
+	require.Contains(t, string(data), "## Glossary\n\n- <a id=\"glossary-worker\"></a>**worker** - A process executing steps.")
+}
+
+func TestMarkdownStability(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[1].Stability = StabilityExperimental
+
+	data, err := fd.Encode()
+	require.NoError(t, err)
+	require.Contains(t, string(data), "## Step\n> **Experimental:** This feature is experimental and may change or be removed in future releases.\n")
+	require.Contains(t, string(data), "<div class=\"dt\">\n\n> **Experimental:** This feature is experimental")
+	require.Equal(t, 2, strings.Count(string(data), "**Experimental:**"), "only steps references Step")
+}
+
+func TestMarkdownBadExamples(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields[1].BadExamples = []BadExample{{Name: "Quoted number", Value: `"ten"`, Reason: "Workers is a number."}}
+
+	data, err := fd.Encode()
+	require.NoError(t, err)
+	require.Contains(t, string(data), "Common mistakes:\n\n\n**Quoted number**: Workers is a number.\n\n```yaml\nworkers: \"ten\"\n```")
+
+	data, err = fd.EncodeMDX(nil)
+	require.NoError(t, err)
+	require.Contains(t, string(data), ":::danger Quoted number\n\nWorkers is a number.\n\n```yaml\nworkers: \"ten\"\n```\n\n:::")
+}
+
+func TestToMarkdown(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields[1].Description = "Number of workers.\nPipes | are escaped."
+	fd.Structs[0].Fields[1].Default = "25"
+
+	data, err := fd.ToMarkdown(&MarkdownOptions{HeadingLevel: 3, Layout: LayoutTable, AnchorStyle: AnchorHeadingID})
+	require.NoError(t, err)
+	require.Contains(t, string(data), "### Job {#job}\n")
+	require.Contains(t, string(data), "| Field | Type | Description |\n|-------|------|-------------|\n")
+	require.Contains(t, string(data), "| <code>workers</code> | int | Number of workers.<br />Pipes \\| are escaped.<br />Default value: <code>25</code> |\n")
+	require.Contains(t, string(data), "| <code>steps</code> | []<a href=\"#step\">Step</a> |  |\n")
+	require.Contains(t, string(data), "| <code>type</code> | string | Valid values: <code>dns</code>, <code>http</code> |\n")
+	require.NotContains(t, string(data), "<div class=\"dd\">")
+
+	data, err = fd.ToMarkdown(&MarkdownOptions{AnchorStyle: AnchorNone})
+	require.NoError(t, err)
+	require.Contains(t, string(data), "## Job\n")
+	require.Contains(t, string(data), "<code>steps</code>  <i>[]Step</i>")
+
+	data, err = fd.ToMarkdown(&MarkdownOptions{Template: `{{ range .Structs }}{{ .Type }}{{ template "fieldTable" . }}{{ end }}`})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "Job\n| Field | Type | Description |"))
+
+	_, err = fd.ToMarkdown(&MarkdownOptions{Template: `{{ .Missing`})
+	require.Error(t, err)
+}
+
+func TestMarkdownDialect(t *testing.T) {
+	fd := testFileDoc()
+	fd.Dialects = []Dialect{DialectJSON, DialectTOML}
+	fd.Structs[0].Fields[1].Tags = map[string]string{"toml": "worker_count"}
+	fd.Structs[0].AddExample("a job", &Endpoint{Host: "a", Port: 80})
+
+	data, err := fd.ToMarkdown(&MarkdownOptions{Layout: LayoutTable, Dialect: DialectTOML})
+	require.NoError(t, err)
+	require.Contains(t, string(data), "| <code>worker_count</code> | int |  |\n")
+	require.Contains(t, string(data), "```toml\n# a job\n# endpoint settings\n\nhost = \"a\" # endpoint host\nport = 80 # custom port\n```\n\n```json\n")
+	require.NotContains(t, string(data), "```yaml")
+}
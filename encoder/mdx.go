@@ -0,0 +1,319 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var mdxTemplate = `---
+{{ range $item := frontmatter -}}
+{{ $item.Key }}: {{ $item.Value }}
+{{ end -}}
+---
+
+import Tabs from '@theme/Tabs';
+import TabItem from '@theme/TabItem';
+
+{{- define "examples" }}
+{{- $name := .Name }}
+
+<Tabs>
+{{- range $index, $example := .Examples }}
+<TabItem value="{{ $index }}" label="{{ exampleLabel $example $index }}">
+
+` + "```yaml" + `
+{{ yaml $example.GetValue $name }}
+` + "```" + `
+{{- range $dialect := dialects }}
+
+` + "```{{ $dialect }}" + `
+{{ dialect $example.GetValue (dialectKey $ $dialect) $dialect }}
+` + "```" + `
+{{- end }}
+
+</TabItem>
+{{- end }}
+</Tabs>
+{{- end }}
+
+{{- with mdx .Description }}
+
+{{ . }}
+{{- end }}
+{{- range $struct := .Structs }}
+
+## {{ $struct.Type }}
+{{- with stabilityBanner $struct.Stability }}
+
+:::caution {{ stabilityTitle $struct.Stability }}
+
+{{ . }}
+
+:::
+{{- end }}
+{{- with describe $struct.Description }}
+
+{{ . }}
+{{- end }}
+{{- if $struct.DocsURL }}
+
+[Learn more]({{ $struct.DocsURL }})
+{{- end }}
+{{- if $struct.Diagram }}
+
+{{ diagram $struct.Diagram }}
+{{- end }}
+{{- if $struct.AppearsIn }}
+
+Appears in:
+{{ range $appearance := $struct.AppearsIn }}
+- {{ typeLink $appearance.TypeName }}` + "`.{{ $appearance.FieldName }}`" + `{{ with $appearance.Cardinality }} as {{ . }}{{ end }}{{ with $appearance.Path }} under ` + "`{{ . }}`" + `{{ end }}
+{{- end }}
+{{- end }}
+{{- if $struct.Examples }}
+{{- template "examples" $struct }}
+{{- end }}
+{{- range $index, $_ := $struct.Fields }}
+{{- $field := $struct.Field $index }}
+{{- if $field.Name }}
+
+### ` + "`{{ $field.Name }}`" + `
+
+Type: {{ typeLink $field.Type }}
+{{- with typeStability $field.Type }}
+
+:::caution {{ stabilityTitle . }}
+
+{{ stabilityBanner . }}
+
+:::
+{{- end }}
+{{- with describe $field.Description }}
+
+{{ . }}
+{{- end }}
+{{- if $field.DocsURL }}
+
+[Learn more]({{ $field.DocsURL }})
+{{- end }}
+{{- if or $field.Values $field.Enum }}
+
+Valid values:
+{{ range $value := $field.Values }}
+- ` + "`{{ $value }}`" + `
+{{- end }}
+{{- range $value := $field.Enum }}
+- ` + "`{{ $value.Value }}`" + `{{ with $value.Description }} - {{ mdx . }}{{ end }}
+{{- end }}
+{{- end }}
+{{- if $field.Note }}
+
+:::note
+
+{{ mdx $field.Note }}
+
+:::
+{{- end }}
+{{- if $field.Examples }}
+{{- template "examples" $field }}
+{{- end }}
+{{- range $bad := $field.BadExamples }}
+
+:::danger {{ if $bad.Name }}{{ mdx $bad.Name }}{{ else }}Common mistake{{ end }}
+{{- with $bad.Reason }}
+
+{{ mdx . }}
+{{- end }}
+
+` + "```yaml" + `
+{{ badExample $field.Name $bad }}
+` + "```" + `
+
+:::
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if .Glossary }}
+
+## Glossary
+{{ range $entry := .Glossary }}
+- <a id="{{ glossaryAnchor $entry.Term }}"></a>**{{ mdx $entry.Term }}** - {{ mdx $entry.Definition }}
+{{- end }}
+{{- end }}
+`
+
+// MDXOptions configures the MDX output of the file documentation.
+type MDXOptions struct {
+	// Title is the title of the page, the name of the file documentation by default.
+	Title string
+	// SidebarPosition is the position of the page in the sidebar, omitted when zero.
+	SidebarPosition int
+	// Frontmatter contains additional frontmatter entries.
+	Frontmatter map[string]string
+}
+
+// EncodeMDX encodes the file documentation as an MDX page for Docusaurus,
+// with admonitions for notes and deprecations and tabbed examples.
+func (fd *FileDoc) EncodeMDX(options *MDXOptions) ([]byte, error) {
+	if options == nil {
+		options = &MDXOptions{}
+	}
+
+	t, err := template.New("file_mdx.tpl").
+		Funcs(template.FuncMap{
+			"frontmatter": func() []KeyValue { return options.frontmatter(fd.Name) },
+			"mdx":         escapeMDX,
+			"describe":    fd.describeMDX,
+			"typeLink":    fd.mdxTypeLink,
+			"yaml": func(in interface{}, name string) string {
+				return strings.TrimRight(yamlSnippet(in, name, ""), "\n")
+			},
+			"dialect":         dialectSnippet,
+			"dialectKey":      func(d *Doc, dialect Dialect) string { return d.Key(dialect) },
+			"glossaryAnchor":  GlossaryAnchor,
+			"dialects":        func() []Dialect { return fd.Dialects },
+			"diagram":         encodeDiagram,
+			"exampleLabel":    exampleLabel,
+			"typeStability":   fd.typeStability,
+			"stabilityTitle":  stabilityTitle,
+			"stabilityBanner": stabilityBanner,
+			"badExample":      badExampleSnippet,
+		}).
+		Parse(mdxTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, fd); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteMDX writes the MDX page of the file documentation to the directory.
+func (fd *FileDoc) WriteMDX(dir string, options *MDXOptions) error {
+	data, err := fd.EncodeMDX(options)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, strings.ToLower(fd.Name)+".mdx"), data, 0o644)
+}
+
+func (o *MDXOptions) frontmatter(name string) []KeyValue {
+	title := o.Title
+	if title == "" {
+		title = name
+	}
+
+	items := []KeyValue{{Key: "title", Value: strconv.Quote(title)}}
+	if o.SidebarPosition != 0 {
+		items = append(items, KeyValue{Key: "sidebar_position", Value: strconv.Itoa(o.SidebarPosition)})
+	}
+	keys := make([]string, 0, len(o.Frontmatter))
+	for key := range o.Frontmatter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		items = append(items, KeyValue{Key: key, Value: o.Frontmatter[key]})
+	}
+	return items
+}
+
+// describeMDX renders a description, moving deprecation notices following
+// the go convention of a `Deprecated:` paragraph into an admonition.
+func (fd *FileDoc) describeMDX(description string) string {
+	var text, deprecated []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(description), "\n\n") {
+		if strings.HasPrefix(strings.TrimSpace(paragraph), "Deprecated:") {
+			deprecated = append(deprecated, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(paragraph), "Deprecated:")))
+			continue
+		}
+		text = append(text, paragraph)
+	}
+
+	result := escapeMDX(fd.glossaryMarkdown(strings.Join(text, "\n\n")))
+	if len(deprecated) > 0 {
+		result += fmt.Sprintf("\n\n:::warning Deprecated\n\n%s\n\n:::", escapeMDX(strings.Join(deprecated, "\n\n")))
+	}
+	return strings.TrimSpace(result)
+}
+
+// mdxTypeLink renders a field type as inline code, linking the documented
+// structs it references to their sections.
+func (fd *FileDoc) mdxTypeLink(t string) string {
+	var parts []string
+	last := 0
+	for _, loc := range re.FindAllStringIndex(t, -1) {
+		name := t[loc[0]:loc[1]]
+		if fd.Struct(name) == nil {
+			continue
+		}
+		if loc[0] > last {
+			parts = append(parts, "`"+t[last:loc[0]]+"`")
+		}
+		parts = append(parts, fmt.Sprintf("[%s](#%s)", name, strings.ReplaceAll(strings.ToLower(name), ".", "")))
+		last = loc[1]
+	}
+	if last < len(t) {
+		parts = append(parts, "`"+t[last:]+"`")
+	}
+	return strings.Join(parts, "")
+}
+
+func exampleLabel(example *Example, index int) string {
+	if example.Name != "" {
+		return strings.ReplaceAll(example.Name, `"`, "&quot;")
+	}
+	return fmt.Sprintf("Example %d", index+1)
+}
+
+// dialectSnippet encodes the example value in the dialect without a code fence.
+func dialectSnippet(in interface{}, name string, dialect Dialect) string {
+	if name != "" {
+		in = map[string]interface{}{name: in}
+	}
+	data, err := EncodeDialect(in, dialect)
+	if err != nil {
+		return fmt.Sprintf("%s encoding failed %s", dialect, err)
+	}
+	return strings.TrimRight(string(data), "\n")
+}
+
+// escapeMDX escapes the characters starting JSX expressions and elements
+// outside of code spans and fenced code blocks.
+func escapeMDX(text string) string {
+	lines := strings.Split(text, "\n")
+	fenced := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+
+		parts := strings.Split(line, "`")
+		for j := 0; j < len(parts); j += 2 {
+			parts[j] = strings.NewReplacer("{", `\{`, "}", `\}`, "<", "&lt;").Replace(parts[j])
+		}
+		lines[i] = strings.Join(parts, "`")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,35 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeMDX(t *testing.T) {
+	fd := testFileDoc()
+	fd.Description = "Runs {steps} in <order>, see `{ inline }`."
+	fd.Structs[0].Description = "A job.\n\nDeprecated: use Pipeline instead."
+	fd.Structs[0].Fields[0].Note = "Names are unique."
+	fd.Structs[0].Fields[0].AddExample("simple", "scan")
+	fd.Structs[0].Fields[0].AddExample("", "crawl")
+
+	data, err := fd.EncodeMDX(&MDXOptions{
+		SidebarPosition: 2,
+		Frontmatter:     map[string]string{"slug": "/reference/job"},
+	})
+	require.NoError(t, err)
+
+	mdx := string(data)
+	require.Contains(t, mdx, "---\ntitle: \"Job\"\nsidebar_position: 2\nslug: /reference/job\n---\n")
+	require.Contains(t, mdx, "Runs \\{steps\\} in &lt;order>, see `{ inline }`.")
+	require.Contains(t, mdx, "A job.\n\n:::warning Deprecated\n\nuse Pipeline instead.\n\n:::")
+	require.Contains(t, mdx, ":::note\n\nNames are unique.\n\n:::")
+	require.Contains(t, mdx, "<TabItem value=\"0\" label=\"simple\">\n\n```yaml\nname: scan\n```")
+	require.Contains(t, mdx, "<TabItem value=\"1\" label=\"Example 2\">")
+	require.Contains(t, mdx, "Type: `[]`[Step](#step)")
+}
@@ -0,0 +1,99 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"sync"
+	"time"
+)
+
+// EventKind is the kind of operation an Event describes.
+type EventKind int
+
+const (
+	// EventGenerate is reported after documentation is generated by docgen.
+	EventGenerate EventKind = iota
+	// EventEncode is reported after a value is encoded to documented yaml.
+	EventEncode
+	// EventMarkdown is reported after a FileDoc is rendered to markdown.
+	EventMarkdown
+	// EventValidate is reported after a document is validated against a FileDoc.
+	EventValidate
+)
+
+// String returns the name of the event kind, suitable for use as a metric label.
+func (k EventKind) String() string {
+	switch k {
+	case EventGenerate:
+		return "generate"
+	case EventEncode:
+		return "encode"
+	case EventMarkdown:
+		return "markdown"
+	case EventValidate:
+		return "validate"
+	default:
+		return "unknown"
+	}
+}
+
+// Event contains statistics about a single operation.
+type Event struct {
+	// Kind is the kind of the operation.
+	Kind EventKind
+	// Name is the name of the documented type or file, if known.
+	Name string
+	// Duration is the time taken by the operation.
+	Duration time.Duration
+	// Structs is the number of structs processed.
+	Structs int
+	// Fields is the number of fields processed.
+	Fields int
+	// Issues is the number of issues found by the operation.
+	Issues int
+	// Err is the error returned by the operation, if any.
+	Err error
+}
+
+// Metrics receives statistics about documentation operations. Implementations
+// can forward them to monitoring systems like Prometheus.
+//
+// Observe may be called concurrently.
+type Metrics interface {
+	Observe(event *Event)
+}
+
+// MetricsFunc is an adapter to allow using an ordinary function as Metrics.
+type MetricsFunc func(event *Event)
+
+// Observe calls f(event).
+func (f MetricsFunc) Observe(event *Event) {
+	f(event)
+}
+
+var (
+	metricsMutex sync.RWMutex
+	metrics      Metrics
+)
+
+// SetMetrics sets the Metrics receiving statistics for all operations.
+// Passing nil disables reporting.
+func SetMetrics(m Metrics) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	metrics = m
+}
+
+// Observe reports an event to the configured Metrics, if any.
+func Observe(event *Event) {
+	metricsMutex.RLock()
+	m := metrics
+	metricsMutex.RUnlock()
+
+	if m != nil {
+		m.Observe(event)
+	}
+}
@@ -0,0 +1,164 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Migration is a key renamed by Migrate.
+type Migration struct {
+	// Path is the path of the renamed key, e.g. steps[0].headers.
+	Path string `json:"path"`
+	// Line is the line of the key, starting at 1.
+	Line int `json:"line"`
+	// Column is the column of the key, starting at 1.
+	Column int `json:"column"`
+	// From is the key of the deprecated field.
+	From string `json:"from"`
+	// To is the key of the field replacing it.
+	To string `json:"to"`
+}
+
+// String returns the migration formatted for terminal output.
+func (m Migration) String() string {
+	return fmt.Sprintf("%d:%d: %s: renamed %s to %s", m.Line, m.Column, m.Path, m.From, m.To)
+}
+
+// Migrate renames the keys of the fields documented as ReplacedBy another
+// field of the same struct in every document of the yaml stream, returning
+// the migrated stream along with the renamed keys. Only the keys are
+// rewritten, so the comments and formatting of the documents are
+// preserved. Keys whose replacement is already set are left unchanged.
+// Keys which cannot be located in the source, e.g. keys with escapes, are
+// reported with a ValidationError.
+func Migrate(data []byte, fd *FileDoc) ([]byte, []Migration, error) {
+	doc := fd.Root()
+	if doc == nil {
+		return data, nil, nil
+	}
+
+	var (
+		keys       []*yaml.Node
+		migrations []Migration
+	)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, err
+		}
+		if len(node.Content) > 0 {
+			migrations = append(migrations, migrateNode(fd, node.Content[0], doc, "", &keys)...)
+		}
+	}
+	if len(migrations) == 0 {
+		return data, nil, nil
+	}
+
+	// rename the keys from the end so that the offsets of the previous
+	// keys stay valid
+	lines := lineOffsets(data)
+	edits := make([]keyEdit, 0, len(migrations))
+	for i, migration := range migrations {
+		edit, ok := newKeyEdit(data, lines, keys[i], migration.To)
+		if !ok {
+			return nil, nil, ValidationError{Path: migration.Path, Line: migration.Line, Column: migration.Column, Message: fmt.Sprintf("could not locate key %q", migration.From)}
+		}
+		edits = append(edits, edit)
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+
+	migrated := append([]byte(nil), data...)
+	for _, edit := range edits {
+		migrated = append(migrated[:edit.start], append([]byte(edit.text), migrated[edit.end:]...)...)
+	}
+	return migrated, migrations, nil
+}
+
+// migrateNode returns the migrations of the keys of the node documented by
+// doc, appending the key nodes to renamed in the same order.
+func migrateNode(fd *FileDoc, node *yaml.Node, doc *Doc, path string, renamed *[]*yaml.Node) []Migration {
+	var migrations []Migration
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			migrations = append(migrations, migrateNode(fd, item, doc, fmt.Sprintf("%s[%d]", path, i), renamed)...)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field := doc.FieldByName(key.Value)
+			if field == nil {
+				continue
+			}
+			fieldPath := joinPath(path, key.Value)
+
+			if to := field.ReplacedBy; to != "" && doc.FieldByName(to) != nil && mappingValue(node, to) == nil {
+				migrations = append(migrations, Migration{Path: fieldPath, Line: key.Line, Column: key.Column, From: key.Value, To: to})
+				*renamed = append(*renamed, key)
+			}
+			if nested := fd.Resolve(field); nested != nil {
+				migrations = append(migrations, migrateNode(fd, value, nested, fieldPath, renamed)...)
+			}
+		}
+	}
+	return migrations
+}
+
+// keyEdit replaces the bytes of a key in a document.
+type keyEdit struct {
+	start, end int
+	text       string
+}
+
+// newKeyEdit returns the edit renaming the key node in data to the key,
+// keeping the quoting style of the key. It returns false if the source of
+// the key is not found at its position, e.g. for keys with escapes.
+func newKeyEdit(data []byte, lines []int, key *yaml.Node, to string) (keyEdit, bool) {
+	if key.Line < 1 || key.Line > len(lines) || key.Column < 1 {
+		return keyEdit{}, false
+	}
+
+	// columns count characters rather than bytes
+	start := lines[key.Line-1]
+	for column := 1; column < key.Column && start < len(data); column++ {
+		_, size := utf8.DecodeRune(data[start:])
+		start += size
+	}
+
+	from, text := key.Value, to
+	switch key.Style {
+	case yaml.DoubleQuotedStyle:
+		from, text = `"`+from+`"`, `"`+text+`"`
+	case yaml.SingleQuotedStyle:
+		from, text = "'"+from+"'", "'"+text+"'"
+	}
+	if !bytes.HasPrefix(data[start:], []byte(from)) {
+		return keyEdit{}, false
+	}
+	return keyEdit{start: start, end: start + len(from), text: text}, true
+}
+
+// lineOffsets returns the offsets of the start of every line of data.
+func lineOffsets(data []byte) []int {
+	offsets := []int{0}
+	for i, c := range data {
+		if c == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,85 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields = append(fd.Structs[0].Fields, Doc{Name: "threads", Type: "int", Description: "Deprecated: use workers.", ReplacedBy: "workers"})
+	fd.Structs[1].Fields = append(fd.Structs[1].Fields, Doc{Name: "kind", Type: "string", ReplacedBy: "type"})
+
+	migrated, migrations, err := Migrate([]byte(`# the job
+name: test
+threads: 10 # more is faster
+steps:
+  - "kind": dns
+    headers:
+      kind: b
+  - kind: http
+    type: http
+`), fd)
+	require.NoError(t, err)
+	require.Equal(t, `# the job
+name: test
+workers: 10 # more is faster
+steps:
+  - "type": dns
+    headers:
+      kind: b
+  - kind: http
+    type: http
+`, string(migrated))
+	require.Equal(t, []Migration{
+		{Path: "threads", Line: 3, Column: 1, From: "threads", To: "workers"},
+		{Path: "steps[0].kind", Line: 5, Column: 5, From: "kind", To: "type"},
+	}, migrations)
+	require.Equal(t, "3:1: threads: renamed threads to workers", migrations[0].String())
+
+	data := []byte("name: test\n")
+	migrated, migrations, err = Migrate(data, fd)
+	require.NoError(t, err)
+	require.Empty(t, migrations)
+	require.Equal(t, data, migrated)
+
+	_, _, err = Migrate([]byte("name: [a\n"), fd)
+	require.Error(t, err)
+}
+
+func TestMigrateDocuments(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields = append(fd.Structs[0].Fields, Doc{Name: "threads", Type: "int", ReplacedBy: "workers"})
+
+	migrated, migrations, err := Migrate([]byte(`name: first
+threads: 1
+---
+name: second
+---
+# the third job
+name: third
+threads: 3
+`), fd)
+	require.NoError(t, err)
+	require.Equal(t, `name: first
+workers: 1
+---
+name: second
+---
+# the third job
+name: third
+workers: 3
+`, string(migrated))
+	require.Equal(t, []Migration{
+		{Path: "threads", Line: 2, Column: 1, From: "threads", To: "workers"},
+		{Path: "threads", Line: 8, Column: 1, From: "threads", To: "workers"},
+	}, migrations)
+
+	_, _, err = Migrate([]byte("name: first\n---\nname: [a\n"), fd)
+	require.Error(t, err)
+}
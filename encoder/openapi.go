@@ -0,0 +1,47 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+// OpenAPIVersion is the OpenAPI specification version produced by OpenAPI.
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPI is an OpenAPI document containing only reusable components.
+type OpenAPI struct {
+	OpenAPI    string            `json:"openapi"`
+	Info       OpenAPIInfo       `json:"info"`
+	Components OpenAPIComponents `json:"components"`
+}
+
+// OpenAPIInfo is the metadata of an OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIComponents contains the reusable schemas of an OpenAPI document.
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// OpenAPI returns an OpenAPI document with every documented struct in
+// `components.schemas`, so that the schemas can be referenced from API
+// specifications. The version is the version of the documented API.
+func (fd *FileDoc) OpenAPI(version string) *OpenAPI {
+	document := &OpenAPI{
+		OpenAPI: OpenAPIVersion,
+		Info: OpenAPIInfo{
+			Title:       fd.Name,
+			Description: fd.Description,
+			Version:     version,
+		},
+		Components: OpenAPIComponents{Schemas: map[string]*Schema{}},
+	}
+
+	for _, s := range fd.Structs {
+		document.Components.Schemas[s.Type] = fd.structSchema(s, componentsPrefix)
+	}
+	return document
+}
@@ -4,6 +4,11 @@
 
 package encoder
 
+import (
+	"fmt"
+	"reflect"
+)
+
 // CommentsFlags comments encoding flags type.
 type CommentsFlags int
 
@@ -22,9 +27,137 @@ const (
 	CommentsAll = CommentsExamples | CommentsDocs
 )
 
+const (
+	// CommentsFirstLine limits the docstring of fields to its first line.
+	CommentsFirstLine CommentsFlags = 1 << (iota + 3)
+	// CommentsDescription renders the full description of fields in place
+	// of their docstring.
+	CommentsDescription
+	// CommentsValues renders the values allowed for fields.
+	CommentsValues
+)
+
+const (
+	// CommentsNone renders no comments.
+	CommentsNone = CommentsDisabled
+	// CommentsShort renders the first line of the docstring of fields.
+	CommentsShort = CommentsDocs | CommentsFirstLine
+	// CommentsFull renders the full description of fields along with their
+	// examples and allowed values.
+	CommentsFull = CommentsAll | CommentsDescription | CommentsValues
+)
+
+// ParseComments returns the comments flags of a verbosity level, one of
+// none, short (or minimal), docs, all (or default) and full.
+func ParseComments(level string) (CommentsFlags, error) {
+	switch level {
+	case "none":
+		return CommentsNone, nil
+	case "short", "minimal":
+		return CommentsShort, nil
+	case "docs":
+		return CommentsDocs, nil
+	case "all", "default":
+		return CommentsAll, nil
+	case "full":
+		return CommentsFull, nil
+	default:
+		return CommentsNone, fmt.Errorf("unknown comments level %q, expected one of none, short, docs, all or full", level)
+	}
+}
+
+// CommentPlacement is where the comment documenting a field is written.
+type CommentPlacement int
+
+const (
+	// CommentPlacementDefault writes comments inline after scalar values
+	// and above the keys of lists and maps.
+	CommentPlacementDefault CommentPlacement = iota
+	// CommentPlacementHead writes comments above the key.
+	CommentPlacementHead
+	// CommentPlacementLine writes comments inline, after the value of
+	// scalars and after the key of lists and maps.
+	CommentPlacementLine
+	// CommentPlacementFoot writes comments below the value.
+	CommentPlacementFoot
+)
+
 // Options defines encoder config.
 type Options struct {
 	Comments CommentsFlags
+	// SchemaURL is written as a yaml-language-server schema header.
+	SchemaURL string
+	// Placement is where the comments documenting fields are written.
+	Placement CommentPlacement
+	// FieldPlacements overrides Placement for fields, keyed by their
+	// Type.field path, e.g. Job.steps.
+	FieldPlacements map[string]CommentPlacement
+	// WrapColumns is the column comments are wrapped at, 0 disables
+	// wrapping.
+	WrapColumns int
+	// FieldExamples renders the examples of fields as a commented examples
+	// block beneath them.
+	FieldExamples bool
+	// Redact replaces the values of secret fields with Redacted.
+	Redact bool
+	// Indent is the number of spaces nested nodes are indented by,
+	// 4 if unset.
+	Indent int
+	// Order is the order the fields of structs are written in.
+	Order FieldOrder
+	// OmitEmpty omits the empty optional fields of structs, as if they
+	// were tagged with omitempty. Required fields are always written.
+	OmitEmpty bool
+	// CommentEmpty writes the empty optional fields of structs commented
+	// out, so that they are listed without being set.
+	CommentEmpty bool
+	// Docs documents types in place of their Doc method or the registry.
+	Docs map[reflect.Type]*Doc
+	// Anchors writes repeated struct values once, anchored, and aliases
+	// to them elsewhere.
+	Anchors bool
+	// FileDocs are the file documentations whose headers start the
+	// documents of their root struct.
+	FileDocs []*FileDoc
+	// Marker starts the written documentation comments with the
+	// CommentMarker, so that StripDocComments can remove them.
+	Marker bool
+	// Color colors the keys, values and comments of the yaml with ANSI
+	// escape sequences.
+	Color ColorMode
+
+	// anchors records the struct nodes of the document being encoded.
+	anchors *anchors
+
+	// indent is the column of the node being encoded.
+	indent int
+}
+
+// with returns a copy of the options with the options applied.
+func (o *Options) with(opts ...Option) *Options {
+	res := *o
+
+	if o.FieldPlacements != nil {
+		res.FieldPlacements = make(map[string]CommentPlacement, len(o.FieldPlacements))
+		for field, placement := range o.FieldPlacements {
+			res.FieldPlacements[field] = placement
+		}
+	}
+
+	res.FileDocs = append([]*FileDoc(nil), o.FileDocs...)
+
+	if o.Docs != nil {
+		res.Docs = make(map[reflect.Type]*Doc, len(o.Docs))
+		for t, doc := range o.Docs {
+			res.Docs[t] = doc
+		}
+	}
+
+	for _, opt := range opts {
+		opt(&res)
+	}
+
+	return &res
 }
 
 func newOptions(opts ...Option) *Options {
@@ -48,3 +181,189 @@ func WithComments(flags CommentsFlags) Option {
 		o.Comments = flags
 	}
 }
+
+// WithSchemaURL adds a `# yaml-language-server: $schema=` header referencing
+// the schema at the url, enabling completion and hover documentation in
+// editors using the yaml language server.
+func WithSchemaURL(url string) Option {
+	return func(o *Options) {
+		o.SchemaURL = url
+	}
+}
+
+// WithCommentPlacement sets where the comments documenting fields are
+// written.
+func WithCommentPlacement(placement CommentPlacement) Option {
+	return func(o *Options) {
+		o.Placement = placement
+	}
+}
+
+// WithFieldCommentPlacement sets where the comment documenting a field is
+// written, overriding WithCommentPlacement. The field is given by its
+// Type.field path, e.g. Job.steps.
+func WithFieldCommentPlacement(field string, placement CommentPlacement) Option {
+	return func(o *Options) {
+		if o.FieldPlacements == nil {
+			o.FieldPlacements = map[string]CommentPlacement{}
+		}
+		o.FieldPlacements[field] = placement
+	}
+}
+
+// placement returns where the comment documenting the field of the struct
+// is written.
+func (o *Options) placement(structName, fieldName string) CommentPlacement {
+	if placement, ok := o.FieldPlacements[structName+"."+fieldName]; ok {
+		return placement
+	}
+	return o.Placement
+}
+
+// WithCommentWrap wraps the comments documenting fields at the columns,
+// preserving their paragraph breaks.
+func WithCommentWrap(columns int) Option {
+	return func(o *Options) {
+		o.WrapColumns = columns
+	}
+}
+
+// WithFieldExamples renders the examples of every field as a commented
+// examples block beneath the field, including fields which are set.
+func WithFieldExamples() Option {
+	return func(o *Options) {
+		o.FieldExamples = true
+	}
+}
+
+// WithRedaction replaces the values of fields marked as secret with
+// Redacted, keeping their comments, so that live configs can be shared.
+func WithRedaction() Option {
+	return func(o *Options) {
+		o.Redact = true
+	}
+}
+
+// WithIndent sets the number of spaces nested nodes are indented by.
+func WithIndent(spaces int) Option {
+	return func(o *Options) {
+		o.Indent = spaces
+	}
+}
+
+// WithFieldOrder writes the fields of structs in the order, e.g.
+// FieldOrderRequiredFirst to group the fields which must be set at the
+// top of generated examples.
+func WithFieldOrder(order FieldOrder) Option {
+	return func(o *Options) {
+		o.Order = order
+	}
+}
+
+// WithOmitEmpty omits the empty optional fields of structs, as if they
+// were tagged with omitempty. Fields documented as required are written.
+func WithOmitEmpty() Option {
+	return func(o *Options) {
+		o.OmitEmpty = true
+	}
+}
+
+// WithCommentedEmpty writes the empty optional fields of structs commented
+// out in place of omitting them, e.g. `# workers: 0`, so that dumps of
+// populated configs stay readable while listing every key.
+func WithCommentedEmpty() Option {
+	return func(o *Options) {
+		o.CommentEmpty = true
+	}
+}
+
+// WithDoc attaches the documentation to the type of v, which can be a value
+// or a pointer, for this encoder only. It takes precedence over the Doc
+// method of the type and the documentation registered with Register.
+func WithDoc(v interface{}, doc *Doc) Option {
+	return func(o *Options) {
+		t := registryKey(v)
+		if t == nil {
+			return
+		}
+
+		if o.Docs == nil {
+			o.Docs = map[reflect.Type]*Doc{}
+		}
+		o.Docs[t] = doc
+	}
+}
+
+// WithAnchors writes struct values repeated in a document once, with an
+// anchor, and aliases to the anchor in place of the following values.
+func WithAnchors() Option {
+	return func(o *Options) {
+		o.Anchors = true
+	}
+}
+
+// WithFileDoc starts the documents whose root value is the root struct of
+// the file documentation with a header comment of its name and description.
+// It can be given once for every kind of document in a stream.
+func WithFileDoc(fd *FileDoc) Option {
+	return func(o *Options) {
+		o.FileDocs = append(o.FileDocs, fd)
+	}
+}
+
+// WithCommentMarker starts the documentation comments with the
+// CommentMarker in place of `#`, so that they can be told apart from the
+// other comments of the file and removed by StripDocComments.
+func WithCommentMarker() Option {
+	return func(o *Options) {
+		o.Marker = true
+	}
+}
+
+// WithColor colors the keys, values and comments of the yaml with ANSI
+// escape sequences in the mode, e.g. ColorAuto to print the documented
+// config in terminals and plain yaml when the output is piped.
+func WithColor(mode ColorMode) Option {
+	return func(o *Options) {
+		o.Color = mode
+	}
+}
+
+// plain reports whether the value can be encoded by yaml.Marshal, without
+// comments nor any other transformation.
+func (o *Options) plain() bool {
+	return o.Comments == CommentsDisabled && !o.Redact && !o.OmitEmpty && !o.CommentEmpty && o.Order == FieldOrderDeclaration && !o.Anchors
+}
+
+// getDoc returns the documentation of the value, attached with WithDoc or
+// found by getDoc.
+func (o *Options) getDoc(in interface{}) *Doc {
+	if len(o.Docs) > 0 {
+		if doc, ok := o.Docs[registryKey(in)]; ok {
+			return doc
+		}
+	}
+
+	return getDoc(in)
+}
+
+// indentSize returns the number of spaces nested nodes are indented by.
+func (o *Options) indentSize() int {
+	if o.Indent > 0 {
+		return o.Indent
+	}
+
+	return yamlIndent
+}
+
+// nested returns the options to encode a node indented by the columns.
+func (o *Options) nested(columns int) *Options {
+	if o.WrapColumns == 0 {
+		return o
+	}
+
+	nested := *o
+	nested.indent += columns
+
+	return &nested
+}
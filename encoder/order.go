@@ -0,0 +1,123 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// FieldOrder is the order the fields of structs are written in.
+type FieldOrder int
+
+const (
+	// FieldOrderDeclaration writes the fields in the order of the go struct.
+	FieldOrderDeclaration FieldOrder = iota
+	// FieldOrderDoc writes the fields in the order of the documentation of
+	// the struct, followed by the undocumented fields.
+	FieldOrderDoc
+	// FieldOrderAlphabetical writes the fields sorted by key.
+	FieldOrderAlphabetical
+	// FieldOrderRequiredFirst writes the fields documented as required
+	// first, each group in declaration order.
+	FieldOrderRequiredFirst
+)
+
+// orderPairs orders the key and value pairs of the mapping node encoding
+// a struct documented by doc.
+func orderPairs(node *yaml.Node, doc *Doc, order FieldOrder) {
+	less := fieldLess(doc, order, DialectYAML)
+	if less == nil {
+		return
+	}
+
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return less(pairs[i][0].Value, pairs[j][0].Value)
+	})
+
+	node.Content = node.Content[:0]
+	for _, pair := range pairs {
+		node.Content = append(node.Content, pair[0], pair[1])
+	}
+}
+
+// fieldLess returns the order of the keys in the dialect of a struct
+// documented by doc, or nil if the fields keep their declaration order.
+func fieldLess(doc *Doc, order FieldOrder, dialect Dialect) func(a, b string) bool {
+	//nolint:exhaustive
+	switch order {
+	case FieldOrderAlphabetical:
+		return func(a, b string) bool {
+			return a < b
+		}
+	case FieldOrderDeclaration:
+		return nil
+	}
+
+	if doc == nil {
+		return nil
+	}
+
+	// undocumented fields are written last
+	ranks := make(map[string]int, len(doc.Fields))
+	for i, field := range doc.Fields {
+		switch {
+		case order == FieldOrderDoc:
+			ranks[field.Key(dialect)] = i
+		case field.Required:
+			ranks[field.Key(dialect)] = -1
+		}
+	}
+
+	rank := func(key string) int {
+		if r, ok := ranks[key]; ok {
+			return r
+		}
+		if order == FieldOrderRequiredFirst {
+			return 0
+		}
+		return len(doc.Fields)
+	}
+
+	return func(a, b string) bool {
+		return rank(a) < rank(b)
+	}
+}
+
+// mapKeyLess orders the keys of maps, numerically for numbers and by their
+// string representation otherwise.
+func mapKeyLess(a, b reflect.Value) bool {
+	a, b = reflect.Indirect(a), reflect.Indirect(b)
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+
+	if a.Kind() == b.Kind() {
+		//nolint:exhaustive
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.String:
+			return a.String() < b.String()
+		}
+	}
+
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type orderedConfig struct {
+	Name    string `yaml:"name"`
+	Workers int    `yaml:"workers"`
+	Debug   bool   `yaml:"debug"`
+	Extra   string `yaml:"extra"`
+}
+
+func TestFieldOrder(t *testing.T) {
+	doc := &Doc{Fields: []Doc{
+		{Name: "name"},
+		{Name: "workers", Required: true},
+		{Name: "debug"},
+		{Name: "extra", Required: true},
+	}}
+	value := &orderedConfig{Name: "scan", Workers: 4}
+
+	for _, tc := range []struct {
+		order    FieldOrder
+		expected string
+	}{
+		{FieldOrderDeclaration, "name: scan\nworkers: 4\ndebug: false\nextra: \"\"\n"},
+		{FieldOrderAlphabetical, "debug: false\nextra: \"\"\nname: scan\nworkers: 4\n"},
+		{FieldOrderRequiredFirst, "workers: 4\nextra: \"\"\nname: scan\ndebug: false\n"},
+	} {
+		data, err := Marshal(value, WithDoc(value, doc), WithFieldOrder(tc.order))
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, string(data), "order %d", tc.order)
+	}
+
+	data, err := Marshal(&orderedConfig{Debug: true}, WithDoc(value, doc), WithFieldOrder(FieldOrderRequiredFirst), WithOmitEmpty())
+	require.NoError(t, err)
+	require.Equal(t, "workers: 0\nextra: \"\"\ndebug: true\n", string(data))
+
+	// the fields missing from the documentation are written last
+	partial := &Doc{Fields: []Doc{{Name: "debug"}, {Name: "name"}}}
+
+	data, err = Marshal(value, WithDoc(value, partial), WithFieldOrder(FieldOrderDoc))
+	require.NoError(t, err)
+	require.Equal(t, "debug: false\nname: scan\nworkers: 4\nextra: \"\"\n", string(data))
+
+	data, err = MarshalJSONC(value, WithDoc(value, partial), WithFieldOrder(FieldOrderDoc), WithComments(CommentsNone))
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"debug\": false,\n  \"name\": \"scan\",\n  \"workers\": 4,\n  \"extra\": \"\"\n}\n", string(data))
+}
+
+func TestMapKeyOrder(t *testing.T) {
+	data, err := Marshal(map[int]string{10: "a", 2: "b", 1: "c"}, WithComments(CommentsDocs))
+	require.NoError(t, err)
+	require.Equal(t, "1: c\n2: b\n10: a\n", string(data))
+}
@@ -0,0 +1,137 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"path"
+	"reflect"
+	"strings"
+)
+
+// DocFromType builds a best-effort documentation of a struct type at
+// runtime, for types which were not processed by docgen, such as plugin
+// configs. Only names, types and dialect tags are known, without any
+// description or example.
+func DocFromType(t reflect.Type) *Doc {
+	return FileDocFromType(t).Root()
+}
+
+// FileDocFromType builds a best-effort file documentation of a struct type
+// and the structs reachable from its fields at runtime, including the back
+// references between them. It is empty if t is not a struct or a pointer
+// to a struct.
+func FileDocFromType(t reflect.Type) *FileDoc {
+	t = derefType(t)
+	fd := &FileDoc{}
+	if t.Kind() != reflect.Struct {
+		return fd
+	}
+	fd.Name = t.Name()
+
+	b := &reflectBuilder{fd: fd, pkg: t.PkgPath(), docs: map[reflect.Type]*Doc{}}
+	b.structDoc(t)
+	fd.SetAppearancePaths()
+	return fd
+}
+
+type reflectBuilder struct {
+	fd   *FileDoc
+	pkg  string
+	docs map[reflect.Type]*Doc
+}
+
+func (b *reflectBuilder) structDoc(t reflect.Type) *Doc {
+	if doc, ok := b.docs[t]; ok {
+		return doc
+	}
+
+	doc := &Doc{Type: b.typeName(t)}
+	b.docs[t] = doc
+	b.fd.Structs = append(b.fd.Structs, doc)
+	b.addFields(doc, t)
+	return doc
+}
+
+// addFields adds the fields of the struct type to the doc, flattening
+// inline structs.
+func (b *reflectBuilder) addFields(doc *Doc, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		name, options := fieldKey(f)
+		if name == "-" {
+			continue
+		}
+
+		inline := isInline(options)
+		if inline && derefType(f.Type).Kind() == reflect.Struct {
+			b.addFields(doc, derefType(f.Type))
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		field := Doc{Name: name, Type: b.typeName(f.Type)}
+		for _, dialect := range []Dialect{DialectJSON, DialectTOML} {
+			if key := strings.Split(f.Tag.Get(string(dialect)), ",")[0]; key != "" && key != "-" {
+				if field.Tags == nil {
+					field.Tags = map[string]string{}
+				}
+				field.Tags[string(dialect)] = key
+			}
+		}
+		doc.Fields = append(doc.Fields, field)
+
+		if elem := elem(f.Type); elem.Kind() == reflect.Struct && elem.Name() != "" && !standardType(elem) {
+			nested := b.structDoc(elem)
+			nested.AppearsIn = append(nested.AppearsIn, Appearance{
+				TypeName:  doc.Type,
+				FieldName: name,
+				Kind:      ReferenceKind(field.Type, f.Type.Kind() == reflect.Ptr),
+			})
+		}
+	}
+}
+
+// typeName formats the type the way docgen does, qualifying types from
+// other packages than the root struct with their package name.
+func (b *reflectBuilder) typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.typeName(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return "[]" + b.typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + b.typeName(t.Key()) + "]" + b.typeName(t.Elem())
+	}
+
+	if t.Name() == "" {
+		return t.String()
+	}
+	if t.PkgPath() == "" || t.PkgPath() == b.pkg {
+		return t.Name()
+	}
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+// standardType returns true for types of the standard library, such as
+// time.Time, which are documented as scalars.
+func standardType(t reflect.Type) bool {
+	return t.PkgPath() != "" && !strings.Contains(strings.Split(t.PkgPath(), "/")[0], ".")
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,55 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type reflectPlugin struct {
+	Name     string                   `yaml:"name" json:"pluginName"`
+	Timeout  time.Duration            `yaml:"timeout"`
+	Started  time.Time                `yaml:"started"`
+	Targets  []*reflectTarget         `yaml:"targets"`
+	Labels   map[string]reflectTarget `yaml:"labels"`
+	Mixin    `yaml:",inline"`
+	Ignored  string `yaml:"-"`
+	internal string
+}
+
+type reflectTarget struct {
+	Host string
+}
+
+func TestDocFromType(t *testing.T) {
+	fd := FileDocFromType(reflect.TypeOf(&reflectPlugin{}))
+	require.Equal(t, "reflectPlugin", fd.Name)
+	require.Len(t, fd.Structs, 2)
+
+	doc := DocFromType(reflect.TypeOf(reflectPlugin{}))
+	require.Equal(t, "reflectPlugin", doc.Type)
+
+	var names, types []string
+	for _, field := range doc.Fields {
+		names = append(names, field.Name)
+		types = append(types, field.Type)
+	}
+	require.Equal(t, []string{"name", "timeout", "started", "targets", "labels", "mixed_in"}, names)
+	require.Equal(t, []string{"string", "time.Duration", "time.Time", "[]reflectTarget", "map[string]reflectTarget", "string"}, types)
+	require.Equal(t, map[string]string{"json": "pluginName"}, doc.Fields[0].Tags)
+
+	target := fd.Struct("reflectTarget")
+	require.Equal(t, []Doc{{Name: "host", Type: "string"}}, target.Fields)
+	require.Equal(t, []Appearance{
+		{TypeName: "reflectPlugin", FieldName: "targets", Kind: ReferenceList, Path: "targets"},
+		{TypeName: "reflectPlugin", FieldName: "labels", Kind: ReferenceMap, Path: "labels"},
+	}, target.AppearsIn)
+
+	require.Empty(t, FileDocFromType(reflect.TypeOf("")).Structs)
+}
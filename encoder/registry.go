@@ -0,0 +1,49 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"reflect"
+	"sync"
+)
+
+var registry = struct {
+	sync.RWMutex
+	docs map[reflect.Type]*Doc
+}{docs: map[reflect.Type]*Doc{}}
+
+// Register registers the documentation of the type of v, which can be a
+// value or a pointer, such as `(*Config)(nil)`. Code generated by docgen
+// registers the documentation of the structs of its package in init.
+func Register(v interface{}, doc *Doc) {
+	t := registryKey(v)
+	if t == nil {
+		return
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.docs[t] = doc
+}
+
+// Lookup returns the documentation registered for the type of v, which can
+// be a value or a pointer, or nil if none is registered.
+func Lookup(v interface{}) *Doc {
+	t := registryKey(v)
+	if t == nil {
+		return nil
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.docs[t]
+}
+
+func registryKey(v interface{}) reflect.Type {
+	if v == nil {
+		return nil
+	}
+	return derefType(reflect.TypeOf(v))
+}
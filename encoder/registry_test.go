@@ -0,0 +1,29 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type registeredConfig struct {
+	Host string `yaml:"host"`
+}
+
+func TestRegistry(t *testing.T) {
+	doc := &Doc{Fields: []Doc{{Name: "host", Comments: [3]string{LineComment: "target host"}}}}
+	require.Nil(t, Lookup(registeredConfig{}))
+
+	Register((*registeredConfig)(nil), doc)
+	require.Same(t, doc, Lookup(registeredConfig{}))
+	require.Same(t, doc, Lookup(&registeredConfig{}))
+	require.Nil(t, Lookup(nil))
+
+	data, err := Marshal(&registeredConfig{Host: "localhost"})
+	require.NoError(t, err)
+	require.Equal(t, "host: localhost # target host\n", string(data))
+}
@@ -0,0 +1,214 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"encoding/json"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// SchemaDraft is the JSON Schema draft produced by JSONSchema.
+const SchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+const (
+	definitionsPrefix = "#/definitions/"
+	componentsPrefix  = "#/components/schemas/"
+)
+
+// Schema is a JSON Schema document or subschema.
+type Schema struct {
+	Schema               string               `json:"$schema,omitempty"`
+	ID                   string               `json:"$id,omitempty"`
+	Ref                  string               `json:"$ref,omitempty"`
+	Title                string               `json:"title,omitempty"`
+	Description          string               `json:"description,omitempty"`
+	MarkdownDescription  string               `json:"markdownDescription,omitempty"`
+	HTMLDescription      string               `json:"x-intellij-html-description,omitempty"`
+	Type                 string               `json:"type,omitempty"`
+	Properties           map[string]*Schema   `json:"properties,omitempty"`
+	AdditionalProperties interface{}          `json:"additionalProperties,omitempty"`
+	Items                *Schema              `json:"items,omitempty"`
+	MinItems             int                  `json:"minItems,omitempty"`
+	MaxItems             int                  `json:"maxItems,omitempty"`
+	UniqueItems          bool                 `json:"uniqueItems,omitempty"`
+	Required             []string             `json:"required,omitempty"`
+	AnyOf                []*Schema            `json:"anyOf,omitempty"`
+	AllOf                []*Schema            `json:"allOf,omitempty"`
+	OneOf                []*Schema            `json:"oneOf,omitempty"`
+	If                   *Schema              `json:"if,omitempty"`
+	Then                 *Schema              `json:"then,omitempty"`
+	Discriminator        *SchemaDiscriminator `json:"discriminator,omitempty"`
+	Const                interface{}          `json:"const,omitempty"`
+	Enum                 []interface{}        `json:"enum,omitempty"`
+	Default              interface{}          `json:"default,omitempty"`
+	Examples             []interface{}        `json:"examples,omitempty"`
+	Definitions          map[string]*Schema   `json:"definitions,omitempty"`
+}
+
+// SchemaDiscriminator is the OpenAPI discriminator object.
+type SchemaDiscriminator struct {
+	PropertyName string            `json:"propertyName"`
+	Mapping      map[string]string `json:"mapping,omitempty"`
+}
+
+// JSONSchema returns a JSON Schema describing the root struct of the file
+// documentation. Every documented struct is added to the definitions.
+func (fd *FileDoc) JSONSchema() *Schema {
+	schema := &Schema{
+		Schema:      SchemaDraft,
+		Title:       fd.Name,
+		Description: fd.Description,
+		Definitions: map[string]*Schema{},
+	}
+
+	for _, s := range fd.Structs {
+		schema.Definitions[s.Type] = fd.structSchema(s, definitionsPrefix)
+	}
+	if root := fd.Root(); root != nil {
+		schema.Ref = definitionsPrefix + root.Type
+	}
+
+	return schema
+}
+
+// ToJSONSchema returns the JSON Schema document of the file documentation
+// serialized as indented JSON, ready to be served by applications, e.g.
+// from a `GET /config/schema` endpoint.
+func (fd *FileDoc) ToJSONSchema() ([]byte, error) {
+	return json.MarshalIndent(fd.JSONSchema(), "", "  ")
+}
+
+// structSchema returns the schema of the struct, referencing other structs
+// with the ref prefix.
+func (fd *FileDoc) structSchema(doc *Doc, refPrefix string) *Schema {
+	if doc.Discriminator != nil {
+		return fd.discriminatedSchema(doc, refPrefix)
+	}
+
+	schema := &Schema{
+		Type:                 "object",
+		Description:          doc.Description,
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+	}
+
+	for i := range doc.Fields {
+		field := &doc.Fields[i]
+		if field.Name == "" {
+			continue
+		}
+
+		property := fd.fieldSchema(field, refPrefix)
+		if property.Ref != "" {
+			// keywords next to $ref are ignored, wrap the reference to
+			// keep the description of the field
+			property = &Schema{AllOf: []*Schema{property}}
+		}
+		property.Description = field.Description
+		for _, value := range field.AllowedValues() {
+			property.Enum = append(property.Enum, value)
+		}
+		if property.Type == "array" {
+			property.MinItems = field.MinItems
+			property.MaxItems = field.MaxItems
+			property.UniqueItems = field.UniqueItems
+		}
+		if field.KeyDoc != "" {
+			describeValues(property, field.KeyDoc)
+		}
+		if field.Default != "" {
+			var value interface{}
+			if err := yaml.Unmarshal([]byte(field.Default), &value); err == nil {
+				property.Default = value
+			}
+		}
+		for _, example := range field.Examples {
+			if value, ok := exampleValue(example); ok {
+				property.Examples = append(property.Examples, value)
+			}
+		}
+		schema.Properties[field.Name] = property
+		if field.Required {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+
+	return schema
+}
+
+// fieldSchema returns the schema of the field type, or one of the types
+// it accepts.
+func (fd *FileDoc) fieldSchema(field *Doc, refPrefix string) *Schema {
+	switch len(field.Accepts) {
+	case 0:
+		return fd.typeSchema(field.Type, refPrefix)
+	case 1:
+		return fd.typeSchema(field.Accepts[0], refPrefix)
+	}
+
+	schema := &Schema{}
+	for _, typ := range field.Accepts {
+		schema.OneOf = append(schema.OneOf, fd.typeSchema(typ, refPrefix))
+	}
+	return schema
+}
+
+func (fd *FileDoc) typeSchema(typ, refPrefix string) *Schema {
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		return &Schema{Type: "array", Items: fd.typeSchema(typ[2:], refPrefix)}
+	case strings.HasPrefix(typ, "map["):
+		return &Schema{Type: "object", AdditionalProperties: fd.typeSchema(typ[mapKeyEnd(typ)+1:], refPrefix)}
+	}
+
+	if fd.Struct(typ) != nil {
+		return &Schema{Ref: refPrefix + typ}
+	}
+
+	switch scalarTag(typ) {
+	case "!!str":
+		return &Schema{Type: "string"}
+	case "!!bool":
+		return &Schema{Type: "boolean"}
+	case "!!int":
+		return &Schema{Type: "integer"}
+	case "!!float":
+		return &Schema{Type: "number"}
+	default:
+		return &Schema{}
+	}
+}
+
+// describeValues sets the description of the values of a map schema to
+// the description of its keys, shown by editors for every key. References
+// are wrapped, as keywords next to $ref are ignored.
+func describeValues(schema *Schema, description string) {
+	values, ok := schema.AdditionalProperties.(*Schema)
+	if !ok {
+		return
+	}
+	if values.Ref != "" {
+		values = &Schema{AllOf: []*Schema{values}}
+		schema.AdditionalProperties = values
+	}
+	values.Description = description
+}
+
+// exampleValue returns the example value converted to plain yaml types
+// suitable for serializing as JSON.
+func exampleValue(e *Example) (interface{}, bool) {
+	data, err := yaml.Marshal(e.GetValue())
+	if err != nil {
+		return nil, false
+	}
+
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil || value == nil {
+		return nil, false
+	}
+	return value, true
+}
@@ -0,0 +1,144 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema := testFileDoc().JSONSchema()
+
+	require.Equal(t, "#/definitions/Job", schema.Ref)
+	require.Len(t, schema.Definitions, 2)
+
+	steps := schema.Definitions["Job"].Properties["steps"]
+	require.Equal(t, "array", steps.Type)
+	require.Equal(t, "#/definitions/Step", steps.Items.Ref)
+
+	stepType := schema.Definitions["Step"].Properties["type"]
+	require.Equal(t, []interface{}{"dns", "http"}, stepType.Enum)
+}
+
+func TestJSONSchemaKeyDoc(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields = append(fd.Structs[0].Fields, Doc{Name: "profiles", Type: "map[string]Step", KeyDoc: "Name of the profile."})
+	fd.Structs[1].Fields[1].KeyDoc = "Name of the header."
+
+	schema := fd.JSONSchema()
+
+	headers := schema.Definitions["Step"].Properties["headers"].AdditionalProperties.(*Schema)
+	require.Equal(t, &Schema{Type: "string", Description: "Name of the header."}, headers)
+
+	profiles := schema.Definitions["Job"].Properties["profiles"].AdditionalProperties.(*Schema)
+	require.Equal(t, &Schema{AllOf: []*Schema{{Ref: "#/definitions/Step"}}, Description: "Name of the profile."}, profiles)
+}
+
+func TestJSONSchemaStructField(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields = append(fd.Structs[0].Fields, Doc{Name: "default", Type: "Step", Description: "Step run by default."})
+
+	schema := fd.JSONSchema()
+
+	step := schema.Definitions["Job"].Properties["default"]
+	require.Empty(t, step.Ref)
+	require.Equal(t, &Schema{AllOf: []*Schema{{Ref: "#/definitions/Step"}}, Description: "Step run by default."}, step)
+}
+
+func TestToolDefinition(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields[0].Required = true
+	fd.Structs[0].Fields[0].Description = "Name of the job."
+	tool := fd.ToolDefinition()
+
+	require.Equal(t, "function", tool.Type)
+	require.Equal(t, "Job", tool.Function.Name)
+	require.False(t, tool.Function.Strict, "maps can not be described in strict mode")
+
+	params := tool.Function.Parameters
+	require.Equal(t, []string{"name", "workers", "steps"}, params.Required)
+	require.Equal(t, false, params.AdditionalProperties)
+
+	steps := params.Properties["steps"].AnyOf[0]
+	require.Equal(t, "array", steps.Type)
+	require.Equal(t, "object", steps.Items.Type)
+	require.Contains(t, steps.Items.Properties, "type")
+
+	require.Equal(t, &Schema{Type: "string", Description: "Name of the job."}, params.Properties["name"], "required fields do not accept null")
+	require.Equal(t, []*Schema{{Type: "integer"}, {Type: "null"}}, params.Properties["workers"].AnyOf)
+}
+
+func TestOpenAPI(t *testing.T) {
+	document := testFileDoc().OpenAPI("1.2.0")
+
+	require.Equal(t, OpenAPIVersion, document.OpenAPI)
+	require.Equal(t, OpenAPIInfo{Title: "Job", Version: "1.2.0"}, document.Info)
+	require.Len(t, document.Components.Schemas, 2)
+
+	steps := document.Components.Schemas["Job"].Properties["steps"]
+	require.Equal(t, "#/components/schemas/Step", steps.Items.Ref)
+	require.Equal(t, []interface{}{"dns", "http"}, document.Components.Schemas["Step"].Properties["type"].Enum)
+}
+
+func TestLanguageServerSchema(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Description = "Job runs <steps>.\n\nSteps run in order."
+	fd.Structs[0].DocsURL = "https://example.com/job"
+	fd.Structs[0].Fields[1].Description = "Number of workers."
+	fd.Structs[0].Fields[1].AddExample("", 10)
+
+	schema := fd.LanguageServerSchema()
+
+	job := schema.Definitions["Job"]
+	require.Equal(t, "Job runs <steps>.\n\nSteps run in order.\n\n[Learn more](https://example.com/job)", job.MarkdownDescription)
+	require.Equal(t, `<p>Job runs &lt;steps&gt;.</p><p>Steps run in order.</p><p><a href="https://example.com/job">Learn more</a></p>`, job.HTMLDescription)
+
+	workers := job.Properties["workers"]
+	require.Equal(t, "Number of workers.\n\n```yaml\nworkers: 10\n```", workers.MarkdownDescription)
+	require.Equal(t, "<p>Number of workers.</p>", workers.HTMLDescription)
+}
+
+func discriminatedFileDoc() *FileDoc {
+	fd := testFileDoc()
+	fd.Structs[1].Discriminator = &Discriminator{
+		Field:   "type",
+		Mapping: map[string]string{"dns": "DNSStep", "http": "HTTPStep"},
+	}
+	fd.Structs = append(fd.Structs,
+		&Doc{Type: "DNSStep", Fields: []Doc{{Name: "type", Type: "string"}, {Name: "name", Type: "string"}}},
+		&Doc{Type: "HTTPStep", Fields: []Doc{{Name: "type", Type: "string"}, {Name: "headers", Type: "map[string]string"}}},
+	)
+	return fd
+}
+
+func TestDiscriminatorSchema(t *testing.T) {
+	fd := discriminatedFileDoc()
+
+	step := fd.JSONSchema().Definitions["Step"]
+	require.Equal(t, []string{"type"}, step.Required)
+	require.Equal(t, []interface{}{"dns", "http"}, step.Properties["type"].Enum)
+	require.Len(t, step.AllOf, 2)
+	require.Equal(t, "http", step.AllOf[1].If.Properties["type"].Const)
+	require.Equal(t, "#/definitions/HTTPStep", step.AllOf[1].Then.Ref)
+
+	step = fd.OpenAPI("1.0.0").Components.Schemas["Step"]
+	require.Equal(t, []*Schema{{Ref: "#/components/schemas/DNSStep"}, {Ref: "#/components/schemas/HTTPStep"}}, step.OneOf)
+	require.Equal(t, "type", step.Discriminator.PropertyName)
+	require.Equal(t, "#/components/schemas/DNSStep", step.Discriminator.Mapping["dns"])
+}
+
+func TestToJSONSchema(t *testing.T) {
+	data, err := testFileDoc().ToJSONSchema()
+	require.NoError(t, err)
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	require.Equal(t, SchemaDraft, schema["$schema"])
+	require.Equal(t, "#/definitions/Job", schema["$ref"])
+	require.Contains(t, schema["definitions"], "Step")
+}
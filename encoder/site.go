@@ -0,0 +1,328 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var siteTemplate = `
+{{- define "page" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<link rel="stylesheet" href="style.css">
+<script src="search-index.js"></script>
+<script src="search.js" defer></script>
+{{- if and .Struct .Struct.Diagram .Struct.Diagram.Mermaid }}
+<script type="module">
+import mermaid from "https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.esm.min.mjs";
+mermaid.initialize({ startOnLoad: true });
+</script>
+{{- end }}
+</head>
+<body>
+<nav>
+<a class="home" href="index.html">{{ .Site.Name }}</a>
+<input id="search" type="search" placeholder="Search...">
+<ul id="results"></ul>
+<ul>
+{{- range $struct := .Site.Structs }}
+<li><a href="{{ page $struct.Type }}">{{ $struct.Type }}</a></li>
+{{- end }}
+</ul>
+</nav>
+<main>
+{{ if .Struct }}{{ template "struct" .Struct }}{{ else }}{{ template "index" .Site }}{{ end }}
+</main>
+</body>
+</html>
+{{ end -}}
+
+{{- define "index" -}}
+<h1>{{ .Name }}</h1>
+{{ paragraphs .Description }}
+{{ if .Root }}<p>Start with <a href="{{ page .Root.Type }}">{{ .Root.Type }}</a>.</p>{{ end }}
+{{- if .Glossary }}
+<h2>Glossary</h2>
+<dl>
+{{- range $entry := .Glossary }}
+<dt id="{{ glossaryAnchor $entry.Term }}">{{ $entry.Term }}</dt>
+<dd>{{ $entry.Definition }}</dd>
+{{- end }}
+</dl>
+{{- end }}
+{{- end -}}
+
+{{- define "struct" -}}
+<h1>{{ .Type }}</h1>
+{{- with stabilityBanner .Stability }}
+<p class="banner"><strong>{{ stabilityTitle $.Stability }}:</strong> {{ . }}</p>
+{{- end }}
+{{ paragraphs .Description }}
+{{- if .DocsURL }}
+<p><a href="{{ .DocsURL }}">Learn more</a></p>
+{{- end }}
+{{- with .Diagram }}
+{{- if .Mermaid }}
+<pre class="mermaid">{{ .Mermaid }}</pre>
+{{- else }}
+<img src="{{ diagramFile $.Type . }}" alt="{{ .Path }}">
+{{- end }}
+{{- end }}
+{{- if .AppearsIn }}
+<h2>Appears in</h2>
+<ul>
+{{- range $appearance := .AppearsIn }}
+<li><a href="{{ page $appearance.TypeName }}#{{ $appearance.FieldName }}"><code>{{ $appearance.TypeName }}.{{ $appearance.FieldName }}</code></a>{{ with $appearance.Cardinality }} as {{ . }}{{ end }}{{ with $appearance.Path }} under <code>{{ . }}</code>{{ end }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- range $example := .Examples }}
+<pre><code>{{ yaml $example.GetValue "" $example.GetName }}</code></pre>
+{{- end }}
+{{- if .Fields }}
+<h2>Fields</h2>
+<dl>
+{{- range $field := .Fields }}
+{{- if $field.Name }}
+<dt id="{{ $field.Name }}"><code>{{ $field.Name }}</code> <i>{{ typeLink $field.Type }}</i></dt>
+<dd>
+{{- with typeStability $field.Type }}
+<p class="banner"><strong>{{ stabilityTitle . }}:</strong> {{ stabilityBanner . }}</p>
+{{- end }}
+{{ paragraphs $field.Description }}
+{{- if $field.DocsURL }}
+<p><a href="{{ $field.DocsURL }}">Learn more</a></p>
+{{- end }}
+{{- if or $field.Values $field.Enum }}
+<p>Valid values:</p>
+<ul>
+{{- range $value := $field.Values }}
+<li><code>{{ $value }}</code></li>
+{{- end }}
+{{- range $value := $field.Enum }}
+<li><code>{{ $value.Value }}</code>{{ with $value.Description }} - {{ . }}{{ end }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- if $field.Note }}
+<blockquote>{{ $field.Note }}</blockquote>
+{{- end }}
+{{- range $example := $field.Examples }}
+<pre><code>{{ yaml $example.GetValue $field.Name $example.GetName }}</code></pre>
+{{- end }}
+</dd>
+{{- end }}
+{{- end }}
+</dl>
+{{- end }}
+{{- end -}}
+`
+
+const siteStyle = `body { display: flex; margin: 0; font-family: sans-serif; }
+nav { width: 16rem; min-height: 100vh; padding: 1rem; background: #f5f5f5; box-sizing: border-box; }
+nav ul { list-style: none; padding: 0; }
+nav .home { font-weight: bold; }
+#search { width: 100%; margin: 1rem 0; }
+main { flex: 1; padding: 1rem 2rem; max-width: 60rem; }
+dt { margin-top: 1.5rem; }
+pre { background: #f5f5f5; padding: 0.5rem; overflow-x: auto; }
+.banner { background: #fff4e5; border-left: 4px solid #f0a020; padding: 0.5rem; }
+`
+
+const siteSearch = `(function () {
+  var input = document.getElementById("search");
+  var results = document.getElementById("results");
+  input.addEventListener("input", function () {
+    var query = input.value.toLowerCase();
+    results.innerHTML = "";
+    if (!query) {
+      return;
+    }
+    searchIndex.filter(function (entry) {
+      return entry.title.toLowerCase().indexOf(query) !== -1 ||
+        entry.description.toLowerCase().indexOf(query) !== -1;
+    }).slice(0, 20).forEach(function (entry) {
+      var item = document.createElement("li");
+      var link = document.createElement("a");
+      link.href = entry.url;
+      link.textContent = entry.title;
+      item.appendChild(link);
+      results.appendChild(item);
+    });
+  });
+})();
+`
+
+// SearchEntry is an entry of the client-side search index of the site.
+type SearchEntry struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+type sitePage struct {
+	Title  string
+	Site   *FileDoc
+	Struct *Doc
+}
+
+// WriteSite writes a static HTML site for the file documentation to the
+// directory. The site contains an index page, one page per struct linked
+// from a sidebar and a client-side search index.
+func (fd *FileDoc) WriteSite(dir string) error {
+	files, err := fd.Site()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Site returns the files of the static HTML site written by WriteSite,
+// keyed by their name, e.g. to serve the site from memory.
+func (fd *FileDoc) Site() (map[string][]byte, error) {
+	t, err := template.New("site.tpl").
+		Funcs(template.FuncMap{
+			"page":            sitePageName,
+			"typeLink":        fd.typeLink,
+			"paragraphs":      fd.paragraphs,
+			"glossaryAnchor":  GlossaryAnchor,
+			"yaml":            yamlSnippet,
+			"typeStability":   fd.typeStability,
+			"stabilityTitle":  stabilityTitle,
+			"stabilityBanner": stabilityBanner,
+			"diagramFile":     diagramFileName,
+		}).
+		Parse(siteTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := map[string]*sitePage{
+		"index.html": {Title: fd.Name, Site: fd},
+	}
+	files := make(map[string][]byte, len(pages)+3)
+	for _, s := range fd.Structs {
+		pages[sitePageName(s.Type)] = &sitePage{Title: s.Type, Site: fd, Struct: s}
+		if d := s.Diagram; d != nil && d.Mermaid == "" && len(d.Data) > 0 {
+			files[diagramFileName(s.Type, d)] = d.Data
+		}
+	}
+
+	for name, page := range pages {
+		buf := &bytes.Buffer{}
+		if err := t.ExecuteTemplate(buf, "page", page); err != nil {
+			return nil, err
+		}
+		files[name] = buf.Bytes()
+	}
+
+	index, err := json.Marshal(fd.SearchIndex())
+	if err != nil {
+		return nil, err
+	}
+	files["style.css"] = []byte(siteStyle)
+	files["search.js"] = []byte(siteSearch)
+	files["search-index.js"] = []byte(fmt.Sprintf("var searchIndex = %s;\n", index))
+	return files, nil
+}
+
+// SearchIndex returns the search index entries for all the structs and
+// their fields, linking to the site pages.
+func (fd *FileDoc) SearchIndex() []SearchEntry {
+	var entries []SearchEntry
+	for _, s := range fd.Structs {
+		entries = append(entries, SearchEntry{
+			Title:       s.Type,
+			URL:         sitePageName(s.Type),
+			Description: firstLine(s.Description),
+		})
+		for _, field := range s.Fields {
+			if field.Name == "" {
+				continue
+			}
+			entries = append(entries, SearchEntry{
+				Title:       s.Type + "." + field.Name,
+				URL:         sitePageName(s.Type) + "#" + field.Name,
+				Description: firstLine(field.Description),
+			})
+		}
+	}
+	return entries
+}
+
+// sitePageName returns the file name of the page of a struct.
+func sitePageName(typeName string) string {
+	return strings.ToLower(strings.ReplaceAll(typeName, ".", "-")) + ".html"
+}
+
+// diagramFileName returns the file name the diagram of a struct is copied
+// to, or the path of the diagram if its contents are not embedded.
+func diagramFileName(typeName string, d *Diagram) string {
+	if len(d.Data) == 0 {
+		return d.Path
+	}
+	return strings.TrimSuffix(sitePageName(typeName), ".html") + "-diagram" + strings.ToLower(filepath.Ext(d.Path))
+}
+
+// typeLink renders a field type, linking the documented structs it references.
+func (fd *FileDoc) typeLink(t string) template.HTML {
+	buf := &strings.Builder{}
+	last := 0
+	for _, loc := range re.FindAllStringIndex(t, -1) {
+		name := t[loc[0]:loc[1]]
+		if fd.Struct(name) == nil {
+			continue
+		}
+		buf.WriteString(html.EscapeString(t[last:loc[0]]))
+		fmt.Fprintf(buf, `<a href="%s">%s</a>`, sitePageName(name), html.EscapeString(name))
+		last = loc[1]
+	}
+	buf.WriteString(html.EscapeString(t[last:]))
+
+	//nolint:gosec // all the parts are escaped above
+	return template.HTML(buf.String())
+}
+
+// paragraphs renders text separated by blank lines as HTML paragraphs,
+// linking the glossary terms to the glossary on the index page.
+func (fd *FileDoc) paragraphs(text string) template.HTML {
+	buf := &strings.Builder{}
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph == "" {
+			continue
+		}
+		paragraph = linkGlossary(html.EscapeString(paragraph), fd.Glossary, func(match, term string) string {
+			return fmt.Sprintf(`<a href="index.html#%s">%s</a>`, GlossaryAnchor(term), match)
+		})
+		fmt.Fprintf(buf, "<p>%s</p>\n", paragraph)
+	}
+
+	//nolint:gosec // the paragraphs are escaped above
+	return template.HTML(buf.String())
+}
+
+func firstLine(text string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(text), "\n", 2)[0])
+}
@@ -0,0 +1,60 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSite(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[1].AppearsIn = []Appearance{{TypeName: "Job", FieldName: "steps", Kind: ReferenceList, Path: "steps"}}
+	fd.Structs[1].Diagram = &Diagram{Path: "step.mmd", Mermaid: "flowchart LR\n  a --> b"}
+
+	dir := t.TempDir()
+	require.NoError(t, fd.WriteSite(dir))
+
+	for _, name := range []string{"index.html", "job.html", "step.html", "style.css", "search.js", "search-index.js"} {
+		require.FileExists(t, filepath.Join(dir, name))
+	}
+
+	job, err := os.ReadFile(filepath.Join(dir, "job.html"))
+	require.NoError(t, err)
+	require.Contains(t, string(job), `<i>[]<a href="step.html">Step</a></i>`)
+	require.Contains(t, string(job), `<li><a href="step.html">Step</a></li>`)
+
+	step, err := os.ReadFile(filepath.Join(dir, "step.html"))
+	require.NoError(t, err)
+	require.Contains(t, string(step), `<a href="job.html#steps"><code>Job.steps</code></a> as a list under <code>steps</code></li>`)
+	require.Contains(t, string(step), `<i>map[string]string</i>`)
+	require.Contains(t, string(step), "<pre class=\"mermaid\">flowchart LR\n  a --&gt; b</pre>")
+	require.Contains(t, string(step), "mermaid.initialize")
+	require.NotContains(t, string(job), "mermaid.initialize")
+
+	require.NoFileExists(t, filepath.Join(dir, "step-diagram.mmd"))
+
+	index := fd.SearchIndex()
+	require.Len(t, index, 7)
+	require.Equal(t, SearchEntry{Title: "Step.headers", URL: "step.html#headers"}, index[6])
+}
+
+func TestWriteSiteImageDiagram(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Diagram = &Diagram{Path: "docs/job.svg", Data: []byte("<svg></svg>")}
+	fd.Structs[1].Diagram = &Diagram{Path: "docs/step.png"}
+
+	files, err := fd.Site()
+	require.NoError(t, err)
+	require.Equal(t, []byte("<svg></svg>"), files["job-diagram.svg"])
+	require.Contains(t, string(files["job.html"]), `<img src="job-diagram.svg" alt="docs/job.svg">`)
+	require.NotContains(t, string(files["job.html"]), "mermaid.initialize")
+
+	// diagrams without contents are still referenced by their path
+	require.Contains(t, string(files["step.html"]), `<img src="docs/step.png" alt="docs/step.png">`)
+}
@@ -0,0 +1,48 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import "strings"
+
+// Stability levels of documented structs.
+const (
+	// StabilityExperimental marks structs which may change or be removed.
+	StabilityExperimental = "experimental"
+	// StabilityBeta marks structs which may still change.
+	StabilityBeta = "beta"
+	// StabilityStable marks structs covered by compatibility guarantees.
+	StabilityStable = "stable"
+)
+
+// typeStability returns the stability of the documented struct referenced
+// by the field type, empty for stable or undocumented types.
+func (fd *FileDoc) typeStability(typ string) string {
+	if doc := fd.Struct(ElemType(typ)); doc != nil && doc.Stability != StabilityStable {
+		return doc.Stability
+	}
+	return ""
+}
+
+// stabilityTitle returns the title of the banner shown for the stability.
+func stabilityTitle(stability string) string {
+	switch stability {
+	case StabilityExperimental, StabilityBeta:
+		return strings.ToUpper(stability[:1]) + stability[1:]
+	default:
+		return ""
+	}
+}
+
+// stabilityBanner returns the text of the banner shown for the stability.
+func stabilityBanner(stability string) string {
+	switch stability {
+	case StabilityExperimental:
+		return "This feature is experimental and may change or be removed in future releases."
+	case StabilityBeta:
+		return "This feature is in beta and may change in future releases."
+	default:
+		return ""
+	}
+}
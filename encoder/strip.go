@@ -0,0 +1,116 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// CommentMarker starts the comments written by encoders created
+// WithCommentMarker, in place of `#`, e.g. `#: Number of workers.`.
+const CommentMarker = "#:"
+
+// markComment marks every line of the comment with the CommentMarker.
+func markComment(comment string) string {
+	if comment == "" {
+		return ""
+	}
+
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		switch {
+		case line == "" || strings.HasPrefix(line, CommentMarker):
+		case strings.HasPrefix(line, "#"):
+			lines[i] = CommentMarker + line[1:]
+		default:
+			lines[i] = CommentMarker + " " + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// markComments marks the comments of the node and its children.
+func markComments(node *yaml.Node) {
+	node.HeadComment = markComment(node.HeadComment)
+	node.LineComment = markComment(node.LineComment)
+	node.FootComment = markComment(node.FootComment)
+
+	for _, child := range node.Content {
+		markComments(child)
+	}
+}
+
+// StripDocComments removes the comments written by encoders created
+// WithCommentMarker from the yaml documents, keeping the other comments,
+// so that tools can toggle between the documented and undocumented views
+// of the same file.
+func StripDocComments(data []byte) ([]byte, error) {
+	var documents []*yaml.Node
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		stripComments(&node)
+		documents = append(documents, &node)
+	}
+	if len(documents) == 0 {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(documents[0]))
+	for _, document := range documents {
+		if err := enc.Encode(document); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// stripComments removes the marked comment lines of the node and its
+// children.
+func stripComments(node *yaml.Node) {
+	node.HeadComment = stripComment(node.HeadComment)
+	node.LineComment = stripComment(node.LineComment)
+	node.FootComment = stripComment(node.FootComment)
+
+	for _, child := range node.Content {
+		stripComments(child)
+	}
+}
+
+// stripComment returns the comment without its marked lines.
+func stripComment(comment string) string {
+	if !strings.Contains(comment, CommentMarker) {
+		return comment
+	}
+
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), CommentMarker) {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,88 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripDocComments(t *testing.T) {
+	fd := testFileDoc()
+	fd.Description = "A job."
+	job, step := fd.Structs[0], fd.Structs[1]
+	job.Fields[0].Comments[LineComment] = "Name of the job."
+	job.Fields[2].Comments[LineComment] = "Steps run by the job."
+	step.Fields[1].Comments[LineComment] = "Headers sent\nwith the requests."
+
+	original := `# my job
+name: scan
+workers: 4 # tuned by hand
+steps:
+  - type: "http"
+    headers:
+      a: b
+`
+
+	data, err := Annotate([]byte(original), fd, WithCommentMarker())
+	require.NoError(t, err)
+	require.Equal(t, `# my job
+name: scan
+workers: 4 # tuned by hand
+#: Steps run by the job.
+steps:
+  - type: "http"
+    #: Headers sent
+    #: with the requests.
+    headers:
+      a: b
+`, string(data))
+
+	data, err = StripDocComments(data)
+	require.NoError(t, err)
+	require.Equal(t, original, string(data))
+
+	listeners := &FileDoc{Name: "Listener", Description: "Listener accepts connections.", Structs: []*Doc{&listenerDoc}}
+	data, err = Marshal(&Listener{Address: ":80"}, WithComments(CommentsDocs), WithFileDoc(listeners), WithCommentMarker())
+	require.NoError(t, err)
+	require.Equal(t, `#: Listener
+#:
+#: Listener accepts connections.
+
+#: Address to listen on,
+#: such as :8080.
+address: :80
+#: served hosts
+hosts: []
+`, string(data))
+
+	data, err = StripDocComments(data)
+	require.NoError(t, err)
+	require.Equal(t, "address: :80\nhosts: []\n", string(data))
+
+	data, err = StripDocComments([]byte("a: 1 # kept\n---\n#: removed\nb: 2\n"))
+	require.NoError(t, err)
+	require.Equal(t, "a: 1 # kept\n---\nb: 2\n", string(data))
+
+	_, err = StripDocComments([]byte("a: [1"))
+	require.Error(t, err)
+}
+
+func (suite *EncoderSuite) TestCommentMarker() {
+	value := &Config{Slice: []string{"a"}}
+
+	plain, err := Marshal(value, WithComments(CommentsNone))
+	suite.Require().NoError(err)
+
+	data, err := Marshal(value, WithComments(CommentsDocs), WithCommentMarker())
+	suite.Require().NoError(err)
+	suite.Assert().Contains(string(data), "#: ")
+	suite.Assert().NotContains(string(data), "\n# ")
+
+	data, err = StripDocComments(data)
+	suite.Require().NoError(err)
+	suite.Assert().Equal(string(plain), string(data))
+}
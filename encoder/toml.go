@@ -0,0 +1,40 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// MarshalTOML encodes the value as a TOML document commented with the
+// documentation of its fields, as Marshal does for yaml. Struct fields are
+// named after their toml tag, falling back to the yaml tag. The comments
+// level, wrapping and redaction options are honored.
+func MarshalTOML(value interface{}, opts ...Option) ([]byte, error) {
+	o := newOptions(opts...)
+
+	table, ok := plainValue(reflect.ValueOf(value), DialectTOML, o).(orderedMap)
+	if !ok {
+		return nil, fmt.Errorf("toml documents must be tables, got %T", value)
+	}
+
+	buf := &bytes.Buffer{}
+	writeTOMLTable(buf, nil, table)
+
+	if o.Comments.enabled(CommentsDocs) {
+		if doc := o.getDoc(value); doc != nil {
+			header := &bytes.Buffer{}
+			writeTOMLComment(header, joinComments(doc.Comments[HeadComment], docComment(doc, o.Comments)))
+			if header.Len() > 0 && buf.Len() > 0 {
+				header.WriteByte('\n')
+			}
+			return append(header.Bytes(), buf.Bytes()...), nil
+		}
+	}
+
+	return buf.Bytes(), nil
+}
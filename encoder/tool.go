@@ -0,0 +1,140 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ToolDefinition is a function calling tool definition accepted by LLM
+// APIs, allowing assistants to generate documents for the file documentation.
+type ToolDefinition struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction describes the function of a tool definition.
+type ToolFunction struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Strict      bool    `json:"strict"`
+	Parameters  *Schema `json:"parameters"`
+}
+
+var toolNameRe = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// ToolDefinition returns a function calling tool definition whose parameters
+// describe the root struct of the file documentation.
+//
+// The parameters use the strict JSON Schema subset: structs are inlined,
+// every property is required and only optional properties accept null. Strict is
+// only set if every field could be described within that subset, which is
+// not the case for maps and untyped fields.
+func (fd *FileDoc) ToolDefinition() *ToolDefinition {
+	tool := &ToolDefinition{
+		Type: "function",
+		Function: ToolFunction{
+			Name:        strings.Trim(toolNameRe.ReplaceAllString(fd.Name, "_"), "_"),
+			Description: fd.Description,
+			Strict:      true,
+		},
+	}
+
+	root := fd.Root()
+	if root == nil {
+		tool.Function.Parameters = &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+		return tool
+	}
+	if tool.Function.Description == "" {
+		tool.Function.Description = root.Description
+	}
+
+	b := &toolSchemaBuilder{fd: fd, strict: true, visiting: map[string]bool{}}
+	tool.Function.Parameters = b.structSchema(root)
+	tool.Function.Strict = b.strict
+
+	return tool
+}
+
+type toolSchemaBuilder struct {
+	fd       *FileDoc
+	strict   bool
+	visiting map[string]bool
+}
+
+func (b *toolSchemaBuilder) structSchema(doc *Doc) *Schema {
+	schema := &Schema{
+		Type:                 "object",
+		Description:          doc.Description,
+		Properties:           map[string]*Schema{},
+		Required:             []string{},
+		AdditionalProperties: false,
+	}
+	if b.visiting[doc.Type] {
+		// recursive types can not be inlined
+		b.strict = false
+		return schema
+	}
+	b.visiting[doc.Type] = true
+	defer delete(b.visiting, doc.Type)
+
+	for i := range doc.Fields {
+		field := &doc.Fields[i]
+		if field.Name == "" {
+			continue
+		}
+
+		var property *Schema
+		switch len(field.Accepts) {
+		case 0:
+			property = b.typeSchema(field.Type)
+		case 1:
+			property = b.typeSchema(field.Accepts[0])
+		default:
+			property = &Schema{}
+			for _, typ := range field.Accepts {
+				property.AnyOf = append(property.AnyOf, b.typeSchema(typ))
+			}
+		}
+		for _, value := range field.AllowedValues() {
+			property.Enum = append(property.Enum, value)
+		}
+
+		if field.Required {
+			property.Description = field.Description
+		} else {
+			// strict mode requires every property, optional ones are
+			// set to null instead
+			property = &Schema{
+				Description: field.Description,
+				AnyOf:       []*Schema{property, {Type: "null"}},
+			}
+		}
+		schema.Properties[field.Name] = property
+		schema.Required = append(schema.Required, field.Name)
+	}
+	return schema
+}
+
+func (b *toolSchemaBuilder) typeSchema(typ string) *Schema {
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		return &Schema{Type: "array", Items: b.typeSchema(typ[2:])}
+	case strings.HasPrefix(typ, "map["):
+		b.strict = false
+		return &Schema{Type: "object", AdditionalProperties: b.typeSchema(typ[mapKeyEnd(typ)+1:])}
+	}
+
+	if doc := b.fd.Struct(typ); doc != nil {
+		return b.structSchema(doc)
+	}
+
+	schema := b.fd.typeSchema(typ, definitionsPrefix)
+	if schema.Type == "" {
+		b.strict = false
+	}
+	return schema
+}
@@ -0,0 +1,187 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// UnmarshalError is returned by UnmarshalStrict, holding the decoding
+// errors augmented with the documentation of the offending fields.
+type UnmarshalError struct {
+	Errors []string
+}
+
+// Error implements the error interface.
+func (e *UnmarshalError) Error() string {
+	return "yaml: unmarshal errors:\n  " + strings.Join(e.Errors, "\n  ")
+}
+
+var (
+	errorLineRe    = regexp.MustCompile(`^line (\d+): `)
+	unknownFieldRe = regexp.MustCompile(`field (\S+) not found in type`)
+)
+
+// UnmarshalStrict decodes the yaml document into v, rejecting unknown keys.
+// Errors are augmented with the description and an example of the field
+// they refer to, taken from the documentation of v, or suggest the closest
+// documented key for unknown fields.
+func UnmarshalStrict(data []byte, v interface{}, doc *Doc) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(v)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+
+	var node yaml.Node
+	if yaml.Unmarshal(data, &node) != nil {
+		return err
+	}
+	paths := map[int][]string{}
+	keyPaths(&node, nil, paths)
+
+	result := &UnmarshalError{}
+	for _, message := range typeErr.Errors {
+		result.Errors = append(result.Errors, explainError(message, paths, reflect.TypeOf(v), doc))
+	}
+	return result
+}
+
+// keyPaths records the path of the deepest key found on every line.
+func keyPaths(node *yaml.Node, path []string, paths map[int][]string) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, item := range node.Content {
+			if len(path) > 0 {
+				paths[item.Line] = path
+			}
+			keyPaths(item, path, paths)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := append(append([]string{}, path...), node.Content[i].Value)
+			paths[node.Content[i].Line] = key
+			keyPaths(node.Content[i+1], key, paths)
+		}
+	}
+}
+
+func explainError(message string, paths map[int][]string, typ reflect.Type, doc *Doc) string {
+	match := errorLineRe.FindStringSubmatch(message)
+	if match == nil {
+		return message
+	}
+	var line int
+	fmt.Sscan(match[1], &line)
+
+	path, ok := paths[line]
+	if !ok {
+		return message
+	}
+
+	// unknown fields are reported in the documentation of their parent
+	if unknown := unknownFieldRe.FindStringSubmatch(message); unknown != nil {
+		parent, _ := resolveDoc(typ, doc, path)
+		if parent == nil {
+			return message
+		}
+		if suggestion := suggest(unknown[1], parent); suggestion != "" {
+			return fmt.Sprintf("%s, did you mean %q?", message, suggestion)
+		}
+		return message
+	}
+
+	_, field := resolveDoc(typ, doc, path)
+	if field == nil {
+		return message
+	}
+
+	name := path[len(path)-1]
+	if description := strings.TrimSpace(field.Description); description != "" {
+		message += fmt.Sprintf("\n    %s: %s", name, strings.Split(description, "\n")[0])
+	}
+	if len(field.Examples) > 0 {
+		example := strings.TrimRight(yamlSnippet(field.Examples[0].GetValue(), name, ""), "\n")
+		message += "\n    example:\n      " + strings.ReplaceAll(example, "\n", "\n      ")
+	}
+	return message
+}
+
+// resolveDoc walks the key path through the go type and its documentation,
+// returning the documentation of the struct holding the last key and of
+// the field documented for it.
+func resolveDoc(typ reflect.Type, doc *Doc, path []string) (*Doc, *Doc) {
+	var field *Doc
+	for i, key := range path {
+		if doc == nil {
+			return nil, nil
+		}
+		field = doc.FieldByName(key)
+		if i == len(path)-1 {
+			break
+		}
+
+		typ = fieldType(typ, key)
+		if typ == nil {
+			return nil, nil
+		}
+		doc = getDoc(reflect.New(typ).Interface())
+	}
+	return doc, field
+}
+
+// fieldType returns the element type of the struct field with the yaml key,
+// looking into inline structs.
+func fieldType(typ reflect.Type, key string) reflect.Type {
+	typ = elem(typ)
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, options := fieldKey(f)
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		if isInline(options) {
+			if t := fieldType(f.Type, key); t != nil {
+				return t
+			}
+		}
+		if name == key {
+			return elem(f.Type)
+		}
+	}
+	return nil
+}
+
+// elem strips pointers, slices and maps from the type.
+func elem(typ reflect.Type) reflect.Type {
+	for {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			typ = typ.Elem()
+		default:
+			return typ
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type strictJob struct {
+	Name  string        `yaml:"name"`
+	Steps []*strictStep `yaml:"steps"`
+}
+
+type strictStep struct {
+	Retries int `yaml:"retries"`
+}
+
+var (
+	strictJobDoc  = Doc{Type: "strictJob", Fields: []Doc{{Name: "name"}, {Name: "steps"}}}
+	strictStepDoc = Doc{Type: "strictStep", Fields: []Doc{{Name: "retries", Description: "Number of retries.\n\nZero disables retries."}}}
+)
+
+func init() {
+	strictStepDoc.Fields[0].AddExample("", 3)
+}
+
+func (strictJob) Doc() *Doc {
+	return &strictJobDoc
+}
+
+func (strictStep) Doc() *Doc {
+	return &strictStepDoc
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	var job strictJob
+	require.NoError(t, UnmarshalStrict([]byte("name: a\nsteps:\n  - retries: 1\n"), &job, job.Doc()))
+	require.Equal(t, 1, job.Steps[0].Retries)
+
+	err := UnmarshalStrict([]byte("name: a\nsteps:\n  - retries: many\n  - retires: 2\n"), &job, job.Doc())
+	require.EqualError(t, err, `yaml: unmarshal errors:
+  line 3: cannot unmarshal !!str `+"`many`"+` into int
+    retries: Number of retries.
+    example:
+      retries: 3
+  line 4: field retires not found in type encoder.strictStep, did you mean "retries"?`)
+}
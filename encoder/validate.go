@@ -0,0 +1,413 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ValidationError describes a single problem found in a yaml document.
+type ValidationError struct {
+	// Path is the dotted path of the offending key.
+	Path string `json:"path"`
+	// Line is the line of the offending node, starting at 1.
+	Line int `json:"line"`
+	// Column is the column of the offending node, starting at 1.
+	Column int `json:"column"`
+	// Message describes the problem.
+	Message string `json:"message"`
+	// Rule classifies the problem, one of syntax, unknown-field,
+	// required-field, invalid-value, type-mismatch and list-items.
+	Rule string `json:"rule,omitempty"`
+}
+
+// Error implements the error interface.
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// Validate validates a yaml document against the root struct of the file
+// documentation, reporting unknown keys, missing required fields, values
+// outside of the documented values and type mismatches.
+func Validate(data []byte, fd *FileDoc) []ValidationError {
+	start := time.Now()
+
+	errs := validate(data, fd)
+
+	Observe(&Event{
+		Kind:     EventValidate,
+		Name:     fd.Name,
+		Duration: time.Since(start),
+		Issues:   len(errs),
+	})
+
+	return errs
+}
+
+func validate(data []byte, fd *FileDoc) []ValidationError {
+	root := fd.Root()
+	if root == nil {
+		return nil
+	}
+	return ValidateType(data, fd, root.Type)
+}
+
+// ValidateType validates a yaml document against a documented field type,
+// such as a struct name, `[]Step` or `map[string]int`.
+func ValidateType(data []byte, fd *FileDoc, typ string) []ValidationError {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return []ValidationError{{Message: err.Error(), Rule: "syntax"}}
+	}
+	if len(node.Content) == 0 {
+		return nil
+	}
+
+	v := &validator{fd: fd}
+	if doc := fd.Struct(typ); doc != nil {
+		// unlike nested values, a null document is not a valid struct
+		v.validateStruct(node.Content[0], doc, "")
+	} else {
+		v.validateValue(node.Content[0], typ, "")
+	}
+	return v.errs
+}
+
+type validator struct {
+	fd   *FileDoc
+	errs []ValidationError
+}
+
+func (v *validator) report(node *yaml.Node, path, rule, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{
+		Path:    path,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+		Rule:    rule,
+	})
+}
+
+func (v *validator) validateStruct(node *yaml.Node, doc *Doc, path string) {
+	if node.Kind != yaml.MappingNode {
+		v.report(node, path, "type-mismatch", "expected %s to be an object, got %s", doc.Type, kindName(node))
+		return
+	}
+
+	if d := doc.Discriminator; d != nil {
+		value := mappingValue(node, d.Field)
+		if value == nil {
+			v.report(node, joinPath(path, d.Field), "required-field", "missing required field %q in %s", d.Field, doc.Type)
+			return
+		}
+		branch := v.fd.Struct(d.Mapping[value.Value])
+		if branch == nil {
+			v.report(value, joinPath(path, d.Field), "invalid-value", "invalid value %q, expected one of: %s", value.Value, strings.Join(d.Values(), ", "))
+			return
+		}
+		doc = branch
+	}
+
+	set := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fieldPath := joinPath(path, key.Value)
+
+		field := doc.FieldByName(key.Value)
+		if field == nil {
+			if suggestion := suggest(key.Value, doc); suggestion != "" {
+				v.report(key, fieldPath, "unknown-field", "unknown field %q in %s, did you mean %q?", key.Value, doc.Type, suggestion)
+			} else {
+				v.report(key, fieldPath, "unknown-field", "unknown field %q in %s", key.Value, doc.Type)
+			}
+			continue
+		}
+		set[key.Value] = true
+		v.validateField(value, field, fieldPath)
+		v.validateEnum(value, field, fieldPath)
+		v.validateItems(value, field, fieldPath)
+	}
+
+	for i := range doc.Fields {
+		field := &doc.Fields[i]
+		if field.Required && field.Name != "" && !set[field.Name] {
+			v.report(node, joinPath(path, field.Name), "required-field", "missing required field %q in %s", field.Name, doc.Type)
+		}
+	}
+}
+
+// validateField validates the value against the type of the field, or
+// any of the types it accepts.
+func (v *validator) validateField(node *yaml.Node, field *Doc, path string) {
+	if len(field.Accepts) == 0 {
+		v.validateValue(node, field.Type, path)
+		return
+	}
+
+	for _, typ := range field.Accepts {
+		alternative := &validator{fd: v.fd}
+		alternative.validateValue(node, typ, path)
+		if len(alternative.errs) == 0 {
+			return
+		}
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	v.report(node, path, "type-mismatch", "expected %s, got %s", strings.Join(field.Accepts, " or "), kindName(node))
+}
+
+// validateItems reports lists with fewer or more items than allowed by the
+// field, or with duplicate items if they must be unique.
+func (v *validator) validateItems(node *yaml.Node, field *Doc, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.SequenceNode {
+		return
+	}
+
+	if field.MinItems > 0 && len(node.Content) < field.MinItems {
+		v.report(node, path, "list-items", "expected at least %d items, got %d", field.MinItems, len(node.Content))
+	}
+	if field.MaxItems > 0 && len(node.Content) > field.MaxItems {
+		v.report(node, path, "list-items", "expected at most %d items, got %d", field.MaxItems, len(node.Content))
+	}
+	if field.UniqueItems {
+		seen := map[string]bool{}
+		for i, item := range node.Content {
+			data, err := yaml.Marshal(item)
+			if err != nil {
+				continue
+			}
+			if seen[string(data)] {
+				v.report(item, fmt.Sprintf("%s[%d]", path, i), "list-items", "duplicate item, items must be unique")
+			}
+			seen[string(data)] = true
+		}
+	}
+}
+
+// validateEnum reports scalar values which are not one of the documented
+// values of the field, if any.
+func (v *validator) validateEnum(node *yaml.Node, field *Doc, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.ScalarNode || node.ShortTag() == "!!null" {
+		return
+	}
+
+	allowed := field.AllowedValues()
+	if len(allowed) == 0 {
+		return
+	}
+	for _, value := range allowed {
+		if node.Value == value {
+			return
+		}
+	}
+	v.report(node, path, "invalid-value", "invalid value %q, expected one of: %s", node.Value, strings.Join(allowed, ", "))
+}
+
+//nolint:gocyclo
+func (v *validator) validateValue(node *yaml.Node, typ, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.ShortTag() == "!!null" {
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(typ, "[]"):
+		if node.Kind != yaml.SequenceNode {
+			v.report(node, path, "type-mismatch", "expected a list, got %s", kindName(node))
+			return
+		}
+		for i, item := range node.Content {
+			v.validateValue(item, typ[2:], fmt.Sprintf("%s[%d]", path, i))
+		}
+	case strings.HasPrefix(typ, "map["):
+		if node.Kind != yaml.MappingNode {
+			v.report(node, path, "type-mismatch", "expected an object, got %s", kindName(node))
+			return
+		}
+		elem := typ[mapKeyEnd(typ)+1:]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.validateValue(node.Content[i+1], elem, joinPath(path, node.Content[i].Value))
+		}
+	default:
+		if doc := v.fd.Struct(typ); doc != nil {
+			v.validateStruct(node, doc, path)
+			return
+		}
+		if expected := scalarTag(typ); expected != "" && !scalarMatches(node, expected) {
+			v.report(node, path, "type-mismatch", "expected %s, got %s", typ, kindName(node))
+		}
+	}
+}
+
+// scalarTag returns the yaml tag expected for a builtin go type name.
+func scalarTag(typ string) string {
+	switch typ {
+	case "string":
+		return "!!str"
+	case "bool":
+		return "!!bool"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "!!int"
+	case "float32", "float64":
+		return "!!float"
+	default:
+		return ""
+	}
+}
+
+func scalarMatches(node *yaml.Node, expected string) bool {
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+	switch expected {
+	case "!!str":
+		// any scalar is a valid string
+		return true
+	case "!!float":
+		return node.ShortTag() == "!!float" || node.ShortTag() == "!!int"
+	default:
+		return node.ShortTag() == expected
+	}
+}
+
+func kindName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+
+	switch node.ShortTag() {
+	case "!!str":
+		return "a string"
+	case "!!int":
+		return "an integer"
+	case "!!float":
+		return "a number"
+	case "!!bool":
+		return "a boolean"
+	default:
+		return strings.TrimPrefix(node.ShortTag(), "!!")
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// ValidationErrors is the list of problems found while decoding a document.
+type ValidationErrors []ValidationError
+
+// Error implements the error interface.
+func (e ValidationErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Decode validates a yaml document against the file documentation and
+// decodes it into v. Documents with unknown keys, suggesting the closest
+// documented key, or other validation issues are rejected with
+// ValidationErrors before decoding.
+func Decode(data []byte, v interface{}, fd *FileDoc) error {
+	if errs := Validate(data, fd); len(errs) > 0 {
+		return ValidationErrors(errs)
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+// suggest returns the documented key closest to the unknown key, or an empty
+// string if no key is close enough to be a likely typo.
+func suggest(key string, doc *Doc) string {
+	best, bestDistance := "", len(key)/2+1
+	if bestDistance > 3 {
+		bestDistance = 3
+	}
+	for i := range doc.Fields {
+		name := doc.Fields[i].Name
+		if name == "" {
+			continue
+		}
+		if distance := levenshtein(strings.ToLower(key), strings.ToLower(name)); distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+// itemConstraints describes the item constraints of a list field.
+func itemConstraints(field Doc) string {
+	var constraints []string
+	switch {
+	case field.MinItems > 0 && field.MinItems == field.MaxItems:
+		constraints = append(constraints, fmt.Sprintf("exactly %d", field.MinItems))
+	default:
+		if field.MinItems > 0 {
+			constraints = append(constraints, fmt.Sprintf("at least %d", field.MinItems))
+		}
+		if field.MaxItems > 0 {
+			constraints = append(constraints, fmt.Sprintf("at most %d", field.MaxItems))
+		}
+	}
+	if field.UniqueItems {
+		constraints = append(constraints, "unique")
+	}
+	return strings.Join(constraints, ", ")
+}
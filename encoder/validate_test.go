@@ -0,0 +1,179 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testFileDoc() *FileDoc {
+	job := &Doc{Type: "Job"}
+	job.Fields = []Doc{
+		{Name: "name", Type: "string"},
+		{Name: "workers", Type: "int"},
+		{Name: "steps", Type: "[]Step"},
+	}
+
+	step := &Doc{Type: "Step"}
+	step.Fields = []Doc{
+		{Name: "type", Type: "string", Values: []string{"dns", "http"}},
+		{Name: "headers", Type: "map[string]string"},
+	}
+
+	return &FileDoc{Name: "Job", Structs: []*Doc{job, step}}
+}
+
+func TestValidate(t *testing.T) {
+	fd := testFileDoc()
+
+	errs := Validate([]byte(`name: test
+workers: 10
+steps:
+  - type: dns
+    headers:
+      a: b
+`), fd)
+	require.Empty(t, errs)
+
+	errs = Validate([]byte(`name: test
+workers: many
+steps:
+  - typ: dns
+    headers: [a]
+`), fd)
+	require.Len(t, errs, 3)
+	require.Equal(t, "workers", errs[0].Path)
+	require.Equal(t, 2, errs[0].Line)
+	require.Equal(t, "steps[0].typ", errs[1].Path)
+	require.Equal(t, `unknown field "typ" in Step, did you mean "type"?`, errs[1].Message)
+	require.Equal(t, "steps[0].headers", errs[2].Path)
+	require.Equal(t, []string{"type-mismatch", "unknown-field", "type-mismatch"}, []string{errs[0].Rule, errs[1].Rule, errs[2].Rule})
+
+	errs = Validate([]byte("name: [a"), fd)
+	require.Len(t, errs, 1)
+	require.Equal(t, "syntax", errs[0].Rule)
+}
+
+func TestValidateType(t *testing.T) {
+	fd := testFileDoc()
+
+	require.Empty(t, ValidateType([]byte("type: dns"), fd, "Step"))
+	require.Empty(t, ValidateType([]byte("- type: dns\n- type: http"), fd, "[]Step"))
+
+	errs := ValidateType([]byte("- type: dns\n- kind: http"), fd, "[]Step")
+	require.Len(t, errs, 1)
+	require.Equal(t, `2:3: [1].kind: unknown field "kind" in Step`, errs[0].Error())
+
+	errs = ValidateType([]byte("ten"), fd, "int")
+	require.Len(t, errs, 1)
+	require.Equal(t, "expected int, got a string", errs[0].Message)
+}
+
+func TestValidateRequiredAndValues(t *testing.T) {
+	fd := testFileDoc()
+	fd.Structs[0].Fields[0].Required = true
+	fd.Structs[1].Fields[0].EnumValues = []EnumValue{{Value: "tcp"}}
+
+	require.Empty(t, Validate([]byte("name: test\nsteps:\n  - type: tcp"), fd))
+
+	errs := Validate([]byte("workers: 1\nsteps:\n  - type: ftp"), fd)
+	require.Len(t, errs, 2)
+	require.Equal(t, "steps[0].type", errs[0].Path)
+	require.Equal(t, `invalid value "ftp", expected one of: dns, http, tcp`, errs[0].Message)
+	require.Equal(t, `1:1: name: missing required field "name" in Job`, errs[1].Error())
+
+	require.Equal(t, []string{"name"}, fd.JSONSchema().Definitions["Job"].Required)
+}
+
+func TestDecode(t *testing.T) {
+	fd := testFileDoc()
+
+	var job struct {
+		Name    string `yaml:"name"`
+		Workers int    `yaml:"workers"`
+	}
+	require.NoError(t, Decode([]byte("name: test\nworkers: 2"), &job, fd))
+	require.Equal(t, 2, job.Workers)
+
+	err := Decode([]byte("name: test\nworker: 2\nsleep: 1"), &job, fd)
+	require.EqualError(t, err, "2:1: worker: unknown field \"worker\" in Job, did you mean \"workers\"?\n3:1: sleep: unknown field \"sleep\" in Job")
+}
+
+func TestValidateDiscriminator(t *testing.T) {
+	fd := discriminatedFileDoc()
+
+	require.Empty(t, Validate([]byte("name: a\nsteps:\n  - type: dns\n    name: example.com\n  - type: http\n    headers: {a: b}\n"), fd))
+
+	errs := Validate([]byte("name: a\nsteps:\n  - type: dns\n    headers: {a: b}\n  - type: ftp\n  - name: b\n"), fd)
+	require.Len(t, errs, 3)
+	require.Equal(t, `4:5: steps[0].headers: unknown field "headers" in DNSStep`, errs[0].Error())
+	require.Equal(t, `5:11: steps[1].type: invalid value "ftp", expected one of: dns, http`, errs[1].Error())
+	require.Equal(t, `6:5: steps[2].type: missing required field "type" in Step`, errs[2].Error())
+}
+
+func TestValidateItems(t *testing.T) {
+	fd := testFileDoc()
+	steps := &fd.Structs[0].Fields[2]
+	steps.MinItems = 1
+	steps.MaxItems = 2
+	steps.UniqueItems = true
+
+	require.Empty(t, Validate([]byte("name: a\nsteps:\n  - type: dns\n  - type: http\n"), fd))
+
+	errs := Validate([]byte("name: a\nsteps: []\n"), fd)
+	require.Len(t, errs, 1)
+	require.Equal(t, "2:8: steps: expected at least 1 items, got 0", errs[0].Error())
+
+	errs = Validate([]byte("name: a\nsteps:\n  - type: dns\n  - type: http\n  - type: dns\n"), fd)
+	require.Len(t, errs, 2)
+	require.Equal(t, "expected at most 2 items, got 3", errs[0].Message)
+	require.Equal(t, "steps[2]", errs[1].Path)
+
+	property := fd.JSONSchema().Definitions["Job"].Properties["steps"]
+	require.Equal(t, 1, property.MinItems)
+	require.Equal(t, 2, property.MaxItems)
+	require.True(t, property.UniqueItems)
+	require.Equal(t, "at least 1, at most 2, unique", itemConstraints(*steps))
+}
+
+func TestValidateAccepts(t *testing.T) {
+	fd := testFileDoc()
+	name := &fd.Structs[0].Fields[0]
+	name.Type = "string or []string"
+	name.Accepts = []string{"string", "[]string"}
+
+	require.Empty(t, Validate([]byte("name: a\n"), fd))
+	require.Empty(t, Validate([]byte("name: [a, b]\n"), fd))
+
+	errs := Validate([]byte("name: {a: b}\n"), fd)
+	require.Len(t, errs, 1)
+	require.Equal(t, "1:7: name: expected string or []string, got an object", errs[0].Error())
+
+	property := fd.JSONSchema().Definitions["Job"].Properties["name"]
+	require.Equal(t, []*Schema{{Type: "string"}, {Type: "array", Items: &Schema{Type: "string"}}}, property.OneOf)
+	require.Empty(t, property.Type)
+
+	name.Type = "duration"
+	name.Accepts = []string{"string"}
+	require.Equal(t, "1:7: name: expected string, got a list", Validate([]byte("name: [a]\n"), fd)[0].Error())
+	require.Equal(t, "string", fd.JSONSchema().Definitions["Job"].Properties["name"].Type)
+}
+
+func TestCheckBadExamples(t *testing.T) {
+	fd := testFileDoc()
+	workers := &fd.Structs[0].Fields[1]
+	workers.BadExamples = []BadExample{
+		{Name: "Quoted number", Value: `"ten"`, Reason: "Workers is a number."},
+		{Value: "10"},
+	}
+
+	require.Len(t, ValidateField([]byte(`"ten"`), fd, workers), 1)
+
+	errs := fd.CheckBadExamples()
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], `Job.workers: bad example "10" is accepted`)
+}
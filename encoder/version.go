@@ -0,0 +1,78 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the semantic version of the documentation model. It is
+// embedded in generated code as the minimum version it requires, and has
+// to be bumped whenever generated code starts relying on new features,
+// and its major whenever the generated code or the API change
+// incompatibly.
+const Version = "2.0.0"
+
+// SupportPackageIsVersion2 is referenced by generated code to fail the
+// compilation with a clear error when the encoder is too old for the
+// generated code. It follows the major of Version.
+const SupportPackageIsVersion2 = true
+
+// RequireVersion panics when the encoder is older than the minimum version,
+// naming both versions. It is called by generated code at initialization so
+// that generated code and library drifting apart across repositories is
+// reported instead of surfacing as confusing runtime errors.
+func RequireVersion(minimum string) {
+	if err := checkVersion(Version, minimum); err != nil {
+		panic(err)
+	}
+}
+
+func checkVersion(current, minimum string) error {
+	required, err := parseVersion(minimum)
+	if err != nil {
+		return fmt.Errorf("generated documentation requires invalid encoder version %q: %s", minimum, err)
+	}
+	actual, err := parseVersion(current)
+	if err != nil {
+		return fmt.Errorf("invalid encoder version %q: %s", current, err)
+	}
+
+	for i := range required {
+		if actual[i] != required[i] {
+			if actual[i] < required[i] {
+				return fmt.Errorf("generated documentation requires github.com/projectdiscovery/yamldoc-go %s or later, but %s is used: update the module or regenerate the documentation with a matching docgen", minimum, current)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseVersion returns the major, minor and patch numbers of a semantic
+// version, ignoring a leading v and any pre-release or build suffix.
+func parseVersion(version string) ([3]int, error) {
+	var parsed [3]int
+
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return parsed, fmt.Errorf("expected major.minor.patch")
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("invalid number %q", part)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
@@ -0,0 +1,24 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireVersion(t *testing.T) {
+	require.NotPanics(t, func() { RequireVersion(Version) })
+	require.NotPanics(t, func() { RequireVersion("v0.9.0") })
+	require.Panics(t, func() { RequireVersion("3.0.0") })
+
+	require.NoError(t, checkVersion("1.2.0", "1.1.9"))
+	require.NoError(t, checkVersion("2.0.0-rc.1", "1.5.0"))
+	require.EqualError(t, checkVersion("1.1.0", "1.2.0"), "generated documentation requires github.com/projectdiscovery/yamldoc-go 1.2.0 or later, but 1.1.0 is used: update the module or regenerate the documentation with a matching docgen")
+	require.Error(t, checkVersion("1.1.0", "latest"))
+
+	require.Panics(t, func() { RequireVersion("99.0.0") })
+}
@@ -0,0 +1,99 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// minCommentWidth is the narrowest width comments are wrapped at, however
+// deeply they are indented.
+const minCommentWidth = 20
+
+// commentPrefix is the length of the "# " written before comment lines.
+const commentPrefix = 2
+
+// wrapComment reflows the paragraphs of the comment to lines of at most
+// width characters, words longer than the width excepted. List items and
+// indented lines, such as code blocks, are preserved and paragraph breaks
+// are written as empty comment lines.
+func wrapComment(comment string, width int) string {
+	if comment == "" {
+		return ""
+	}
+	if width < minCommentWidth {
+		width = minCommentWidth
+	}
+
+	var (
+		lines     []string
+		paragraph []string
+	)
+
+	flush := func() {
+		line := ""
+		for _, word := range paragraph {
+			switch {
+			case line == "":
+				line = word
+			case len(line)+1+len(word) > width:
+				lines = append(lines, line)
+				line = word
+			default:
+				line += " " + word
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		paragraph = nil
+	}
+
+	for _, line := range strings.Split(comment, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			// an empty comment line keeps the paragraphs in one comment
+			flush()
+			lines = append(lines, "#")
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"):
+			flush()
+			lines = append(lines, line)
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			flush()
+			paragraph = strings.Fields(line)
+		default:
+			paragraph = append(paragraph, strings.Fields(line)...)
+		}
+	}
+	flush()
+
+	return strings.Join(lines, "\n")
+}
+
+// wrapComments wraps the comments documenting the key at the columns of
+// the options. Inline comments which do not fit on the line of the key are
+// moved above it.
+func wrapComments(key, value *yaml.Node, opts *Options) {
+	width := opts.WrapColumns - opts.indent - commentPrefix
+
+	// the length of the key line up to its inline comment
+	line := opts.indent + len(key.Value) + len(": ")
+	if value.Kind == yaml.ScalarNode {
+		line += len(value.Value)
+	}
+	line += len(" ") + commentPrefix
+
+	for _, comment := range []*string{&key.LineComment, &value.LineComment} {
+		if *comment != "" && line+len(*comment) > opts.WrapColumns {
+			key.HeadComment = joinComments(key.HeadComment, *comment)
+			*comment = ""
+		}
+	}
+
+	key.HeadComment = wrapComment(key.HeadComment, width)
+	key.FootComment = wrapComment(key.FootComment, width)
+}
@@ -0,0 +1,36 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapComment(t *testing.T) {
+	comment := `Hosts to scan, given as host names or IP addresses of the targets.
+Ranges are expanded.
+
+Supported forms:
+- a host name such as example.com
+- a CIDR range
+
+    10.0.0.0/8`
+
+	require.Equal(t, `Hosts to scan, given as host
+names or IP addresses of the
+targets. Ranges are expanded.
+#
+Supported forms:
+- a host name such as
+example.com
+- a CIDR range
+#
+    10.0.0.0/8`, wrapComment(comment, 30))
+
+	require.Equal(t, "a-very-long-word-which-can-not-be-split\nnext", wrapComment("a-very-long-word-which-can-not-be-split next", 5))
+	require.Empty(t, wrapComment("", 30))
+}
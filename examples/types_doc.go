@@ -1,28 +1,42 @@
 // This Source Code Form is subject to the terms of the Mozilla Public
 // License, v. 2.0. If a copy of the MPL was not distributed with this
 // file, You can obtain one at http://mozilla.org/MPL/2.0/.
-// DO NOT EDIT: this file is automatically generated by docgen
+
+// Code generated by docgen. DO NOT EDIT.
+//
+// Command: dstdocgen types.go types_doc.go Configuration
+// Source hash: sha256:508e03d33a0cad51cfcc361fa1d727237e0ed35cc95eb249b6419dbc684c42f9
+
 package main
 
 import (
 	"github.com/projectdiscovery/yamldoc-go/encoder"
 )
 
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the encoder package it is being compiled against.
+const _ = encoder.SupportPackageIsVersion2
+
 var (
 	JobDoc             encoder.Doc
 	InternalOptionsDoc encoder.Doc
 )
 
 func init() {
+	encoder.RequireVersion("2.0.0")
+
 	JobDoc.Type = "Job"
 	JobDoc.Comments[encoder.LineComment] = "Job is a single job to be executed by apollo."
-	JobDoc.Description = "Job is a single job to be executed by apollo.\n\nA job contains providers and deployments required to be done\nand some steps to be taken to achieve a desired scan.\n\nA job is just an input and is immutable. The state of a job\nis maintained in other variables instead of the Job struct.\n"
+	JobDoc.Description = "Job is a single job to be executed by apollo.\n\n A job contains providers and deployments required to be done\n and some steps to be taken to achieve a desired scan.\n\n A job is just an input and is immutable. The state of a job\n is maintained in other variables instead of the Job struct."
+	JobDoc.Source = &encoder.Source{File: "examples/types.go", Line: 29}
+	encoder.Register((*Job)(nil), &JobDoc)
 	JobDoc.Fields = make([]encoder.Doc, 5)
 	JobDoc.Fields[0].Name = "name"
 	JobDoc.Fields[0].Type = "string"
 	JobDoc.Fields[0].Note = ""
 	JobDoc.Fields[0].Description = "Name of the Job"
 	JobDoc.Fields[0].Comments[encoder.LineComment] = "Name of the Job"
+	JobDoc.Fields[0].Source = &encoder.Source{File: "examples/types.go", Line: 35}
 
 	JobDoc.Fields[0].AddExample("Name Example", "443-httpx-internet-wide")
 	JobDoc.Fields[1].Name = "key"
@@ -30,6 +44,7 @@ func init() {
 	JobDoc.Fields[1].Note = ""
 	JobDoc.Fields[1].Description = "Key contains a key input of a certain type"
 	JobDoc.Fields[1].Comments[encoder.LineComment] = "Key contains a key input of a certain type"
+	JobDoc.Fields[1].Source = &encoder.Source{File: "examples/types.go", Line: 42}
 	JobDoc.Fields[1].Values = []string{
 		"dns",
 		"http",
@@ -40,6 +55,7 @@ func init() {
 	JobDoc.Fields[2].Note = ""
 	JobDoc.Fields[2].Description = "Description contains a description of the job"
 	JobDoc.Fields[2].Comments[encoder.LineComment] = "Description contains a description of the job"
+	JobDoc.Fields[2].Source = &encoder.Source{File: "examples/types.go", Line: 48}
 
 	JobDoc.Fields[2].AddExample("Description Example", "Runs masscan on port 443 followed by httpx")
 	JobDoc.Fields[3].Name = "providers"
@@ -47,6 +63,7 @@ func init() {
 	JobDoc.Fields[3].Note = ""
 	JobDoc.Fields[3].Description = "Providers contains a list of infrastructure providers\nfor the current scan."
 	JobDoc.Fields[3].Comments[encoder.LineComment] = "Providers contains a list of infrastructure providers"
+	JobDoc.Fields[3].Source = &encoder.Source{File: "examples/types.go", Line: 55}
 
 	JobDoc.Fields[3].AddExample("Providers Example", exampleProvider)
 	JobDoc.Fields[4].Name = "internal-options"
@@ -54,18 +71,23 @@ func init() {
 	JobDoc.Fields[4].Note = ""
 	JobDoc.Fields[4].Description = "InternalOptions contains internal configuration options for scheduler"
 	JobDoc.Fields[4].Comments[encoder.LineComment] = "InternalOptions contains internal configuration options for scheduler"
+	JobDoc.Fields[4].Source = &encoder.Source{File: "examples/types.go", Line: 61}
 
 	JobDoc.Fields[4].AddExample("InternalOptions Example", exampleInternalOptions)
 
 	InternalOptionsDoc.Type = "InternalOptions"
 	InternalOptionsDoc.Comments[encoder.LineComment] = "InternalOptions contains internal configuration options for scheduler"
 	InternalOptionsDoc.Description = "InternalOptions contains internal configuration options for scheduler"
+	InternalOptionsDoc.Source = &encoder.Source{File: "examples/types.go", Line: 65}
 
 	InternalOptionsDoc.AddExample("InternalOptions Example", exampleInternalOptions)
+	encoder.Register((*InternalOptions)(nil), &InternalOptionsDoc)
 	InternalOptionsDoc.AppearsIn = []encoder.Appearance{
 		{
 			TypeName:  "Job",
 			FieldName: "internal-options",
+			Kind:      "pointer",
+			Path:      "internal-options",
 		},
 	}
 	InternalOptionsDoc.Fields = make([]encoder.Doc, 2)
@@ -74,6 +96,7 @@ func init() {
 	InternalOptionsDoc.Fields[0].Note = ""
 	InternalOptionsDoc.Fields[0].Description = "BulkSize is the number of items to process per node at once."
 	InternalOptionsDoc.Fields[0].Comments[encoder.LineComment] = "BulkSize is the number of items to process per node at once."
+	InternalOptionsDoc.Fields[0].Source = &encoder.Source{File: "examples/types.go", Line: 71}
 
 	InternalOptionsDoc.Fields[0].AddExample("BulkSize Example", 10000)
 	InternalOptionsDoc.Fields[1].Name = "scheduling-workers"
@@ -81,6 +104,7 @@ func init() {
 	InternalOptionsDoc.Fields[1].Note = ""
 	InternalOptionsDoc.Fields[1].Description = "SchedulingWorkers is the number of scheduling workers to use for ssh."
 	InternalOptionsDoc.Fields[1].Comments[encoder.LineComment] = "SchedulingWorkers is the number of scheduling workers to use for ssh."
+	InternalOptionsDoc.Fields[1].Source = &encoder.Source{File: "examples/types.go", Line: 77}
 
 	InternalOptionsDoc.Fields[1].AddExample("SchedulingWorkers Example", 10)
 }
@@ -97,7 +121,7 @@ func (InternalOptions) Doc() *encoder.Doc {
 func GetConfigurationDoc() *encoder.FileDoc {
 	return &encoder.FileDoc{
 		Name:        "Configuration",
-		Description: "go:generate docgen types.go types_doc.go Configuration\n",
+		Description: "",
 		Structs: []*encoder.Doc{
 			&JobDoc,
 			&InternalOptionsDoc,
@@ -0,0 +1,308 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package schemaserver serves the JSON Schemas of generated documentation
+// at stable, versioned URLs, for editors and schema catalogs such as
+// schemastore.org.
+package schemaserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+)
+
+// Server serves the registered versions of the schemas.
+//
+// The following endpoints are available:
+//
+//	GET /                        lists the schemas and their versions
+//	GET /{name}.json             returns the latest version of the schema
+//	GET /{name}/{version}.json   returns the version of the schema
+//
+// The latest version is negotiated with an Accept-Version header or a
+// version query parameter holding a version prefix, e.g. 1 or v1.2, which
+// selects the highest registered version matching it. Responses carry an
+// ETag hashing the content of the schema, so that editors revalidate them
+// with If-None-Match, and versioned URLs are cached as immutable.
+type Server struct {
+	mutex   sync.RWMutex
+	options *Options
+	// schemas contains the versions of every schema sorted from the
+	// lowest to the highest, keyed by name.
+	schemas map[string][]*schemaVersion
+}
+
+// Options configures the Server.
+type Options struct {
+	// BaseURL is the URL the server is published at, e.g.
+	// https://schemas.example.com, used for the $id of the schemas
+	// registered from documentation and the URLs of the index.
+	BaseURL string
+	// MaxAge is the duration for which clients may cache the latest
+	// version of a schema before revalidating it. Zero requires clients to
+	// revalidate on every use.
+	MaxAge time.Duration
+}
+
+// schemaVersion is a version of a schema.
+type schemaVersion struct {
+	version string
+	data    []byte
+	etag    string
+}
+
+// New returns a server without schemas. If options is nil, the default
+// options are used.
+func New(options *Options) *Server {
+	if options == nil {
+		options = &Options{}
+	}
+	return &Server{options: options, schemas: map[string][]*schemaVersion{}}
+}
+
+// Register adds the version of the language server schema of the file
+// documentation, named after the documentation in lower case.
+func (s *Server) Register(version string, doc *encoder.FileDoc) error {
+	name := strings.ToLower(doc.Name)
+	schema := doc.LanguageServerSchema()
+	if s.options.BaseURL != "" {
+		schema.ID = s.url(name, version)
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.RegisterSchema(name, version, data)
+}
+
+// RegisterSchema adds the version of the schema with the name, e.g. a
+// schema published for a previous release. A registered version is
+// replaced.
+func (s *Server) RegisterSchema(name, version string, data []byte) error {
+	if !validSegment(name) {
+		return fmt.Errorf("invalid schema name %q", name)
+	}
+	if !validSegment(version) || version == "latest" {
+		return fmt.Errorf("invalid version %q of schema %s", version, name)
+	}
+
+	sum := sha256.Sum256(data)
+	registered := &schemaVersion{version: version, data: data, etag: `"` + hex.EncodeToString(sum[:16]) + `"`}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	versions := s.schemas[name]
+	for i, existing := range versions {
+		if existing.version == version {
+			versions[i] = registered
+			return nil
+		}
+	}
+	versions = append(versions, registered)
+	sort.SliceStable(versions, func(i, j int) bool { return compareVersions(versions[i].version, versions[j].version) < 0 })
+	s.schemas[name] = versions
+	return nil
+}
+
+// LoadDir registers the schemas of the directory laid out as the URLs of
+// the server, where dir/{name}/{version}.json is a version of a schema.
+func (s *Server) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(filepath.Dir(file))
+		version := strings.TrimSuffix(filepath.Base(file), ".json")
+		if err := s.RegisterSchema(name, version, data); err != nil {
+			return fmt.Errorf("could not register %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == "" {
+		s.handleIndex(w)
+		return
+	}
+	if !strings.HasSuffix(path, ".json") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown path %q", r.URL.Path))
+		return
+	}
+
+	name, version, versioned := strings.Cut(strings.TrimSuffix(path, ".json"), "/")
+	if !versioned || version == "latest" {
+		version = r.URL.Query().Get("version")
+		if version == "" {
+			version = r.Header.Get("Accept-Version")
+		}
+		versioned = false
+	}
+
+	schema, err := s.lookup(name, version, versioned)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "application/schema+json")
+	header.Set("ETag", schema.etag)
+	if versioned {
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		header.Set("Content-Location", "/"+name+"/"+schema.version+".json")
+		header.Set("Vary", "Accept-Version")
+		if maxAge := int(s.options.MaxAge.Seconds()); maxAge > 0 {
+			header.Set("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+		} else {
+			header.Set("Cache-Control", "no-cache")
+		}
+	}
+	http.ServeContent(w, r, name+".json", time.Time{}, bytes.NewReader(schema.data))
+}
+
+// ListenAndServe listens on the address and serves the schemas.
+func (s *Server) ListenAndServe(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
+
+// lookup returns the version of the schema, or the highest version
+// matching the version prefix unless exact is set.
+func (s *Server) lookup(name, version string, exact bool) (*schemaVersion, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	versions, ok := s.schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q", name)
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if exact && versions[i].version == version || !exact && matchVersion(versions[i].version, version) {
+			return versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown version %q of schema %s", version, name)
+}
+
+type indexEntry struct {
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+	Latest   string   `json:"latest"`
+	Versions []string `json:"versions"`
+}
+
+// handleIndex lists the schemas sorted by name along with their versions.
+func (s *Server) handleIndex(w http.ResponseWriter) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	index := make([]indexEntry, 0, len(s.schemas))
+	for name, versions := range s.schemas {
+		entry := indexEntry{Name: name, URL: s.url(name, "")}
+		for _, version := range versions {
+			entry.Versions = append(entry.Versions, version.version)
+		}
+		entry.Latest = entry.Versions[len(entry.Versions)-1]
+		index = append(index, entry)
+	}
+	sort.Slice(index, func(i, j int) bool { return index[i].Name < index[j].Name })
+	writeJSON(w, http.StatusOK, index)
+}
+
+// url returns the URL of the version of the schema, or of its latest
+// version if version is empty.
+func (s *Server) url(name, version string) string {
+	path := "/" + name + ".json"
+	if version != "" {
+		path = "/" + name + "/" + version + ".json"
+	}
+	return strings.TrimSuffix(s.options.BaseURL, "/") + path
+}
+
+// validSegment reports whether the value can be used as a segment of the
+// URLs of the schemas.
+func validSegment(value string) bool {
+	return value != "" && value != "." && value != ".." && !strings.ContainsAny(value, "/\\?#")
+}
+
+// matchVersion reports whether the version matches the version prefix,
+// ignoring a leading v and only matching whole components, so that 1.2
+// matches 1.2 and 1.2.3 but not 1.20. An empty prefix matches any version.
+func matchVersion(version, prefix string) bool {
+	version, prefix = strings.TrimPrefix(version, "v"), strings.TrimPrefix(prefix, "v")
+	return prefix == "" || version == prefix || strings.HasPrefix(version, prefix+".")
+}
+
+// compareVersions compares dotted versions component by component,
+// numerically when both components are numbers, e.g. 1.10 is higher than
+// 1.9.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		switch {
+		case aerr == nil && berr == nil && an != bn:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case (aerr != nil || berr != nil) && as[i] != bs[i]:
+			if as[i] < bs[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(as) - len(bs)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package schemaserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(t *testing.T) *Server {
+	s := New(&Options{BaseURL: "https://schemas.example.com/"})
+	fd := &encoder.FileDoc{Name: "Job", Structs: []*encoder.Doc{{Type: "Job", Fields: []encoder.Doc{{Name: "name", Type: "string"}}}}}
+	require.NoError(t, s.Register("1.10.0", fd))
+	require.NoError(t, s.RegisterSchema("job", "1.9.0", []byte(`{"title":"1.9.0"}`)))
+	require.NoError(t, s.RegisterSchema("job", "2.0.0", []byte(`{"title":"2.0.0"}`)))
+	return s
+}
+
+func serve(s *Server, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestServeSchema(t *testing.T) {
+	s := testServer(t)
+
+	w := serve(s, "/job.json", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `{"title":"2.0.0"}`, w.Body.String())
+	require.Equal(t, "application/schema+json", w.Header().Get("Content-Type"))
+	require.Equal(t, "/job/2.0.0.json", w.Header().Get("Content-Location"))
+	require.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+	etag := w.Header().Get("ETag")
+	require.Len(t, etag, 34)
+	w = serve(s, "/job.json", http.Header{"If-None-Match": {etag}})
+	require.Equal(t, http.StatusNotModified, w.Code)
+
+	w = serve(s, "/job.json", http.Header{"Accept-Version": {"1"}})
+	require.Equal(t, "/job/1.10.0.json", w.Header().Get("Content-Location"))
+	var schema encoder.Schema
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &schema))
+	require.Equal(t, "https://schemas.example.com/job/1.10.0.json", schema.ID)
+
+	w = serve(s, "/job/latest.json?version=v1.9", nil)
+	require.Equal(t, `{"title":"1.9.0"}`, w.Body.String())
+
+	w = serve(s, "/job/1.9.0.json", nil)
+	require.Equal(t, `{"title":"1.9.0"}`, w.Body.String())
+	require.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+
+	require.Equal(t, http.StatusNotFound, serve(s, "/job/1.9.json", nil).Code)
+	require.Equal(t, http.StatusNotFound, serve(s, "/job.json?version=1.1", nil).Code)
+	require.Equal(t, http.StatusNotFound, serve(s, "/config.json", nil).Code)
+}
+
+func TestServeIndex(t *testing.T) {
+	w := serve(testServer(t), "/", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `[{"name":"job","url":"https://schemas.example.com/job.json","latest":"2.0.0","versions":["1.9.0","1.10.0","2.0.0"]}]`, w.Body.String())
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "job"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "job", "v1.json"), []byte(`{}`), 0o600))
+
+	s := New(nil)
+	require.NoError(t, s.LoadDir(dir))
+	require.Equal(t, http.StatusOK, serve(s, "/job/v1.json", nil).Code)
+
+	require.Error(t, s.RegisterSchema("job", "latest", []byte(`{}`)))
+	require.Error(t, s.RegisterSchema("a/b", "1", []byte(`{}`)))
+}
+
+func TestCompareVersions(t *testing.T) {
+	require.Negative(t, compareVersions("1.9.0", "1.10.0"))
+	require.Positive(t, compareVersions("v2", "1.10"))
+	require.Zero(t, compareVersions("1.2", "v1.2"))
+	require.Negative(t, compareVersions("1.2", "1.2.1"))
+	require.True(t, matchVersion("1.2.3", "1.2"))
+	require.False(t, matchVersion("1.20.0", "1.2"))
+}
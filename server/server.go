@@ -0,0 +1,281 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package server implements a reference HTTP server validating yaml
+// documents against generated documentation.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+)
+
+// Server serves validation, schema and explanation endpoints for the
+// registered file documentation.
+//
+// The following endpoints are available:
+//
+//	POST /validate             validates the yaml request body
+//	GET  /schema               returns the JSON Schema
+//	GET  /explain?path=a.b.c   explains the field at the dotted path
+//
+// Each endpoint accepts an optional `doc` query parameter selecting the
+// registered FileDoc by name. The first registered FileDoc is used by default.
+type Server struct {
+	mutex   sync.RWMutex
+	docs    []*encoder.FileDoc
+	mux     *http.ServeMux
+	options *Options
+	handler http.Handler
+	slots   chan struct{}
+}
+
+// Options contains the limits applied to requests by the server.
+type Options struct {
+	// MaxBodySize is the maximum size of a request body in bytes.
+	MaxBodySize int64
+	// Timeout is the maximum duration for reading and handling a request.
+	Timeout time.Duration
+	// MaxConcurrent is the maximum number of requests handled at once.
+	// Requests above the limit are rejected with 429 Too Many Requests.
+	MaxConcurrent int
+}
+
+// DefaultOptions returns the default server options.
+func DefaultOptions() *Options {
+	return &Options{
+		MaxBodySize:   1 << 20,
+		Timeout:       10 * time.Second,
+		MaxConcurrent: 64,
+	}
+}
+
+// New returns a server for the provided file documentation. If options
+// is nil, DefaultOptions is used.
+func New(options *Options, docs ...*encoder.FileDoc) *Server {
+	if options == nil {
+		options = DefaultOptions()
+	}
+
+	s := &Server{mux: http.NewServeMux(), options: options}
+	if options.MaxConcurrent > 0 {
+		s.slots = make(chan struct{}, options.MaxConcurrent)
+	}
+	for _, doc := range docs {
+		s.Register(doc)
+	}
+
+	s.mux.HandleFunc("/validate", s.handleValidate)
+	s.mux.HandleFunc("/schema", s.handleSchema)
+	s.mux.HandleFunc("/explain", s.handleExplain)
+
+	s.handler = http.HandlerFunc(s.limit)
+	if options.Timeout > 0 {
+		s.handler = http.TimeoutHandler(s.handler, options.Timeout, `{"error":"request timed out"}`)
+	}
+	return s
+}
+
+// Register adds file documentation to the server.
+func (s *Server) Register(doc *encoder.FileDoc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.docs = append(s.docs, doc)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// limit serves the request if a concurrency slot is available. It runs
+// beneath the timeout handler, so the slot is held until the request is
+// actually handled rather than until it times out.
+func (s *Server) limit(w http.ResponseWriter, r *http.Request) {
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		default:
+			writeError(w, http.StatusTooManyRequests, fmt.Errorf("too many concurrent requests"))
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe listens on the address and serves requests.
+func (s *Server) ListenAndServe(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: s.options.Timeout,
+		ReadTimeout:       s.options.Timeout,
+	}
+	if s.options.Timeout > 0 {
+		// leave room for the timeout handler to write its response
+		server.WriteTimeout = s.options.Timeout + time.Second
+	}
+	return server.ListenAndServe()
+}
+
+// lookup returns the file documentation selected by the request.
+func (s *Server) lookup(r *http.Request) (*encoder.FileDoc, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if len(s.docs) == 0 {
+		return nil, fmt.Errorf("no documentation registered")
+	}
+
+	name := r.URL.Query().Get("doc")
+	if name == "" {
+		return s.docs[0], nil
+	}
+	for _, doc := range s.docs {
+		if strings.EqualFold(doc.Name, name) {
+			return doc, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown documentation %q", name)
+}
+
+type validateResponse struct {
+	Valid  bool                      `json:"valid"`
+	Issues []encoder.ValidationError `json:"issues"`
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	doc, err := s.lookup(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	data, err := s.readBody(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errBodyTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, err)
+		return
+	}
+	if r.Context().Err() != nil {
+		// the request timed out or was canceled while reading the body
+		return
+	}
+
+	issues := encoder.Validate(data, doc)
+	if issues == nil {
+		issues = []encoder.ValidationError{}
+	}
+	writeJSON(w, http.StatusOK, &validateResponse{
+		Valid:  len(issues) == 0,
+		Issues: issues,
+	})
+}
+
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	doc, err := s.lookup(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc.JSONSchema())
+}
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// readBody reads the request body up to the configured maximum size.
+func (s *Server) readBody(r *http.Request) ([]byte, error) {
+	if s.options.MaxBodySize <= 0 {
+		return io.ReadAll(r.Body)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, s.options.MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.options.MaxBodySize {
+		return nil, errBodyTooLarge
+	}
+	return data, nil
+}
+
+type explainResponse struct {
+	Path        string   `json:"path"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Values      []string `json:"values,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
+}
+
+func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	doc, err := s.lookup(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	field, err := doc.Field(path)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	response := &explainResponse{
+		Path:        path,
+		Type:        field.Type,
+		Description: field.Description,
+		Values:      field.AllowedValues(),
+	}
+	for _, example := range field.Examples {
+		data, err := encoder.Marshal(example.GetValue(), encoder.WithComments(encoder.CommentsDisabled))
+		if err != nil {
+			continue
+		}
+		response.Examples = append(response.Examples, string(data))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
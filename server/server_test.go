@@ -0,0 +1,190 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/yamldoc-go/encoder"
+	"github.com/stretchr/testify/require"
+)
+
+func testDoc() *encoder.FileDoc {
+	return &encoder.FileDoc{
+		Name: "Job",
+		Structs: []*encoder.Doc{
+			{
+				Type: "Job",
+				Fields: []encoder.Doc{
+					{Name: "name", Type: "string", Description: "name of the job", Required: true},
+					{Name: "retries", Type: "int", Values: []string{"1", "2", "3"}},
+				},
+			},
+		},
+	}
+}
+
+func serve(s *Server, method, target string, body io.Reader) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, body)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestValidate(t *testing.T) {
+	s := New(nil, testDoc())
+
+	w := serve(s, http.MethodPost, "/validate", strings.NewReader("name: build\nretries: 2\n"))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"valid":true,"issues":[]}`, w.Body.String())
+
+	w = serve(s, http.MethodPost, "/validate", strings.NewReader("retries: 5\nnme: build\n"))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response validateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.False(t, response.Valid)
+
+	rules := make([]string, 0, len(response.Issues))
+	for _, issue := range response.Issues {
+		rules = append(rules, issue.Rule)
+	}
+	require.ElementsMatch(t, []string{"invalid-value", "unknown-field", "required-field"}, rules)
+}
+
+func TestSchema(t *testing.T) {
+	w := serve(New(nil, testDoc()), http.MethodGet, "/schema", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var schema encoder.Schema
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &schema))
+	require.Equal(t, "#/definitions/Job", schema.Ref)
+	require.Contains(t, schema.Definitions["Job"].Properties, "name")
+	require.Equal(t, []string{"name"}, schema.Definitions["Job"].Required)
+}
+
+func TestExplain(t *testing.T) {
+	s := New(nil, testDoc())
+
+	w := serve(s, http.MethodGet, "/explain?path=name", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"path":"name","type":"string","description":"name of the job"}`, w.Body.String())
+
+	w = serve(s, http.MethodGet, "/explain?path=retries", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"path":"retries","type":"int","values":["1","2","3"]}`, w.Body.String())
+
+	require.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/explain?path=missing", nil).Code)
+}
+
+func TestLookupDoc(t *testing.T) {
+	other := &encoder.FileDoc{Name: "Other", Structs: []*encoder.Doc{{Type: "Other"}}}
+	s := New(nil, testDoc(), other)
+
+	w := serve(s, http.MethodPost, "/validate?doc=other", strings.NewReader("name: build\n"))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `unknown field \"name\" in Other`)
+
+	for _, target := range []string{"/validate?doc=unknown", "/schema?doc=unknown", "/explain?doc=unknown&path=name"} {
+		method := http.MethodGet
+		if strings.HasPrefix(target, "/validate") {
+			method = http.MethodPost
+		}
+		w := serve(s, method, target, strings.NewReader("name: build\n"))
+		require.Equal(t, http.StatusNotFound, w.Code, target)
+		require.JSONEq(t, `{"error":"unknown documentation \"unknown\""}`, w.Body.String(), target)
+	}
+
+	require.Equal(t, http.StatusNotFound, serve(New(nil), http.MethodGet, "/schema", nil).Code)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	s := New(nil, testDoc())
+
+	tests := []struct {
+		method string
+		target string
+	}{
+		{http.MethodGet, "/validate"},
+		{http.MethodPut, "/validate"},
+		{http.MethodPost, "/schema"},
+		{http.MethodDelete, "/explain?path=name"},
+	}
+	for _, test := range tests {
+		w := serve(s, test.method, test.target, strings.NewReader(""))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, "%s %s", test.method, test.target)
+	}
+}
+
+// blockingReader blocks reads until it is released, signaling the first
+// read on started.
+type blockingReader struct {
+	started  chan struct{}
+	released chan struct{}
+	once     sync.Once
+}
+
+func newBlockingReader() *blockingReader {
+	return &blockingReader{started: make(chan struct{}), released: make(chan struct{})}
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	b.once.Do(func() { close(b.started) })
+	<-b.released
+	return 0, io.EOF
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	s := New(&Options{MaxBodySize: 16}, testDoc())
+
+	w := serve(s, http.MethodPost, "/validate", strings.NewReader("name: build\nretries: 2\n"))
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	require.JSONEq(t, `{"error":"request body too large"}`, w.Body.String())
+
+	w = serve(s, http.MethodPost, "/validate", strings.NewReader("name: build\n"))
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTooManyRequests(t *testing.T) {
+	s := New(&Options{MaxConcurrent: 1}, testDoc())
+
+	body := newBlockingReader()
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(s, http.MethodPost, "/validate", body) }()
+	<-body.started
+
+	w := serve(s, http.MethodGet, "/schema", nil)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.JSONEq(t, `{"error":"too many concurrent requests"}`, w.Body.String())
+
+	close(body.released)
+	require.Equal(t, http.StatusOK, (<-done).Code)
+	require.Equal(t, http.StatusOK, serve(s, http.MethodGet, "/schema", nil).Code)
+}
+
+func TestTimeout(t *testing.T) {
+	s := New(&Options{Timeout: 20 * time.Millisecond, MaxConcurrent: 1}, testDoc())
+
+	body := newBlockingReader()
+	w := serve(s, http.MethodPost, "/validate", body)
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, `{"error":"request timed out"}`, w.Body.String())
+
+	// the timed out request is still being handled and keeps its slot
+	require.Equal(t, http.StatusTooManyRequests, serve(s, http.MethodGet, "/schema", nil).Code)
+
+	close(body.released)
+	require.Eventually(t, func() bool {
+		return serve(s, http.MethodGet, "/schema", nil).Code == http.StatusOK
+	}, time.Second, 5*time.Millisecond)
+}